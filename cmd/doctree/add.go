@@ -18,11 +18,19 @@ Examples:
   Register current directory for auto-indexing:
 
     $ doctree add .
+
+  Register current directory for auto-indexing, including private symbols:
+
+    $ doctree add --include-private .
 `
 	// Parse flags for our subcommand.
 	flagSet := flag.NewFlagSet("add", flag.ExitOnError)
 	dataDirFlag := flagSet.String("data-dir", defaultDataDir(), "where doctree stores its data")
 	projectFlag := flagSet.String("project", defaultProjectName("."), "name of the project")
+	var includeFlag, excludeFlag stringSliceFlag
+	flagSet.Var(&includeFlag, "include", "only index files matching this gitignore-style pattern (may be repeated)")
+	flagSet.Var(&excludeFlag, "exclude", "do not index files matching this gitignore-style pattern (may be repeated)")
+	includePrivateFlag := flagSet.Bool("include-private", false, "also index private symbols, e.g. unexported Go identifiers")
 
 	// Handles calls to our subcommand.
 	handler := func(args []string) error {
@@ -31,8 +39,9 @@ Examples:
 			return &cmder.UsageError{}
 		}
 		dir := flagSet.Arg(0)
-		if dir != "." {
-			*projectFlag = defaultProjectName(dir)
+		project, err := projectName(flagSet, *projectFlag, dir)
+		if err != nil {
+			return err
 		}
 
 		projectPath, err := filepath.Abs(dir)
@@ -49,7 +58,10 @@ Examples:
 
 		// Update the autoIndexProjects array
 		autoIndexedProjects[projectPath] = indexer.AutoIndexedProject{
-			Name: *projectFlag,
+			Name:           project,
+			Include:        includeFlag,
+			Exclude:        excludeFlag,
+			IncludePrivate: *includePrivateFlag,
 		}
 
 		err = indexer.WriteAutoIndex(autoIndexPath, autoIndexedProjects)
@@ -59,7 +71,11 @@ Examples:
 
 		// Run indexers on the newly registered dir
 		ctx := context.Background()
-		return indexer.RunIndexers(ctx, projectPath, *dataDirFlag, *projectFlag)
+		return indexer.RunIndexers(ctx, projectPath, *dataDirFlag, project, indexer.Options{
+			Include:        includeFlag,
+			Exclude:        excludeFlag,
+			IncludePrivate: *includePrivateFlag,
+		})
 	}
 
 	// Register the command.
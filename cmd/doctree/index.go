@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/hexops/cmder"
 
@@ -18,12 +19,41 @@ Examples:
 
     $ doctree index .
 
+  Index all code in the current directory, except for generated code:
+
+    $ doctree index --exclude='*.pb.go' --exclude='gen/' .
+
+  Index all code in the current directory, including private symbols for internal documentation:
+
+    $ doctree index --include-private .
+
+  Index Go code as built for specific platforms (by default linux/amd64, darwin/amd64,
+  windows/amd64 and js/wasm), documenting which declarations are platform-specific:
+
+    $ doctree index --targets=linux/amd64,linux/arm64,windows/amd64 .
+
+  Files ignored by .gitignore or .doctreeignore files are not indexed. Options which should always
+  be used for a project can be set in a doctree.yaml file in the indexed directory:
+
+    project: github.com/example/repo
+    languages: [go, markdown]
+    exclude: [examples/]
+    options:
+      go:
+        unexported: true
+        targets: [linux/amd64, windows/amd64]
+
 `
 
 	// Parse flags for our subcommand.
 	flagSet := flag.NewFlagSet("index", flag.ExitOnError)
 	dataDirFlag := flagSet.String("data-dir", defaultDataDir(), "where doctree stores its data")
 	projectFlag := flagSet.String("project", defaultProjectName("."), "name of the project")
+	var includeFlag, excludeFlag stringSliceFlag
+	flagSet.Var(&includeFlag, "include", "only index files matching this gitignore-style pattern (may be repeated)")
+	flagSet.Var(&excludeFlag, "exclude", "do not index files matching this gitignore-style pattern (may be repeated)")
+	includePrivateFlag := flagSet.Bool("include-private", false, "also index private symbols, e.g. unexported Go identifiers")
+	targetsFlag := flagSet.String("targets", "", "comma-separated platforms to index platform-specific code for, e.g. linux/amd64,windows/amd64")
 
 	// Handles calls to our subcommand.
 	handler := func(args []string) error {
@@ -32,12 +62,23 @@ Examples:
 			return &cmder.UsageError{}
 		}
 		dir := flagSet.Arg(0)
-		if dir != "." {
-			*projectFlag = defaultProjectName(dir)
+		project, err := projectName(flagSet, *projectFlag, dir)
+		if err != nil {
+			return err
+		}
+
+		var targets []string
+		if *targetsFlag != "" {
+			targets = strings.Split(*targetsFlag, ",")
 		}
 
 		ctx := context.Background()
-		return indexer.RunIndexers(ctx, dir, *dataDirFlag, *projectFlag)
+		return indexer.RunIndexers(ctx, dir, *dataDirFlag, project, indexer.Options{
+			Include:        includeFlag,
+			Exclude:        excludeFlag,
+			Targets:        targets,
+			IncludePrivate: *includePrivateFlag,
+		})
 	}
 
 	// Register the command.
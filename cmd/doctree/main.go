@@ -8,9 +8,12 @@ import (
 
 	// Register language indexers.
 	_ "github.com/sourcegraph/doctree/doctree/indexer/golang"
+	_ "github.com/sourcegraph/doctree/doctree/indexer/java"
 	_ "github.com/sourcegraph/doctree/doctree/indexer/javascript"
 	_ "github.com/sourcegraph/doctree/doctree/indexer/markdown"
 	_ "github.com/sourcegraph/doctree/doctree/indexer/python"
+	_ "github.com/sourcegraph/doctree/doctree/indexer/rust"
+	_ "github.com/sourcegraph/doctree/doctree/indexer/typescript"
 	_ "github.com/sourcegraph/doctree/doctree/indexer/zig"
 )
 
@@ -22,6 +22,7 @@ import (
 	"github.com/hexops/cmder"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/doctree/doctree/apischema"
+	"github.com/sourcegraph/doctree/doctree/git"
 	"github.com/sourcegraph/doctree/doctree/indexer"
 	"github.com/sourcegraph/doctree/doctree/schema"
 	"github.com/sourcegraph/doctree/frontend"
@@ -60,7 +61,12 @@ Examples:
 			log.Fatal(err)
 		}
 
-		go Serve(*cloudModeFlag, *httpFlag, *dataDirFlag, indexDataDir)
+		urlTemplates, err := git.ReadURLTemplates(filepath.Join(*dataDirFlag, "url-templates"))
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "ReadURLTemplates"))
+		}
+
+		go Serve(*cloudModeFlag, *httpFlag, *dataDirFlag, indexDataDir, urlTemplates)
 		go func() {
 			err := ListenAutoIndexedProjects(dataDirFlag)
 			if err != nil {
@@ -94,7 +100,9 @@ Examples:
 }
 
 // Serve an HTTP server on the given addr.
-func Serve(cloudMode bool, addr, dataDir, indexDataDir string) {
+//
+// urlTemplates are used to link pages and sections to their source code on the code host.
+func Serve(cloudMode bool, addr, dataDir, indexDataDir string, urlTemplates git.URLTemplates) {
 	log.Printf("Listening on %s", addr)
 	mux := http.NewServeMux()
 	mux.Handle("/", frontendHandler(cloudMode))
@@ -200,15 +208,21 @@ app.ports.observeElementID.subscribe(function(id) {
 					found = &page
 					break
 				}
+			}
+			if found != nil {
+				break
+			}
+		}
+		// Subpages may also be listed as pages of their own (e.g. Python submodules), which are
+		// preferred above, otherwise the page containing the subpage is served.
+		for _, lib := range index.Libraries {
+			for _, page := range lib.Pages {
 				for _, subPage := range page.Subpages {
-					if subPage.Path == pagePath {
+					if found == nil && subPage.Path == pagePath {
+						page := page
 						found = &page
-						break
 					}
 				}
-				if found != nil {
-					break
-				}
 			}
 		}
 		if found == nil {
@@ -216,7 +230,8 @@ app.ports.observeElementID.subscribe(function(id) {
 			return
 		}
 
-		b, err := json.Marshal(apischema.Page(*found))
+		page := indexer.WithPermalinks(*found, index, urlTemplates)
+		b, err := json.Marshal(apischema.Page(page))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -446,10 +461,20 @@ func ListenAutoIndexedProjects(dataDirFlag *string) error {
 	defer watcher.Close()
 
 	// Configure watcher to watch all dirs mentioned in the 'autoindex' file
-	for projectPath := range autoindexedProjects {
+	watchOpts := map[string]indexer.Options{}
+	for projectPath, project := range autoindexedProjects {
+		opts, err := projectOptions(projectPath, indexer.Options{
+			Include: project.Include,
+			Exclude: project.Exclude,
+		})
+		if err != nil {
+			return err
+		}
+		watchOpts[projectPath] = opts
+
 		// Add the project directory to the watcher
 		// TODO: Check if the project changed while the server wasn't running.
-		err = recursiveWatch(watcher, projectPath)
+		err = recursiveWatch(watcher, projectPath, opts)
 		if err != nil {
 			return err
 		}
@@ -475,13 +500,39 @@ func ListenAutoIndexedProjects(dataDirFlag *string) error {
 						return
 					}
 					if isParent {
+						if configuresIndex(ev.Name) {
+							// Which files are indexed may have changed, so update the options used
+							// to filter events and watch any directories which are no longer ignored.
+							opts, err := projectOptions(projectPath, indexer.Options{
+								Include: project.Include,
+								Exclude: project.Exclude,
+							})
+							if err != nil {
+								log.Println(err) // e.g. doctree.yaml is being edited, keep the previous options
+							} else {
+								watchOpts[projectPath] = opts
+								if err := recursiveWatch(watcher, projectPath, opts); err != nil {
+									log.Println(err)
+								}
+							}
+						}
+						if affected, err := affectsIndex(projectPath, watchOpts[projectPath], ev.Name); err != nil {
+							log.Println(err)
+							return
+						} else if !affected {
+							break // Not a file that is indexed, e.g. excluded by doctree.yaml
+						}
 						log.Println("Reindexing", projectPath)
 						ctx := context.Background()
 						if err != nil {
 							log.Println(err)
 							return
 						}
-						err := indexer.RunIndexers(ctx, projectPath, *dataDirFlag, project.Name)
+						err := indexer.RunIndexers(ctx, projectPath, *dataDirFlag, project.Name, indexer.Options{
+							Include:        project.Include,
+							Exclude:        project.Exclude,
+							IncludePrivate: project.IncludePrivate,
+						})
 						if err != nil {
 							log.Fatal(err)
 						}
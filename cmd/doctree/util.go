@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +11,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/doctree/doctree/git"
+	"github.com/sourcegraph/doctree/doctree/indexer"
 )
 
 func defaultDataDir() string {
@@ -32,6 +35,39 @@ func defaultProjectName(defaultDir string) string {
 	return uri
 }
 
+// projectName returns the name of the project in dir. This is the -project flag if it was set
+// explicitly, otherwise the project declared in the directory's doctree.yaml file, otherwise a name
+// derived from the directory (e.g. its git remote.)
+func projectName(flagSet *flag.FlagSet, projectFlag, dir string) (string, error) {
+	explicit := false
+	flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == "project" {
+			explicit = true
+		}
+	})
+	if explicit {
+		return projectFlag, nil
+	}
+	config, err := indexer.ReadConfig(dir)
+	if err != nil {
+		return "", errors.Wrap(err, "ReadConfig")
+	}
+	if config.Project != "" {
+		return config.Project, nil
+	}
+	return defaultProjectName(dir), nil
+}
+
+// stringSliceFlag is a flag which may be specified multiple times, e.g. `--exclude=a --exclude=b`.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 func isParentDir(parent, child string) (bool, error) {
 	relativePath, err := filepath.Rel(parent, child)
 	if err != nil {
@@ -40,18 +76,60 @@ func isParentDir(parent, child string) (bool, error) {
 	return !strings.Contains(relativePath, ".."), nil
 }
 
-// Recursively watch a directory
-func recursiveWatch(watcher *fsnotify.Watcher, dir string) error {
-	err := filepath.Walk(dir, func(walkPath string, fi os.FileInfo, err error) error {
+// projectOptions returns the options the project in dir is indexed with: opts with the project's
+// doctree.yaml configuration applied on top, as indexer.IndexDir does.
+func projectOptions(dir string, opts indexer.Options) (indexer.Options, error) {
+	config, err := indexer.ReadConfig(dir)
+	if err != nil {
+		return opts, errors.Wrap(err, "ReadConfig")
+	}
+	return config.Options(opts), nil
+}
+
+// Recursively watch a directory, excluding directories which would not be indexed with the given
+// options (see projectOptions.)
+func recursiveWatch(watcher *fsnotify.Watcher, dir string, opts indexer.Options) error {
+	return indexer.Walk(dir, opts, func(walkPath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if fi.IsDir() && !strings.HasPrefix(fi.Name(), ".") { // file is directory and isn't hidden
-			if err = watcher.Add(walkPath); err != nil {
+		if d.IsDir() {
+			if walkPath != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir // hidden directory
+			}
+			if err = watcher.Add(filepath.Join(dir, walkPath)); err != nil {
 				return errors.Wrap(err, "watcher.Add")
 			}
 		}
 		return nil
 	})
-	return err
+}
+
+// affectsIndex reports whether a change to the file at path, within the project in dir, may change
+// the project's index: whether the file is indexed with the given options (see projectOptions), or
+// configures what is indexed.
+func affectsIndex(dir string, opts indexer.Options, path string) (bool, error) {
+	if configuresIndex(path) {
+		return true, nil
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false, err
+	}
+	return indexer.Indexed(dir, opts, filepath.ToSlash(rel))
+}
+
+// configuresIndex reports whether the file at path configures which files of a project are indexed,
+// i.e. is a doctree.yaml, .gitignore or .doctreeignore file.
+func configuresIndex(path string) bool {
+	name := filepath.Base(path)
+	if name == indexer.ConfigFile {
+		return true
+	}
+	for _, ignoreFile := range indexer.IgnoreFiles {
+		if name == ignoreFile {
+			return true
+		}
+	}
+	return false
 }
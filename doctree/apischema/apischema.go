@@ -22,4 +22,5 @@ type SearchResult struct {
 	Path        string  `json:"path"`
 	ID          string  `json:"id"`
 	Score       float64 `json:"score"`
+	Deprecated  bool    `json:"deprecated,omitempty"`
 }
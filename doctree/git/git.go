@@ -61,5 +61,5 @@ func RevParse(dir string, abbrefRef bool, rev string) (string, error) {
 	if err != nil {
 		return "", errors.Wrapf(err, "git rev-parse ... (pwd=%s)", cmd.Dir)
 	}
-	return string(out), nil
+	return strings.TrimSpace(string(out)), nil
 }
@@ -0,0 +1,175 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// URLTemplate describes how to link to a file, or a range of lines in a file, at a specific commit
+// on a code host.
+//
+// Templates may contain the following placeholders:
+//
+//	{host}       the code host, e.g. "github.com"
+//	{repo}       the repository path on the host, e.g. "golang/go"
+//	{commit}     the commit SHA
+//	{path}       the file path relative to the repository root
+//	{startLine}  the first line of the range
+//	{endLine}    the last line of the range
+type URLTemplate struct {
+	// File links to an entire file.
+	File string `json:"file"`
+
+	// Lines links to a range of lines in a file.
+	Lines string `json:"lines"`
+}
+
+// URL templates for well-known forges.
+var (
+	GitHub = URLTemplate{
+		File:  "https://{host}/{repo}/blob/{commit}/{path}",
+		Lines: "https://{host}/{repo}/blob/{commit}/{path}#L{startLine}-L{endLine}",
+	}
+	GitLab = URLTemplate{
+		File:  "https://{host}/{repo}/-/blob/{commit}/{path}",
+		Lines: "https://{host}/{repo}/-/blob/{commit}/{path}#L{startLine}-{endLine}",
+	}
+	Bitbucket = URLTemplate{
+		File:  "https://{host}/{repo}/src/{commit}/{path}",
+		Lines: "https://{host}/{repo}/src/{commit}/{path}#lines-{startLine}:{endLine}",
+	}
+	Gitea = URLTemplate{
+		File:  "https://{host}/{repo}/src/commit/{commit}/{path}",
+		Lines: "https://{host}/{repo}/src/commit/{commit}/{path}#L{startLine}-L{endLine}",
+	}
+	Sourcehut = URLTemplate{
+		File:  "https://{host}/{repo}/tree/{commit}/item/{path}",
+		Lines: "https://{host}/{repo}/tree/{commit}/item/{path}#L{startLine}-{endLine}",
+	}
+)
+
+// Forges maps forge names, as used in URL template configuration files, to their templates.
+var Forges = map[string]URLTemplate{
+	"github":    GitHub,
+	"gitlab":    GitLab,
+	"bitbucket": Bitbucket,
+	"gitea":     Gitea,
+	"sourcehut": Sourcehut,
+}
+
+// UnmarshalJSON allows a URLTemplate to be specified as either a forge name (e.g. "gitlab") or an
+// object with explicit templates.
+func (t *URLTemplate) UnmarshalJSON(data []byte) error {
+	var forge string
+	if err := json.Unmarshal(data, &forge); err == nil {
+		template, ok := Forges[forge]
+		if !ok {
+			return fmt.Errorf("unknown forge %q", forge)
+		}
+		*t = template
+		return nil
+	}
+	type plain URLTemplate
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// URLTemplates maps code hosts (e.g. "github.com") to the URL template used to link to source code
+// hosted there.
+type URLTemplates map[string]URLTemplate
+
+// DefaultURLTemplates returns URL templates for well-known public code hosts.
+func DefaultURLTemplates() URLTemplates {
+	return URLTemplates{
+		"github.com":    GitHub,
+		"gitlab.com":    GitLab,
+		"bitbucket.org": Bitbucket,
+		"gitea.com":     Gitea,
+		"codeberg.org":  Gitea,
+		"git.sr.ht":     Sourcehut,
+	}
+}
+
+// ReadURLTemplates reads custom URL templates (e.g. for self-hosted forges) from the JSON file at
+// the given path, merged on top of the DefaultURLTemplates. The file maps code hosts to either a
+// forge name or a URLTemplate object:
+//
+//	{
+//	  "gitlab.example.com": "gitlab",
+//	  "git.example.com": {
+//	    "file": "https://git.example.com/{repo}/browse/{path}?at={commit}",
+//	    "lines": "https://git.example.com/{repo}/browse/{path}?at={commit}#{startLine}-{endLine}"
+//	  }
+//	}
+//
+// If the file does not exist, only the default templates are returned.
+func ReadURLTemplates(path string) (URLTemplates, error) {
+	templates := DefaultURLTemplates()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return templates, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "ReadFile")
+	}
+	var custom URLTemplates
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, errors.Wrap(err, "Unmarshal")
+	}
+	for host, template := range custom {
+		templates[host] = template
+	}
+	return templates, nil
+}
+
+// Permalink returns a URL linking to the given file path at the specified commit, using the
+// template for the repository's code host. If startLine is zero, the URL links to the entire file.
+//
+// The repository is a normalized repository URI as returned by URIForFile, e.g.
+// "github.com/golang/go".
+//
+// Returns an empty string if no template is known for the code host, or if repository or commit
+// are empty.
+func (t URLTemplates) Permalink(repository, commit, path string, startLine, endLine int) string {
+	commit = strings.TrimSpace(commit)
+	if repository == "" || commit == "" {
+		return ""
+	}
+	host, repo, ok := strings.Cut(repository, "/")
+	if !ok {
+		return ""
+	}
+	template, ok := t[host]
+	if !ok {
+		return ""
+	}
+
+	pattern := template.File
+	if startLine > 0 && template.Lines != "" {
+		pattern = template.Lines
+		if endLine < startLine {
+			endLine = startLine
+		}
+	}
+	if pattern == "" {
+		return ""
+	}
+
+	var escapedPath []string
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		escapedPath = append(escapedPath, url.PathEscape(segment))
+	}
+	return strings.NewReplacer(
+		"{host}", host,
+		"{repo}", repo,
+		"{commit}", commit,
+		"{path}", strings.Join(escapedPath, "/"),
+		"{startLine}", strconv.Itoa(startLine),
+		"{endLine}", strconv.Itoa(endLine),
+	).Replace(pattern)
+}
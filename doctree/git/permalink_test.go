@@ -0,0 +1,110 @@
+package git
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func TestURLTemplates_Permalink(t *testing.T) {
+	templates := DefaultURLTemplates()
+	tests := []struct {
+		name                     string
+		repository, commit, path string
+		startLine, endLine       int
+		want                     string
+	}{
+		{
+			name:       "github",
+			repository: "github.com/golang/go",
+			commit:     "abc123\n",
+			path:       "src/net/http/client.go",
+			startLine:  10,
+			endLine:    20,
+			want:       "https://github.com/golang/go/blob/abc123/src/net/http/client.go#L10-L20",
+		},
+		{
+			name:       "github_file",
+			repository: "github.com/golang/go",
+			commit:     "abc123",
+			path:       "README.md",
+			want:       "https://github.com/golang/go/blob/abc123/README.md",
+		},
+		{
+			name:       "gitlab",
+			repository: "gitlab.com/gitlab-org/gitlab",
+			commit:     "abc123",
+			path:       "app/models/user.rb",
+			startLine:  5,
+			endLine:    7,
+			want:       "https://gitlab.com/gitlab-org/gitlab/-/blob/abc123/app/models/user.rb#L5-7",
+		},
+		{
+			name:       "bitbucket",
+			repository: "bitbucket.org/foo/bar",
+			commit:     "abc123",
+			path:       "main.go",
+			startLine:  1,
+			endLine:    3,
+			want:       "https://bitbucket.org/foo/bar/src/abc123/main.go#lines-1:3",
+		},
+		{
+			name:       "gitea",
+			repository: "codeberg.org/foo/bar",
+			commit:     "abc123",
+			path:       "main.go",
+			startLine:  4,
+			want:       "https://codeberg.org/foo/bar/src/commit/abc123/main.go#L4-L4",
+		},
+		{
+			name:       "sourcehut",
+			repository: "git.sr.ht/~foo/bar",
+			commit:     "abc123",
+			path:       "my file.zig",
+			startLine:  2,
+			endLine:    9,
+			want:       "https://git.sr.ht/~foo/bar/tree/abc123/item/my%20file.zig#L2-9",
+		},
+		{
+			name:       "unknown_host",
+			repository: "example.com/foo/bar",
+			commit:     "abc123",
+			path:       "main.go",
+			want:       "",
+		},
+		{
+			name:       "not_a_repository",
+			repository: "",
+			commit:     "",
+			path:       "main.go",
+			want:       "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := templates.Permalink(tc.repository, tc.commit, tc.path, tc.startLine, tc.endLine)
+			autogold.Want(tc.name, tc.want).Equal(t, got)
+		})
+	}
+}
+
+func TestURLTemplate_UnmarshalJSON(t *testing.T) {
+	var templates URLTemplates
+	err := json.Unmarshal([]byte(`{
+		"gitlab.example.com": "gitlab",
+		"git.example.com": {"file": "https://{host}/{repo}/browse/{path}?at={commit}"}
+	}`), &templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	autogold.Want("forge", GitLab).Equal(t, templates["gitlab.example.com"])
+	autogold.Want("custom", URLTemplate{File: "https://{host}/{repo}/browse/{path}?at={commit}"}).Equal(t, templates["git.example.com"])
+	autogold.Want("custom_permalink", "https://git.example.com/foo/bar/browse/main.go?at=abc123").Equal(t,
+		templates.Permalink("git.example.com/foo/bar", "abc123", "main.go", 4, 5),
+	)
+
+	if err := json.Unmarshal([]byte(`{"example.com": "nope"}`), &templates); err == nil {
+		t.Fatal("expected error for unknown forge")
+	}
+}
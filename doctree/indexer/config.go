@@ -0,0 +1,117 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/doctree/doctree/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the name of the per-project configuration file, found at the root of the indexed
+// directory.
+const ConfigFile = "doctree.yaml"
+
+// Config is the per-project configuration read from a doctree.yaml file, so that everyone indexing a
+// project gets the same results without needing to remember the same flags. For example:
+//
+//	project: github.com/sourcegraph/doctree
+//	languages: [go, markdown]
+//	exclude:
+//	  - examples/
+//	  - "*.pb.go"
+//	includePrivate: false
+//	library:
+//	  name: doctree
+//	  version: v0.2.0
+//	options:
+//	  go:
+//	    unexported: true
+type Config struct {
+	// Project name, e.g. "github.com/sourcegraph/doctree". Used unless a project name is given
+	// explicitly on the command line.
+	Project string `yaml:"project"`
+
+	// Languages to index by language ID, e.g. "go". If empty, all languages are indexed.
+	Languages []string `yaml:"languages"`
+
+	// Include and Exclude are gitignore-style patterns of files to index, see Options. They are
+	// used in addition to any given on the command line.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+
+	// IncludePrivate indicates private symbols should be indexed too, see Options.
+	IncludePrivate bool `yaml:"includePrivate"`
+
+	// Library overrides the metadata of the library produced by each indexer. It is ignored for
+	// languages which produce several libraries, e.g. monorepos.
+	Library LibraryConfig `yaml:"library"`
+
+	// LanguageOptions are options specific to a language, by language ID. Each language indexer
+	// documents the options it supports.
+	LanguageOptions map[string]yaml.Node `yaml:"options"`
+}
+
+// LibraryConfig overrides schema.Library metadata. Empty fields are not overridden.
+type LibraryConfig struct {
+	Name        string `yaml:"name"`
+	ID          string `yaml:"id"`
+	Version     string `yaml:"version"`
+	VersionType string `yaml:"versionType"`
+}
+
+// ReadConfig reads the doctree.yaml configuration file in the given directory. If there is none, an
+// empty configuration is returned.
+func ReadConfig(dir string) (*Config, error) {
+	var config Config
+	data, err := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if os.IsNotExist(err) {
+		return &config, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "ReadFile")
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrap(err, ConfigFile)
+	}
+	return &config, nil
+}
+
+// LanguageEnabled reports whether the language with the given ID should be indexed.
+func (c *Config) LanguageEnabled(id string) bool {
+	if len(c.Languages) == 0 {
+		return true
+	}
+	for _, language := range c.Languages {
+		if language == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Options returns opts with the configuration applied on top: its Include and Exclude patterns are
+// used in addition to those of opts, and private symbols are included if either asks for them.
+func (c *Config) Options(opts Options) Options {
+	opts.Include = append(append([]string{}, c.Include...), opts.Include...)
+	opts.Exclude = append(append([]string{}, c.Exclude...), opts.Exclude...)
+	opts.IncludePrivate = opts.IncludePrivate || c.IncludePrivate
+	return opts
+}
+
+// Apply overrides the metadata of the given library as configured.
+func (c LibraryConfig) Apply(library *schema.Library) {
+	if c.Name != "" {
+		library.Name = c.Name
+	}
+	if c.ID != "" {
+		library.ID = c.ID
+	}
+	if c.Version != "" {
+		library.Version = c.Version
+	}
+	if c.VersionType != "" {
+		library.VersionType = c.VersionType
+	}
+}
@@ -0,0 +1,152 @@
+package indexer
+
+import "strings"
+
+// Table formats rows as a Markdown table with the given title, e.g. of the parameters documented by
+// a doc comment. Columns which are empty in every row are omitted.
+func Table(title string, headings []string, rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	var columns []int
+	for c := range headings {
+		for _, row := range rows {
+			if row[c] != "" {
+				columns = append(columns, c)
+				break
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("**" + title + "**\n\n|")
+	for _, c := range columns {
+		b.WriteString(" " + headings[c] + " |")
+	}
+	b.WriteString("\n" + strings.Repeat("| --- ", len(columns)) + "|")
+	for _, row := range rows {
+		b.WriteString("\n|")
+		for _, c := range columns {
+			cell := SingleLine(row[c])
+			b.WriteString(" " + strings.ReplaceAll(cell, "|", `\|`) + " |")
+		}
+	}
+	return b.String()
+}
+
+// Code formats s as inline Markdown code, or returns "" if s is empty.
+func Code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+// SingleLine collapses whitespace, e.g. of a multi-line declaration, to single spaces.
+func SingleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// maxLabel is the length beyond which labels, e.g. of constants with long values, are truncated.
+const maxLabel = 100
+
+// Truncate shortens a label which is too long to display, e.g. that of a constant with a long
+// value.
+func Truncate(label string) string {
+	if len(label) <= maxLabel {
+		return label
+	}
+	return label[:maxLabel] + " ..."
+}
+
+// SearchKey returns the search key of name within the given prefix, e.g. a class's.
+func SearchKey(prefix []string, name string) []string {
+	if len(prefix) == 0 {
+		return []string{name}
+	}
+	return append(append(append([]string{}, prefix...), "."), name)
+}
+
+// SplitBlockTags splits a line of a doc comment before each of the given block tags which follows
+// other text, e.g. "Adds. @deprecated Use plus." into "Adds." and "@deprecated Use plus.", since
+// single-line comments such as `/** Adds. @deprecated Use plus. */` are common. Tags within inline
+// code, e.g. "`@deprecated`", are left as-is.
+func SplitBlockTags(line string, tags ...string) []string {
+	var lines []string
+	start := 0
+	for i := 1; i < len(line); i++ {
+		if line[i] != '@' || (line[i-1] != ' ' && line[i-1] != '\t') || strings.Count(line[start:i], "`")%2 != 0 {
+			continue
+		}
+		for _, tag := range tags {
+			rest := strings.TrimPrefix(line[i:], tag)
+			if len(rest) == len(line[i:]) || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+				continue
+			}
+			if before := strings.TrimSpace(line[start:i]); before != "" {
+				lines = append(lines, before)
+			}
+			start = i
+			break
+		}
+	}
+	return append(lines, line[start:])
+}
+
+// BlockTag is a block tag of a doc comment, e.g. "@param x - The value."
+type BlockTag struct {
+	Name, Text string
+}
+
+// BlockTags splits a JSDoc-style doc comment, e.g. `/** Adds. @param x The value. */`, into the
+// lines of its description and its block tags. The given block tags may follow other text on a
+// line (see SplitBlockTags), except in fenced code and @example tags, whose text is code.
+func BlockTags(comment string, tags ...string) (description []string, blockTags []BlockTag) {
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/**"), "*/")
+	inCode := false
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		line = strings.TrimPrefix(line, " ")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		parts := []string{line}
+		if !inCode && (len(blockTags) == 0 || blockTags[len(blockTags)-1].Name != "@example") {
+			parts = SplitBlockTags(line, tags...)
+		}
+		for _, part := range parts {
+			if !inCode && strings.HasPrefix(part, "@") {
+				name, text, _ := strings.Cut(part, " ")
+				blockTags = append(blockTags, BlockTag{Name: name, Text: text})
+				continue
+			}
+			if len(blockTags) > 0 {
+				blockTags[len(blockTags)-1].Text += "\n" + part
+				continue
+			}
+			description = append(description, part)
+		}
+	}
+	return description, blockTags
+}
+
+// Deprecated formats the text of a @deprecated tag, which may be empty, as a paragraph.
+func Deprecated(text string) string {
+	if text == "" {
+		return "Deprecated."
+	}
+	return "Deprecated: " + text
+}
+
+// Example formats the text of an @example tag as a paragraph. Unless it contains fenced code
+// already, the text is code in the given language.
+func Example(text, language string) string {
+	if !strings.Contains(text, "```") {
+		text = "```" + language + "\n" + text + "\n```"
+	}
+	return "**Example**\n\n" + text
+}
@@ -0,0 +1,62 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func TestBlockTags(t *testing.T) {
+	type result struct {
+		Description []string
+		Tags        []BlockTag
+	}
+	tests := []struct {
+		name, comment string
+		want          autogold.Value
+	}{
+		{
+			name:    "single line",
+			comment: "/** Adds. @deprecated Use `@plus`. */",
+			want: autogold.Want("single line", result{
+				Description: []string{
+					"Adds.",
+				},
+				Tags: []BlockTag{{
+					Name: "@deprecated",
+					Text: "Use `@plus`.",
+				}},
+			}),
+		},
+		{
+			name:    "multi line",
+			comment: "/**\n * Adds.\n *\n * @param x - The value.\n *   Must be positive.\n * @returns The sum.\n */",
+			want: autogold.Want("multi line", result{
+				Description: []string{"", "Adds.", ""},
+				Tags: []BlockTag{
+					{Name: "@param", Text: "x - The value.\n  Must be positive."},
+					{Name: "@returns", Text: "The sum.\n"},
+				},
+			}),
+		},
+		{
+			name:    "example",
+			comment: "/**\n * @example\n * add(1, 2) @returns 3\n * ```\n * @param x\n * ```\n */",
+			want: autogold.Want("example", result{
+				Description: []string{
+					"",
+				},
+				Tags: []BlockTag{{
+					Name: "@example",
+					Text: "\nadd(1, 2) @returns 3\n```\n@param x\n```\n",
+				}},
+			}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			description, tags := BlockTags(tc.comment, "@param", "@returns", "@deprecated", "@example")
+			tc.want.Equal(t, result{Description: description, Tags: tags})
+		})
+	}
+}
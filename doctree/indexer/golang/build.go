@@ -0,0 +1,144 @@
+package golang
+
+import (
+	"bufio"
+	"bytes"
+	"go/build/constraint"
+	"path"
+	"strings"
+)
+
+// defaultTargets are the platforms Go code is indexed for by default, the same as pkg.go.dev.
+var defaultTargets = []string{"linux/amd64", "darwin/amd64", "windows/amd64", "js/wasm"}
+
+// Known GOOS and GOARCH values, used to recognize file name suffixes such as _linux.go. See
+// go/build/syslist.go
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	unixOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "linux": true, "netbsd": true, "openbsd": true,
+		"solaris": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true, "sparc": true,
+		"sparc64": true, "wasm": true,
+	}
+)
+
+// fileTargets returns the targets (e.g. "linux/amd64") out of those given that the Go file at path
+// is built for, according to its file name (e.g. foo_windows.go) and build constraints.
+func fileTargets(filePath string, content []byte, targets []string) []string {
+	expr := buildConstraint(content)
+	var matched []string
+	for _, target := range targets {
+		goos, goarch, _ := strings.Cut(target, "/")
+		if !matchFileName(path.Base(filePath), goos, goarch) {
+			continue
+		}
+		if expr != nil && !expr.Eval(func(tag string) bool { return matchTag(tag, goos, goarch) }) {
+			continue
+		}
+		matched = append(matched, target)
+	}
+	return matched
+}
+
+// buildConstraint returns the build constraint of a Go file given by //go:build or (for older code)
+// // +build lines before the package clause, or nil if there is none.
+func buildConstraint(content []byte) constraint.Expr {
+	var plusBuild []constraint.Expr
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*") {
+			continue
+		}
+		if !strings.HasPrefix(line, "//") {
+			break // package clause
+		}
+		if constraint.IsGoBuild(line) {
+			expr, err := constraint.Parse(line)
+			if err != nil {
+				return nil
+			}
+			return expr // takes precedence over +build lines
+		}
+		if constraint.IsPlusBuild(line) {
+			if expr, err := constraint.Parse(line); err == nil {
+				plusBuild = append(plusBuild, expr)
+			}
+		}
+	}
+	if len(plusBuild) == 0 {
+		return nil
+	}
+	// Multiple +build lines must all be satisfied.
+	expr := plusBuild[0]
+	for _, other := range plusBuild[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: other}
+	}
+	return expr
+}
+
+// matchFileName reports whether a file with the given name is built for goos/goarch, according to
+// its _GOOS, _GOARCH or _GOOS_GOARCH suffix if any.
+func matchFileName(name, goos, goarch string) bool {
+	name = strings.TrimSuffix(name, ".go")
+	name = strings.TrimSuffix(name, "_test")
+	parts := strings.Split(name, "_")
+	if len(parts) < 2 {
+		return true // no suffix (the part before the first underscore is never considered)
+	}
+	last := parts[len(parts)-1]
+	if len(parts) >= 3 && knownOS[parts[len(parts)-2]] && knownArch[last] {
+		return matchOS(parts[len(parts)-2], goos) && last == goarch
+	}
+	if knownOS[last] {
+		return matchOS(last, goos)
+	}
+	if knownArch[last] {
+		return last == goarch
+	}
+	return true
+}
+
+// matchTag reports whether a build tag is satisfied when building for goos/goarch. cgo is assumed
+// to be enabled, except for js/wasm, and every Go release tag (e.g. go1.18) is satisfied.
+func matchTag(tag, goos, goarch string) bool {
+	switch {
+	case tag == "unix":
+		return unixOS[goos]
+	case tag == "cgo":
+		return goos != "js"
+	case tag == "gc":
+		return true
+	case strings.HasPrefix(tag, "go1."):
+		return true
+	}
+	return matchOS(tag, goos) || tag == goarch
+}
+
+// matchOS reports whether the GOOS name in a build tag or file name is satisfied by goos, taking
+// into account that e.g. "linux" files are also built for android.
+func matchOS(name, goos string) bool {
+	switch {
+	case name == goos:
+		return true
+	case name == "linux" && goos == "android":
+		return true
+	case name == "solaris" && goos == "illumos":
+		return true
+	case name == "darwin" && goos == "ios":
+		return true
+	}
+	return false
+}
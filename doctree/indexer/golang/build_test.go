@@ -0,0 +1,130 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func Test_buildConstraint(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "go-build",
+			content: "// Copyright 2022.\n\n//go:build linux && !arm64\n\npackage foo\n",
+			want:    "linux && !arm64",
+		},
+		{
+			name:    "plus-build",
+			content: "// +build linux darwin\n// +build amd64\n\npackage foo\n",
+			want:    "(linux || darwin) && amd64",
+		},
+		{
+			name:    "go-build-takes-precedence",
+			content: "//go:build windows\n// +build linux\n\npackage foo\n",
+			want:    "windows",
+		},
+		{
+			name:    "after-block-comment",
+			content: "/*\n * Copyright 2022.\n */\n\n//go:build js\n\npackage foo\n",
+			want:    "js",
+		},
+		{
+			name:    "after-package-clause",
+			content: "package foo\n\n//go:build linux\n",
+			want:    "",
+		},
+		{
+			name:    "invalid",
+			content: "//go:build linux &&\n\npackage foo\n",
+			want:    "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			if expr := buildConstraint([]byte(tc.content)); expr != nil {
+				got = expr.String()
+			}
+			autogold.Want(tc.name, tc.want).Equal(t, got)
+		})
+	}
+}
+
+func Test_matchFileName(t *testing.T) {
+	targets := append(append([]string{}, defaultTargets...), "android/arm64", "darwin/arm64", "ios/arm64")
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "foo.go", want: targets},
+		{name: "linux.go", want: targets},
+		{name: "foo_linux.go", want: []string{"linux/amd64", "android/arm64"}},
+		{name: "foo_linux_test.go", want: []string{"linux/amd64", "android/arm64"}},
+		{name: "foo_windows_amd64.go", want: []string{"windows/amd64"}},
+		{name: "foo_arm64.go", want: []string{"android/arm64", "darwin/arm64", "ios/arm64"}},
+		{name: "foo_darwin.go", want: []string{"darwin/amd64", "darwin/arm64", "ios/arm64"}},
+		{name: "foo_bar.go", want: targets},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, target := range targets {
+				goos, goarch, _ := strings.Cut(target, "/")
+				if matchFileName(tc.name, goos, goarch) {
+					got = append(got, target)
+				}
+			}
+			autogold.Want(tc.name, tc.want).Equal(t, got)
+		})
+	}
+}
+
+func Test_fileTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "unconstrained",
+			path:    "foo.go",
+			content: "package foo\n",
+			want:    defaultTargets,
+		},
+		{
+			name:    "unix",
+			path:    "foo.go",
+			content: "//go:build unix\n\npackage foo\n",
+			want:    []string{"linux/amd64", "darwin/amd64"},
+		},
+		{
+			name:    "cgo",
+			path:    "foo.go",
+			content: "// +build cgo\n\npackage foo\n",
+			want:    []string{"linux/amd64", "darwin/amd64", "windows/amd64"},
+		},
+		{
+			name:    "file-name-and-constraint",
+			path:    "pkg/foo_linux.go",
+			content: "//go:build go1.18 && !arm64\n\npackage foo\n",
+			want:    []string{"linux/amd64"},
+		},
+		{
+			name:    "ignored",
+			path:    "foo.go",
+			content: "//go:build ignore\n\npackage main\n",
+			want:    nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			autogold.Want(tc.name, tc.want).Equal(t, fileTargets(tc.path, []byte(tc.content), defaultTargets))
+		})
+	}
+}
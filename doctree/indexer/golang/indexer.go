@@ -5,10 +5,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"go/token"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 	doc "github.com/slimsag/godocmd"
@@ -16,6 +19,7 @@ import (
 	"github.com/smacker/go-tree-sitter/golang"
 	"github.com/sourcegraph/doctree/doctree/indexer"
 	"github.com/sourcegraph/doctree/doctree/schema"
+	"golang.org/x/mod/modfile"
 )
 
 func init() {
@@ -29,440 +33,262 @@ func (i *goIndexer) Name() schema.Language { return schema.LanguageGo }
 
 func (i *goIndexer) Extensions() []string { return []string{"go"} }
 
-func (i *goIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index, error) {
+func (i *goIndexer) IndexDir(ctx context.Context, dir string, opts indexer.Options) (*schema.Index, error) {
+	var options goOptions
+	if err := opts.DecodeLanguageOptions(&options); err != nil {
+		return nil, errors.Wrap(err, "DecodeLanguageOptions")
+	}
+	if opts.IncludePrivate {
+		options.Unexported = true
+	}
+
+	targets := defaultTargets
+	if len(options.Targets) > 0 {
+		targets = options.Targets
+	}
+	if len(opts.Targets) > 0 {
+		targets = opts.Targets
+	}
+
 	// Find Go sources
-	var sources []string
-	if err := fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err // error walking dir
-		}
-		if !d.IsDir() {
-			ext := filepath.Ext(path)
-			if ext == ".go" {
-				sources = append(sources, path)
-			}
-		}
-		return nil
-	}); err != nil {
-		return nil, errors.Wrap(err, "WalkDir")
+	sources, err := indexer.Sources(dir, opts, ".go")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sources")
 	}
 
 	files := 0
 	bytes := 0
-	packages := map[string]packageInfo{}
-	constsByPackage := map[string][]schema.Section{}
-	varsByPackage := map[string][]schema.Section{}
-	typesByPackage := map[string][]schema.Section{}
-	functionsByPackage := map[string][]schema.Section{}
-	methodsByType := map[string][]schema.Section{}
+	var parsed []*goFile
+	platformsByFile := map[string][]string{}
+	dirFS := os.DirFS(dir)
 	for _, path := range sources {
-		if strings.HasSuffix(path, "_test.go") {
-			continue
-		}
-		dirFS := os.DirFS(dir)
 		content, err := fs.ReadFile(dirFS, path)
 		if err != nil {
 			return nil, errors.Wrap(err, "ReadFile")
 		}
+		platforms := fileTargets(path, content, targets)
+		if len(platforms) == 0 {
+			continue // not built for any target, e.g. //go:build ignore
+		}
+		platformsByFile[path] = platforms
 		files += 1
 		bytes += len(content)
 
-		// Parse the file with tree-sitter.
-		parser := sitter.NewParser()
-		defer parser.Close()
-		parser.SetLanguage(golang.GetLanguage())
-
-		tree, err := parser.ParseCtx(ctx, nil, content)
-		if err != nil {
-			return nil, errors.Wrap(err, "ParseCtx")
-		}
-		defer tree.Close()
-
-		// Inspect the root node.
-		n := tree.RootNode()
-
-		// Package clauses
-		var pkgName string
-		{
-			query, err := sitter.NewQuery([]byte(`
-				(
-					(comment)* @package_docs
-					.
-					(package_clause
-						(package_identifier) @package_name
-					) @package_clause
-					(#set-adjacent! @package_docs @package_name)
-				)
-			`), golang.GetLanguage())
+		file := &goFile{}
+		if !opts.Cache.Get(path, content, file) {
+			if isTestFile(path) {
+				file, err = indexExamples(ctx, path, content)
+			} else {
+				file, err = indexFile(ctx, path, content, options)
+			}
 			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
+				return nil, errors.Wrap(err, path)
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				pkgClause := firstCaptureContentOr(content, captures["package_clause"], "")
-				pkgDocs := commentsToMarkdown(content, extractPackageDocs(captures["package_docs"], captures["package_clause"]))
-				_ = pkgClause // TODO: use me!
-				pkgName = firstCaptureContentOr(content, captures["package_name"], "")
-
-				if existing, ok := packages[pkgName]; ok {
-					if pkgDocs != "" {
-						existing.docs += "\n\n"
-						existing.docs += pkgDocs
-					}
-					packages[pkgName] = existing
-				} else {
-					dir := filepath.Dir(path)
-					if dir == "." {
-						dir = "/"
-					}
-					packages[pkgName] = packageInfo{path: dir, docs: pkgDocs}
-				}
+			if err := opts.Cache.Put(path, content, file); err != nil {
+				return nil, errors.Wrap(err, "Put")
 			}
 		}
+		parsed = append(parsed, file)
+	}
 
-		// Function definitions
-		{
-			query, err := sitter.NewQuery([]byte(`
-				(
-					(comment)* @func_docs
-					.
-					(function_declaration
-						name: (identifier) @func_name
-						type_parameters: (type_parameter_list)? @func_type_params
-						parameters: (parameter_list)? @func_params
-						result: (_)? @func_result
-					)
-				)
-			`), golang.GetLanguage())
-			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
-			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				funcDocs := commentsToMarkdown(content, captures["func_docs"])
-				funcName := firstCaptureContentOr(content, captures["func_name"], "")
-				funcTypeParams := firstCaptureContentOr(content, captures["func_type_params"], "")
-				funcParams := firstCaptureContentOr(content, captures["func_params"], "")
-				funcResult := firstCaptureContentOr(content, captures["func_result"], "")
-
-				firstRune := []rune(funcName)[0]
-				if string(firstRune) != strings.ToUpper(string(firstRune)) || string(firstRune) == "_" {
-					continue // unexported
-				}
+	libraries, err := findLibraries(dir, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "findLibraries")
+	}
 
-				funcLabel := schema.Markdown("func " + funcName + funcTypeParams + funcParams)
-				if funcResult != "" {
-					funcLabel = funcLabel + schema.Markdown(" "+funcResult)
+	// Packages are keyed by directory, as packages in different directories may have the same
+	// name (e.g. several "internal/util" packages.)
+	packages := map[string]packageInfo{}
+	constsByPackage := map[string][]schema.Section{}
+	varsByPackage := map[string][]schema.Section{}
+	typesByPackage := map[string][]schema.Section{}
+	functionsByPackage := map[string][]schema.Section{}
+	methodsByType := map[string][]schema.Section{}
+	typedConstsByType := map[string][]schema.Section{}
+	typedVarsByType := map[string][]schema.Section{}
+	constructorsByType := map[string][]schema.Section{}
+	funcResults := map[string][]string{}
+	embeddedByType := map[string][]string{}
+	examplesByPackage := map[string][]goExample{}
+	for _, file := range parsed {
+		pkgDir := file.PkgDir
+		if isTestFile(file.Path) {
+			// Examples may be in an external test package (e.g. "foo_test"), so are matched to the
+			// package by directory.
+			examplesByPackage[pkgDir] = append(examplesByPackage[pkgDir], file.Examples...)
+			continue
+		}
+		if existing, ok := packages[pkgDir]; ok {
+			if file.PkgDocs != "" {
+				if existing.docs == "" {
+					// Prefer pointing at the file with package docs, e.g. doc.go
+					existing.location = &schema.Location{Path: file.Path}
 				}
-				funcs := functionsByPackage[pkgName]
-				funcs = append(funcs, schema.Section{
-					ID:         funcName,
-					ShortLabel: funcName,
-					Label:      funcLabel,
-					Detail:     schema.Markdown(funcDocs),
-					SearchKey:  []string{pkgName, ".", funcName},
-				})
-				functionsByPackage[pkgName] = funcs
+				existing.docs += "\n\n"
+				existing.docs += file.PkgDocs
+			}
+			packages[pkgDir] = existing
+		} else {
+			packages[pkgDir] = packageInfo{
+				name:     file.PkgName,
+				path:     importPath(libraries, pkgDir),
+				docs:     file.PkgDocs,
+				location: &schema.Location{Path: file.Path},
 			}
 		}
-
-		// Method definitions
-		{
-			query, err := sitter.NewQuery([]byte(`
-			(
-				(comment)* @method_docs
-				.
-				(method_declaration
-					receiver: (parameter_list
-					   (parameter_declaration 
-						   (type_identifier
-						   
-						   ) @type_identifier
-					   ) 
-					) @method_receiver
-					name: (field_identifier) @method_name
-					parameters: (parameter_list)? @method_params
-					result: (_)? @method_result
-				)
-			)
-			`), golang.GetLanguage())
-			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
+		// Declarations in platform-specific files may be declared in several files, for different
+		// platforms.
+		platforms := platformsByFile[file.Path]
+		constsByPackage[pkgDir] = mergePlatforms(constsByPackage[pkgDir], file.Consts, platforms)
+		varsByPackage[pkgDir] = mergePlatforms(varsByPackage[pkgDir], file.Vars, platforms)
+		typesByPackage[pkgDir] = mergePlatforms(typesByPackage[pkgDir], file.Types, platforms)
+		functionsByPackage[pkgDir] = mergePlatforms(functionsByPackage[pkgDir], file.Funcs, platforms)
+		for typeName, methods := range file.Methods {
+			key := pkgDir + "." + typeName
+			methodsByType[key] = mergePlatforms(methodsByType[key], methods, platforms)
+		}
+		for typeName, consts := range file.TypedConsts {
+			key := pkgDir + "." + typeName
+			typedConstsByType[key] = mergePlatforms(typedConstsByType[key], consts, platforms)
+		}
+		for typeName, vars := range file.TypedVars {
+			key := pkgDir + "." + typeName
+			typedVarsByType[key] = mergePlatforms(typedVarsByType[key], vars, platforms)
+		}
+		for funcName, resultTypes := range file.FuncResults {
+			funcResults[pkgDir+"."+funcName] = resultTypes
+		}
+		for typeName, embedded := range file.Embedded {
+			embeddedByType[pkgDir+"."+typeName] = embedded
+		}
+	}
+	for pkgDir := range packages {
+		// Constants and variables of a type the package does not declare are shown with the rest.
+		declared := map[string]bool{}
+		for _, typ := range typesByPackage[pkgDir] {
+			declared[typ.ID] = true
+		}
+		for key, consts := range typedConstsByType {
+			if typePkgDir, typeName := splitTypeKey(key); typePkgDir == pkgDir && !declared[typeName] {
+				constsByPackage[pkgDir] = append(constsByPackage[pkgDir], consts...)
+				delete(typedConstsByType, key)
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				methodDocs := commentsToMarkdown(content, captures["method_docs"])
-				methodName := firstCaptureContentOr(content, captures["method_name"], "")
-				methodReceiver := firstCaptureContentOr(content, captures["method_receiver"], "")
-				methodTypeIdentifier := firstCaptureContentOr(content, captures["type_identifier"], "")
-				methodParams := firstCaptureContentOr(content, captures["method_params"], "")
-				methodResult := firstCaptureContentOr(content, captures["method_result"], "")
-
-				firstRune := []rune(methodName)[0]
-				if string(firstRune) != strings.ToUpper(string(firstRune)) || string(firstRune) == "_" {
-					continue // unexported
-				}
-
-				methodLabel := schema.Markdown("func " + methodReceiver + " " + methodName + methodParams)
-				if methodResult != "" {
-					methodLabel = methodLabel + schema.Markdown(" "+methodResult)
-				}
-				methods := methodsByType[methodTypeIdentifier]
-				methods = append(methods, schema.Section{
-					ID:         methodName,
-					ShortLabel: methodName,
-					Label:      methodLabel,
-					Detail:     schema.Markdown(methodDocs),
-					SearchKey:  []string{pkgName, ".", methodName},
-				})
-				methodsByType[methodTypeIdentifier] = methods
-			}
-		}
-
-		// Type declarations
-		{
-			query, err := sitter.NewQuery([]byte(`
-				(source_file
-					(_)?
-					(comment)* @type_docs
-					.
-					(type_declaration
-						(type_spec
-							name: (type_identifier) @type_name
-							type: [
-								(struct_type) @type_struct
-								(interface_type) @type_interface
-								(function_type) @type_func
-
-								(generic_type) @type_other
-								(qualified_type) @type_other
-								(pointer_type) @type_other
-								(array_type) @type_other
-								(slice_type) @type_other
-								(map_type) @type_other
-								(channel_type) @type_other
-							]
-						)
-					)
-				)
-			`), golang.GetLanguage())
-			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
+		}
+		for key, vars := range typedVarsByType {
+			if typePkgDir, typeName := splitTypeKey(key); typePkgDir == pkgDir && !declared[typeName] {
+				varsByPackage[pkgDir] = append(varsByPackage[pkgDir], vars...)
+				delete(typedVarsByType, key)
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				typeDocs := commentsToMarkdown(content, captures["type_docs"])
-				typeName := firstCaptureContentOr(content, captures["type_name"], "")
-
-				typeStruct := firstCaptureContentOr(content, captures["type_struct"], "")
-				typeInterface := firstCaptureContentOr(content, captures["type_interface"], "")
-				typeFunc := firstCaptureContentOr(content, captures["type_func"], "")
-				typeOther := firstCaptureContentOr(content, captures["type_other"], "")
-
-				firstRune := []rune(typeName)[0]
-				if string(firstRune) != strings.ToUpper(string(firstRune)) || string(firstRune) == "_" {
-					continue // unexported
-				}
-
-				var typeLabel schema.Markdown
-				var typeDefinition string
-				if typeStruct != "" {
-					typeLabel = schema.Markdown(fmt.Sprintf("type %s struct", typeName))
-					typeDefinition = fmt.Sprintf("type %s %s", typeName, typeStruct)
-				} else if typeInterface != "" {
-					typeLabel = schema.Markdown(fmt.Sprintf("type %s interface", typeName))
-					typeDefinition = fmt.Sprintf("type %s %s", typeName, typeInterface)
-				} else if typeFunc != "" {
-					typeLabel = schema.Markdown(fmt.Sprintf("type %s func", typeName))
-					typeDefinition = fmt.Sprintf("type %s %s", typeName, typeFunc)
-				} else {
-					firstLine := strings.Split(typeOther, "\n")[0]
-					typeLabel = schema.Markdown(fmt.Sprintf("type %s %s", typeName, firstLine))
-					typeDefinition = fmt.Sprintf("type %s %s", typeName, typeOther)
-				}
+		}
 
-				types := typesByPackage[pkgName]
-				typeSchemaSection := schema.Section{
-					ID:         typeName,
-					ShortLabel: typeName,
-					Label:      typeLabel,
-					Detail:     schema.Markdown(fmt.Sprintf("```go\n%s\n```\n\n%s", typeDefinition, typeDocs)),
-					SearchKey:  []string{pkgName, ".", typeName},
+		// Functions returning exactly one of the package's types (e.g. NewClient returning *Client,
+		// or *Client and an error) are shown under that type, like godoc.
+		var functions []schema.Section
+		for _, function := range functionsByPackage[pkgDir] {
+			var returned []string
+			for _, typeName := range funcResults[pkgDir+"."+function.ID] {
+				if declared[typeName] {
+					returned = append(returned, typeName)
 				}
-				if methodSchemaSection, methodExist := methodsByType[typeName]; methodExist {
-					typeSchemaSection.Children = methodSchemaSection
-				}
-				types = append(types, typeSchemaSection)
-				typesByPackage[pkgName] = types
-			}
-		}
-
-		// Constants/variables
-		gatherConstsVars := func(constOrVar string, byPackage *map[string][]schema.Section) error {
-			query, err := sitter.NewQuery([]byte(fmt.Sprintf(`
-				(source_file
-					(_)?
-					(comment)* @group_docs
-					.
-					(%s_declaration
-						(_)?
-						(comment)* @docs
-						.
-						(%s_spec
-							name: (identifier) @name
-							type: (_)? @type
-							value: (_) @value
-						)
-					)
-				)
-			`, constOrVar, constOrVar)), golang.GetLanguage())
-			if err != nil {
-				return errors.Wrap(err, "NewQuery")
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				groupDocs := commentsToMarkdown(content, captures["group_docs"])
-				docs := commentsToMarkdown(content, captures["docs"])
-				name := firstCaptureContentOr(content, captures["name"], "")
-				typ := firstCaptureContentOr(content, captures["type"], "")
-				value := firstCaptureContentOr(content, captures["value"], "")
-
-				firstRune := []rune(name)[0]
-				if string(firstRune) != strings.ToUpper(string(firstRune)) || string(firstRune) == "_" {
-					continue // unexported
-				}
-
-				// TODO: right now group docs are discarded, we should emit them somehow.
-				_ = groupDocs
-				_ = typ
-
-				definition := fmt.Sprintf("%s %s = %s", constOrVar, name, value)
-
-				sections := (*byPackage)[pkgName]
-				sections = append(sections, schema.Section{
-					ID:         name,
-					ShortLabel: constOrVar + " " + name,
-					Label:      schema.Markdown(constOrVar + " " + name),
-					Detail:     schema.Markdown(fmt.Sprintf("```go\n%s\n```\n\n%s", definition, docs)),
-					SearchKey:  []string{pkgName, ".", name},
-				})
-				(*byPackage)[pkgName] = sections
+			if len(returned) != 1 {
+				functions = append(functions, function)
+				continue
 			}
-			return nil
+			key := pkgDir + "." + returned[0]
+			constructorsByType[key] = append(constructorsByType[key], function)
+		}
+		if len(functions) > 0 {
+			functionsByPackage[pkgDir] = functions
+		} else {
+			delete(functionsByPackage, pkgDir)
 		}
-		if err := gatherConstsVars("const", &constsByPackage); err != nil {
-			return nil, err
+	}
+	for _, sections := range []map[string][]schema.Section{constsByPackage, varsByPackage, typesByPackage, functionsByPackage, methodsByType, typedConstsByType, typedVarsByType, constructorsByType} {
+		for _, sections := range sections {
+			normalizePlatforms(sections, targets)
 		}
-		if err := gatherConstsVars("var", &varsByPackage); err != nil {
-			return nil, err
+	}
+	for pkgDir, types := range typesByPackage {
+		for i, typ := range types {
+			// Fields, then constants and variables of the type, then constructors, then methods, then
+			// methods promoted from embedded types.
+			key := pkgDir + "." + typ.ID
+			own := append(append([]schema.Section{}, typ.Children...), methodsByType[key]...)
+			children := append([]schema.Section{}, typ.Children...)
+			children = append(children, typedConstsByType[key]...)
+			children = append(children, typedVarsByType[key]...)
+			children = append(children, constructorsByType[key]...)
+			children = append(children, methodsByType[key]...)
+			children = append(children, promotedMethods(pkgDir, typ.ID, own, methodsByType, embeddedByType)...)
+			types[i].Children = children
 		}
 	}
 
 	var pages []schema.Page
-	for pkgName, pkgInfo := range packages {
+	for pkgDir, pkgInfo := range packages {
+		pkgSearchKey := importPathSearchKey(pkgInfo.path)
+		if len(pkgSearchKey) == 0 {
+			pkgSearchKey = []string{pkgInfo.name} // root package outside of any module
+		}
 		topLevelSections := []schema.Section{}
 
-		if len(constsByPackage[pkgName]) > 0 {
+		if len(constsByPackage[pkgDir]) > 0 {
 			topLevelSections = append(topLevelSections, schema.Section{
 				ID:         "const",
 				ShortLabel: "const",
 				Label:      "Constants",
 				Category:   true,
 				SearchKey:  []string{},
-				Children:   constsByPackage[pkgName],
+				Children:   withImportPath(pkgSearchKey, constsByPackage[pkgDir]),
 			})
 		}
-		if len(varsByPackage[pkgName]) > 0 {
+		if len(varsByPackage[pkgDir]) > 0 {
 			topLevelSections = append(topLevelSections, schema.Section{
 				ID:         "var",
 				ShortLabel: "var",
 				Label:      "Variables",
 				Category:   true,
 				SearchKey:  []string{},
-				Children:   varsByPackage[pkgName],
+				Children:   withImportPath(pkgSearchKey, varsByPackage[pkgDir]),
 			})
 		}
-		if len(typesByPackage[pkgName]) > 0 {
+		if len(typesByPackage[pkgDir]) > 0 {
 			topLevelSections = append(topLevelSections, schema.Section{
 				ID:         "type",
 				ShortLabel: "type",
 				Label:      "Types",
 				Category:   true,
 				SearchKey:  []string{},
-				Children:   typesByPackage[pkgName],
+				Children:   withImportPath(pkgSearchKey, typesByPackage[pkgDir]),
 			})
 		}
-		if len(functionsByPackage[pkgName]) > 0 {
+		if len(functionsByPackage[pkgDir]) > 0 {
 			topLevelSections = append(topLevelSections, schema.Section{
 				ID:         "func",
 				ShortLabel: "func",
 				Label:      "Functions",
 				Category:   true,
 				SearchKey:  []string{},
-				Children:   functionsByPackage[pkgName],
+				Children:   withImportPath(pkgSearchKey, functionsByPackage[pkgDir]),
 			})
 		}
 
+		pkgDocs := pkgInfo.docs
+		for _, example := range examplesByPackage[pkgDir] {
+			if example.Target == "" {
+				pkgDocs += "\n\n" + example.markdown()
+			}
+		}
+		attachExamples(topLevelSections, examplesByPackage[pkgDir])
+		markDeprecated(topLevelSections)
+
 		pages = append(pages, schema.Page{
 			Path:      pkgInfo.path,
-			Title:     "Package " + pkgName,
-			Detail:    schema.Markdown(pkgInfo.docs),
-			SearchKey: []string{pkgName},
+			Title:     "Package " + pkgInfo.name,
+			Detail:    schema.Markdown(strings.TrimSpace(pkgDocs)),
+			SearchKey: pkgSearchKey,
+			Location:  pkgInfo.location,
 			Sections:  topLevelSections,
 		})
 	}
@@ -472,18 +298,1036 @@ func (i *goIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index, er
 		Language:      schema.LanguageGo,
 		NumFiles:      files,
 		NumBytes:      bytes,
-		Libraries: []schema.Library{
-			{
-				Name:        "TODO",
-				ID:          "TODO",
-				Version:     "TODO",
-				VersionType: "TODO",
-				Pages:       pages,
-			},
-		},
+		Libraries:     indexer.GroupLibraries(libraries, pages, indexer.DefaultLibrary(dir)),
 	}, nil
 }
 
+// findLibraries finds Go modules in dir, i.e. directories containing a go.mod file.
+func findLibraries(dir string, opts indexer.Options) ([]indexer.LibraryRoot, error) {
+	modFiles, err := indexer.FindFiles(dir, opts, "go.mod")
+	if err != nil {
+		return nil, errors.Wrap(err, "FindFiles")
+	}
+	var libraries []indexer.LibraryRoot
+	for _, modFile := range modFiles {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(modFile)))
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadFile")
+		}
+		modulePath := modfile.ModulePath(content)
+		if modulePath == "" {
+			continue
+		}
+		libraries = append(libraries, indexer.LibraryRoot{
+			Dir: path.Dir(modFile),
+			Library: schema.Library{
+				Name: modulePath,
+				ID:   modulePath,
+			},
+		})
+	}
+	return libraries, nil
+}
+
+// importPath returns the import path of the package in the given directory, based on the module
+// it belongs to. If it is not within a module, the directory itself is used.
+func importPath(libraries []indexer.LibraryRoot, pkgDir string) string {
+	var module *indexer.LibraryRoot
+	for i, library := range libraries {
+		if pkgDir != library.Dir && library.Dir != "." && !strings.HasPrefix(pkgDir, library.Dir+"/") {
+			continue
+		}
+		if module == nil || len(library.Dir) > len(module.Dir) {
+			module = &libraries[i]
+		}
+	}
+	if module == nil {
+		if pkgDir == "." {
+			return "/"
+		}
+		return pkgDir
+	}
+	if pkgDir == module.Dir {
+		return module.Library.ID
+	}
+	return path.Join(module.Library.ID, strings.TrimPrefix(pkgDir, module.Dir+"/"))
+}
+
+// importPathSearchKey returns the search key for an import path, e.g. ["net", "/", "http"].
+func importPathSearchKey(importPath string) []string {
+	var key []string
+	for _, part := range strings.Split(strings.Trim(importPath, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if len(key) > 0 {
+			key = append(key, "/")
+		}
+		key = append(key, part)
+	}
+	return key
+}
+
+// withImportPath prefixes the search keys of the given sections and their children, which are
+// relative to the package, with the search key of the package's import path.
+func withImportPath(pkgSearchKey []string, sections []schema.Section) []schema.Section {
+	for i := range sections {
+		// Groups of constants have no search key, their members do.
+		if len(sections[i].SearchKey) > 0 {
+			key := append(append([]string{}, pkgSearchKey...), ".")
+			sections[i].SearchKey = append(key, sections[i].SearchKey...)
+		}
+		sections[i].Children = withImportPath(pkgSearchKey, sections[i].Children)
+	}
+	return sections
+}
+
+// indexFile indexes a single Go source file.
+func indexFile(ctx context.Context, path string, content []byte, options goOptions) (*goFile, error) {
+	file := &goFile{
+		Path:        path,
+		Methods:     map[string][]schema.Section{},
+		Embedded:    map[string][]string{},
+		TypedConsts: map[string][]schema.Section{},
+		TypedVars:   map[string][]schema.Section{},
+		FuncResults: map[string][]string{},
+	}
+
+	// Parse the file with tree-sitter.
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(golang.GetLanguage())
+
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseCtx")
+	}
+	defer tree.Close()
+
+	// Inspect the root node.
+	n := tree.RootNode()
+
+	// Package clauses
+	var pkgName string
+	{
+		query, err := sitter.NewQuery([]byte(`
+			(
+				(comment)* @package_docs
+				.
+				(package_clause
+					(package_identifier) @package_name
+				) @package_clause
+				(#set-adjacent! @package_docs @package_name)
+			)
+		`), golang.GetLanguage())
+		if err != nil {
+			return nil, errors.Wrap(err, "NewQuery")
+		}
+		defer query.Close()
+
+		cursor := sitter.NewQueryCursor()
+		defer cursor.Close()
+		cursor.Exec(query, n)
+
+		for {
+			match, ok := cursor.NextMatch()
+			if !ok {
+				break
+			}
+			captures := indexer.GetCaptures(query, match)
+
+			pkgClause := indexer.FirstCaptureContentOr(content, captures["package_clause"], "")
+			pkgDocs := commentsToMarkdown(content, extractPackageDocs(captures["package_docs"], captures["package_clause"]))
+			_ = pkgClause // TODO: use me!
+			pkgName = indexer.FirstCaptureContentOr(content, captures["package_name"], "")
+
+			if file.PkgName != "" {
+				if pkgDocs != "" {
+					file.PkgDocs += "\n\n"
+					file.PkgDocs += pkgDocs
+				}
+			} else {
+				file.PkgName = pkgName
+				file.PkgDir = filepath.ToSlash(filepath.Dir(path))
+				file.PkgDocs = pkgDocs
+			}
+		}
+	}
+
+	// Function definitions
+	{
+		query, err := sitter.NewQuery([]byte(`
+			(
+				(comment)* @func_docs
+				.
+				(function_declaration
+					name: (identifier) @func_name
+					type_parameters: (type_parameter_list)? @func_type_params
+					parameters: (parameter_list)? @func_params
+					result: (_)? @func_result
+				) @func_decl
+			)
+		`), golang.GetLanguage())
+		if err != nil {
+			return nil, errors.Wrap(err, "NewQuery")
+		}
+		defer query.Close()
+
+		cursor := sitter.NewQueryCursor()
+		defer cursor.Close()
+		cursor.Exec(query, n)
+
+		for {
+			match, ok := cursor.NextMatch()
+			if !ok {
+				break
+			}
+			captures := indexer.GetCaptures(query, match)
+
+			funcDocs := commentsToMarkdown(content, captures["func_docs"])
+			funcName := indexer.FirstCaptureContentOr(content, captures["func_name"], "")
+			funcTypeParams := indexer.FirstCaptureContentOr(content, captures["func_type_params"], "")
+			funcParams := indexer.FirstCaptureContentOr(content, captures["func_params"], "")
+			funcResult := indexer.FirstCaptureContentOr(content, captures["func_result"], "")
+
+			if !options.Unexported && !isExported(funcName) {
+				continue // unexported
+			}
+
+			funcLabel := schema.Markdown("func " + funcName + funcTypeParams + funcParams)
+			if funcResult != "" {
+				funcLabel = funcLabel + schema.Markdown(" "+funcResult)
+			}
+			file.Funcs = append(file.Funcs, schema.Section{
+				ID:         funcName,
+				ShortLabel: funcName,
+				Visibility: visibility(funcName),
+				Label:      funcLabel,
+				Detail:     schema.Markdown(funcDocs),
+				SearchKey:  []string{funcName},
+				Location:   indexer.NodeLocation(path, captures["func_decl"][0]),
+			})
+			if resultTypes := resultTypeNames(funcResult); len(resultTypes) > 0 {
+				file.FuncResults[funcName] = resultTypes
+			}
+		}
+	}
+
+	// Method definitions
+	{
+		query, err := sitter.NewQuery([]byte(`
+		(
+			(comment)* @method_docs
+			.
+			(method_declaration
+				receiver: (parameter_list
+					(parameter_declaration
+						type: [
+							(type_identifier) @type_identifier
+							(generic_type type: (type_identifier) @type_identifier)
+							(pointer_type (type_identifier) @type_identifier)
+							(pointer_type (generic_type type: (type_identifier) @type_identifier))
+						]
+					)
+				) @method_receiver
+				name: (field_identifier) @method_name
+				parameters: (parameter_list)? @method_params
+				result: (_)? @method_result
+			) @method_decl
+		)
+		`), golang.GetLanguage())
+		if err != nil {
+			return nil, errors.Wrap(err, "NewQuery")
+		}
+		defer query.Close()
+
+		cursor := sitter.NewQueryCursor()
+		defer cursor.Close()
+		cursor.Exec(query, n)
+
+		for {
+			match, ok := cursor.NextMatch()
+			if !ok {
+				break
+			}
+			captures := indexer.GetCaptures(query, match)
+
+			methodDocs := commentsToMarkdown(content, captures["method_docs"])
+			methodName := indexer.FirstCaptureContentOr(content, captures["method_name"], "")
+			methodReceiver := indexer.FirstCaptureContentOr(content, captures["method_receiver"], "")
+			methodTypeIdentifier := indexer.FirstCaptureContentOr(content, captures["type_identifier"], "")
+			methodParams := indexer.FirstCaptureContentOr(content, captures["method_params"], "")
+			methodResult := indexer.FirstCaptureContentOr(content, captures["method_result"], "")
+
+			if !options.Unexported && !isExported(methodName) {
+				continue // unexported
+			}
+
+			methodLabel := schema.Markdown("func " + methodReceiver + " " + methodName + methodParams)
+			if methodResult != "" {
+				methodLabel = methodLabel + schema.Markdown(" "+methodResult)
+			}
+			file.Methods[methodTypeIdentifier] = append(file.Methods[methodTypeIdentifier], schema.Section{
+				ID:         methodTypeIdentifier + "." + methodName,
+				ShortLabel: methodName,
+				Visibility: visibility(methodName),
+				Label:      methodLabel,
+				Detail:     schema.Markdown(methodDocs),
+				SearchKey:  []string{methodTypeIdentifier, ".", methodName},
+				Location:   indexer.NodeLocation(path, captures["method_decl"][0]),
+			})
+		}
+	}
+
+	// Type declarations
+	{
+		query, err := sitter.NewQuery([]byte(`
+			(source_file
+				(_)?
+				(comment)* @type_docs
+				.
+				(type_declaration
+					(type_spec
+						name: (type_identifier) @type_name
+						type: [
+							(struct_type) @type_struct
+							(interface_type) @type_interface
+							(function_type) @type_func
+
+							(type_identifier) @type_other
+							(generic_type) @type_other
+							(qualified_type) @type_other
+							(pointer_type) @type_other
+							(array_type) @type_other
+							(slice_type) @type_other
+							(map_type) @type_other
+							(channel_type) @type_other
+						]
+					) @type_spec
+				)
+			)
+		`), golang.GetLanguage())
+		if err != nil {
+			return nil, errors.Wrap(err, "NewQuery")
+		}
+		defer query.Close()
+
+		cursor := sitter.NewQueryCursor()
+		defer cursor.Close()
+		cursor.Exec(query, n)
+
+		for {
+			match, ok := cursor.NextMatch()
+			if !ok {
+				break
+			}
+			captures := indexer.GetCaptures(query, match)
+
+			typeDocs := commentsToMarkdown(content, captures["type_docs"])
+			typeName := indexer.FirstCaptureContentOr(content, captures["type_name"], "")
+
+			typeStruct := indexer.FirstCaptureContentOr(content, captures["type_struct"], "")
+			typeInterface := indexer.FirstCaptureContentOr(content, captures["type_interface"], "")
+			typeFunc := indexer.FirstCaptureContentOr(content, captures["type_func"], "")
+			typeOther := indexer.FirstCaptureContentOr(content, captures["type_other"], "")
+
+			if !options.Unexported && !isExported(typeName) {
+				continue // unexported
+			}
+
+			var typeLabel schema.Markdown
+			var typeDefinition string
+			var typeChildren []schema.Section
+			if typeStruct != "" {
+				var embedded []string
+				typeChildren, embedded = structFields(content, path, typeName, captures["type_struct"][0], options)
+				if len(embedded) > 0 {
+					file.Embedded[typeName] = embedded
+				}
+				typeLabel = schema.Markdown(fmt.Sprintf("type %s struct", typeName))
+				typeDefinition = fmt.Sprintf("type %s %s", typeName, typeStruct)
+			} else if typeInterface != "" {
+				typeLabel = schema.Markdown(fmt.Sprintf("type %s interface", typeName))
+				typeDefinition = fmt.Sprintf("type %s %s", typeName, typeInterface)
+				methods, embedded := interfaceMethods(content, path, typeName, captures["type_interface"][0], options)
+				file.Methods[typeName] = append(file.Methods[typeName], methods...)
+				if len(embedded) > 0 {
+					file.Embedded[typeName] = embedded
+				}
+			} else if typeFunc != "" {
+				typeLabel = schema.Markdown(fmt.Sprintf("type %s func", typeName))
+				typeDefinition = fmt.Sprintf("type %s %s", typeName, typeFunc)
+			} else {
+				firstLine := strings.Split(typeOther, "\n")[0]
+				typeLabel = schema.Markdown(fmt.Sprintf("type %s %s", typeName, firstLine))
+				typeDefinition = fmt.Sprintf("type %s %s", typeName, typeOther)
+			}
+
+			file.Types = append(file.Types, schema.Section{
+				ID:         typeName,
+				ShortLabel: typeName,
+				Visibility: visibility(typeName),
+				Label:      typeLabel,
+				Detail:     schema.Markdown(fmt.Sprintf("```go\n%s\n```\n\n%s", typeDefinition, typeDocs)),
+				SearchKey:  []string{typeName},
+				Location:   indexer.NodeLocation(path, captures["type_spec"][0]),
+				Children:   typeChildren,
+			})
+		}
+	}
+
+	// Constants/variables
+	gatherConstsVars := func(constOrVar string, sections *[]schema.Section, typed map[string][]schema.Section) error {
+		query, err := sitter.NewQuery([]byte(fmt.Sprintf(`
+			(source_file
+				(%s_declaration) @decl
+			)
+		`, constOrVar)), golang.GetLanguage())
+		if err != nil {
+			return errors.Wrap(err, "NewQuery")
+		}
+		defer query.Close()
+
+		cursor := sitter.NewQueryCursor()
+		defer cursor.Close()
+		cursor.Exec(query, n)
+
+		for {
+			match, ok := cursor.NextMatch()
+			if !ok {
+				break
+			}
+			captures := indexer.GetCaptures(query, match)
+			decl := captures["decl"][0]
+
+			section, typeName, ok := constVarDecl(content, path, constOrVar, decl, options)
+			if !ok {
+				continue
+			}
+			if typeName != "" {
+				typed[typeName] = append(typed[typeName], section)
+			} else {
+				*sections = append(*sections, section)
+			}
+		}
+		return nil
+	}
+	if err := gatherConstsVars("const", &file.Consts, file.TypedConsts); err != nil {
+		return nil, err
+	}
+	if err := gatherConstsVars("var", &file.Vars, file.TypedVars); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// indexExamples indexes the example functions in a single _test.go file.
+func indexExamples(ctx context.Context, path string, content []byte) (*goFile, error) {
+	file := &goFile{Path: path, PkgDir: filepath.ToSlash(filepath.Dir(path))}
+
+	// Parse the file with tree-sitter.
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(golang.GetLanguage())
+
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseCtx")
+	}
+	defer tree.Close()
+
+	query, err := sitter.NewQuery([]byte(`
+		(
+			(comment)* @func_docs
+			.
+			(function_declaration
+				name: (identifier) @func_name
+				parameters: (parameter_list) @func_params
+				result: (_)? @func_result
+				body: (block) @func_body
+			)
+		)
+	`), golang.GetLanguage())
+	if err != nil {
+		return nil, errors.Wrap(err, "NewQuery")
+	}
+	defer query.Close()
+
+	cursor := sitter.NewQueryCursor()
+	defer cursor.Close()
+	cursor.Exec(query, tree.RootNode())
+
+	for {
+		match, ok := cursor.NextMatch()
+		if !ok {
+			break
+		}
+		captures := indexer.GetCaptures(query, match)
+
+		funcName := indexer.FirstCaptureContentOr(content, captures["func_name"], "")
+		funcParams := indexer.FirstCaptureContentOr(content, captures["func_params"], "")
+		funcResult := indexer.FirstCaptureContentOr(content, captures["func_result"], "")
+		if !strings.HasPrefix(funcName, "Example") || funcParams != "()" || funcResult != "" {
+			continue // not an example
+		}
+		target, suffix, ok := parseExampleName(strings.TrimPrefix(funcName, "Example"))
+		if !ok {
+			continue
+		}
+		code, output, unordered := splitExampleOutput(indexer.FirstCaptureContentOr(content, captures["func_body"], ""))
+		file.Examples = append(file.Examples, goExample{
+			Target:    target,
+			Suffix:    suffix,
+			Docs:      commentsToMarkdown(content, captures["func_docs"]),
+			Code:      code,
+			Output:    output,
+			Unordered: unordered,
+		})
+	}
+	return file, nil
+}
+
+// parseExampleName parses the name of an example function with the "Example" prefix removed, e.g.
+// "" (package), "F", "T", "T_M", or any of those followed by "_suffix". Returns the ID of the
+// section the example is for (e.g. "T.M") and its suffix.
+func parseExampleName(name string) (target, suffix string, ok bool) {
+	if name == "" {
+		return "", "", true
+	}
+	if !strings.HasPrefix(name, "_") && !isExported(name) {
+		return "", "", false // e.g. Examples, not an example
+	}
+	parts := strings.Split(name, "_")
+	target = parts[0]
+	rest := parts[1:]
+	if target != "" && len(rest) > 0 && rest[0] != "" && isExported(rest[0]) {
+		target += "." + rest[0]
+		rest = rest[1:]
+	}
+	if len(rest) > 1 {
+		return "", "", false
+	}
+	if len(rest) == 1 {
+		suffix = rest[0]
+		if suffix == "" || isExported(suffix) {
+			return "", "", false // suffixes must start with a lower-case letter
+		}
+	}
+	return target, suffix, true
+}
+
+// splitExampleOutput splits the body of an example function (including braces) into its code, and
+// the expected output given by a trailing "// Output:" or "// Unordered output:" comment.
+func splitExampleOutput(body string) (code, output string, unordered bool) {
+	body = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(body), "{"), "}")
+	lines := strings.Split(strings.Trim(body, "\n"), "\n")
+
+	outputLine := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "// Output:") || strings.HasPrefix(trimmed, "// Unordered output:") {
+			outputLine = i
+			unordered = strings.HasPrefix(trimmed, "// Unordered output:")
+		}
+	}
+	if outputLine >= 0 {
+		first := strings.TrimSpace(lines[outputLine][strings.Index(lines[outputLine], ":")+1:])
+		var outputLines []string
+		if first != "" {
+			outputLines = append(outputLines, first)
+		}
+		for _, line := range lines[outputLine+1:] {
+			trimmed := strings.TrimSpace(line)
+			if !strings.HasPrefix(trimmed, "//") {
+				break
+			}
+			outputLines = append(outputLines, strings.TrimPrefix(strings.TrimPrefix(trimmed, "//"), " "))
+		}
+		output = strings.Join(outputLines, "\n")
+		lines = lines[:outputLine]
+	}
+
+	// Remove the indentation of the function body.
+	indent := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, "\t "))]
+		if first || len(lineIndent) < len(indent) {
+			indent, first = lineIndent, false
+		}
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, indent)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), output, unordered
+}
+
+// attachExamples appends examples to the details of the sections they are for, by section ID.
+func attachExamples(sections []schema.Section, examples []goExample) {
+	for i := range sections {
+		for _, example := range examples {
+			if example.Target != "" && example.Target == sections[i].ID && !sections[i].Category {
+				sections[i].Detail = schema.Markdown(strings.TrimSpace(string(sections[i].Detail) + "\n\n" + example.markdown()))
+			}
+		}
+		attachExamples(sections[i].Children, examples)
+	}
+}
+
+// resultTypeNames returns the names of the types in a function's result list (e.g. "*Client",
+// "(*Client, error)" or "(c Client[T], err error)") which may be declared in the same package.
+func resultTypeNames(result string) []string {
+	if strings.HasPrefix(result, "(") && strings.HasSuffix(result, ")") {
+		result = result[1 : len(result)-1]
+	}
+
+	// Split the results by commas that are not within brackets, e.g. in Map[K, V]
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i, r := range result {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(result[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, strings.TrimSpace(result[start:]))
+
+	// Results are either all named or all unnamed. Names without a type, e.g. the "a" in
+	// "a, b int", have the type of the next result. Unnamed results may contain spaces too, e.g.
+	// "chan int", but begin with a keyword rather than a name.
+	named := false
+	for _, part := range parts {
+		if name, _, ok := strings.Cut(part, " "); ok && isTypeName(name) && !token.IsKeyword(name) {
+			named = true
+		}
+	}
+	var names []string
+	for _, part := range parts {
+		if named {
+			_, typ, ok := strings.Cut(part, " ")
+			if !ok {
+				continue
+			}
+			part = strings.TrimSpace(typ)
+		}
+		part = strings.TrimPrefix(part, "*")
+		if i := strings.Index(part, "["); i > 0 {
+			part = part[:i] // type arguments
+		}
+		if isTypeName(part) && !token.IsKeyword(part) {
+			names = append(names, part)
+		}
+	}
+	return names
+}
+
+// markDeprecated marks sections (recursively) whose documentation has a paragraph beginning with
+// "Deprecated:" as deprecated, see https://go.dev/wiki/Deprecated
+func markDeprecated(sections []schema.Section) {
+	for i := range sections {
+		for _, paragraph := range strings.Split(string(sections[i].Detail), "\n\n") {
+			if strings.HasPrefix(strings.TrimSpace(paragraph), "Deprecated:") {
+				sections[i].Deprecated = true
+				break
+			}
+		}
+		markDeprecated(sections[i].Children)
+	}
+}
+
+// splitTypeKey splits a key of the form pkgDir + "." + typeName.
+func splitTypeKey(key string) (pkgDir, typeName string) {
+	i := strings.LastIndex(key, ".")
+	return key[:i], key[i+1:]
+}
+
+// mergePlatforms merges sections declared in a file built for the given platforms into existing
+// ones. Sections with the same ID as an existing one, e.g. a function declared in both foo_linux.go
+// and foo_windows.go, are merged into it.
+func mergePlatforms(existing, sections []schema.Section, platforms []string) []schema.Section {
+	for _, section := range sections {
+		merged := false
+		for i := range existing {
+			if existing[i].ID == section.ID {
+				existing[i].Platforms = append(existing[i].Platforms, platforms...)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			section.Platforms = append([]string{}, platforms...)
+			existing = append(existing, section)
+		}
+	}
+	return existing
+}
+
+// normalizePlatforms orders the platforms of sections by target, and removes them from sections
+// which exist on all targets.
+func normalizePlatforms(sections []schema.Section, targets []string) {
+	for i := range sections {
+		var platforms []string
+		for _, target := range targets {
+			for _, platform := range sections[i].Platforms {
+				if platform == target {
+					platforms = append(platforms, target)
+					break
+				}
+			}
+		}
+		if len(platforms) == len(targets) {
+			platforms = nil
+		}
+		sections[i].Platforms = platforms
+	}
+}
+
+// isTestFile reports whether the file at the given path is a Go test file, which is only indexed
+// for examples.
+func isTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.go")
+}
+
+// constVarDecl returns the section for a const or var declaration. A parenthesized group of
+// declarations is a single section, with a child section for each member.
+//
+// If every member has the same type, and it is declared in the same package, the name of the type
+// is returned so the section can be shown under it (as godoc does for e.g. iota enums.)
+func constVarDecl(content []byte, path, constOrVar string, decl *sitter.Node, options goOptions) (section schema.Section, typeName string, ok bool) {
+	grouped := false
+	var specs []*sitter.Node
+	for i := 0; i < int(decl.ChildCount()); i++ {
+		child := decl.Child(i)
+		switch child.Type() {
+		case "(":
+			grouped = true
+		case constOrVar + "_spec":
+			specs = append(specs, child)
+		}
+	}
+
+	var (
+		members   []schema.Section
+		types     = map[string]bool{}
+		lastType  string
+		lastValue bool
+	)
+	for _, spec := range specs {
+		typ := ""
+		if typeNode := spec.ChildByFieldName("type"); typeNode != nil {
+			typ = typeNode.Content(content)
+		}
+		hasValue := spec.ChildByFieldName("value") != nil
+		if constOrVar == "const" && typ == "" && !hasValue {
+			// Implicit repetition of the previous type and expression, e.g. iota enums.
+			typ, hasValue = lastType, lastValue
+		}
+		lastType, lastValue = typ, hasValue
+
+		for i := 0; i < int(spec.NamedChildCount()); i++ {
+			nameNode := spec.NamedChild(i)
+			if nameNode.Type() != "identifier" {
+				continue // type or value
+			}
+			name := nameNode.Content(content)
+			if name == "_" || (!options.Unexported && !isExported(name)) {
+				continue
+			}
+			types[typ] = true
+			members = append(members, schema.Section{
+				ID:         name,
+				ShortLabel: name,
+				Visibility: visibility(name),
+				Label:      schema.Markdown(constOrVar + " " + name),
+				Detail:     schema.Markdown(commentsToMarkdown(content, nodeDocs(spec))),
+				SearchKey:  []string{name},
+				Location:   indexer.NodeLocation(path, spec),
+			})
+		}
+	}
+	if len(members) == 0 {
+		return schema.Section{}, "", false
+	}
+	if len(types) == 1 {
+		for typ := range types {
+			if isTypeName(typ) {
+				typeName = typ
+			}
+		}
+	}
+
+	docs := commentsToMarkdown(content, nodeDocs(decl))
+	definition := decl.Content(content)
+	if !grouped && len(members) == 1 {
+		section = members[0]
+		section.Detail = schema.Markdown(fmt.Sprintf("```go\n%s\n```\n\n%s", definition, docs))
+		section.Location = indexer.NodeLocation(path, decl)
+		return section, typeName, true
+	}
+
+	var names []string
+	groupVisibility := schema.VisibilityPrivate
+	for _, member := range members {
+		names = append(names, member.ID)
+		if member.Visibility == schema.VisibilityPublic {
+			groupVisibility = schema.VisibilityPublic
+		}
+	}
+	shortNames := names
+	if len(shortNames) > 3 {
+		shortNames = append(append([]string{}, names[:3]...), "...")
+	}
+	return schema.Section{
+		ID:         constOrVar + "-" + names[0],
+		ShortLabel: fmt.Sprintf("%s (%s)", constOrVar, strings.Join(shortNames, ", ")),
+		Visibility: groupVisibility,
+		Label:      schema.Markdown(fmt.Sprintf("%s (%s)", constOrVar, strings.Join(names, ", "))),
+		Detail:     schema.Markdown(fmt.Sprintf("```go\n%s\n```\n\n%s", definition, docs)),
+		SearchKey:  []string{},
+		Location:   indexer.NodeLocation(path, decl),
+		Children:   members,
+	}, typeName, true
+}
+
+// isTypeName reports whether a type expression is a plain type name, i.e. a type declared in the
+// same package.
+func isTypeName(typ string) bool {
+	if typ == "" {
+		return false
+	}
+	for _, r := range typ {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// structFields returns sections for the exported fields of a struct type, including embedded
+// fields, and the names of embedded types declared in the same package.
+func structFields(content []byte, path, typeName string, structType *sitter.Node, options goOptions) ([]schema.Section, []string) {
+	var (
+		fields   []schema.Section
+		embedded []string
+	)
+	fieldList := namedChildOfType(structType, "field_declaration_list")
+	if fieldList == nil {
+		return nil, nil
+	}
+	for i := 0; i < int(fieldList.NamedChildCount()); i++ {
+		field := fieldList.NamedChild(i)
+		if field.Type() != "field_declaration" {
+			continue
+		}
+		fieldType := field.ChildByFieldName("type")
+		if fieldType == nil {
+			continue
+		}
+		fieldTypeContent := fieldType.Content(content)
+		var tag string
+		if tagNode := field.ChildByFieldName("tag"); tagNode != nil {
+			tag = " " + tagNode.Content(content)
+		}
+		docs := commentsToMarkdown(content, nodeDocs(field))
+
+		var names []string
+		for j := 0; j < int(field.NamedChildCount()); j++ {
+			if child := field.NamedChild(j); child.Type() == "field_identifier" {
+				names = append(names, child.Content(content))
+			}
+		}
+		label := func(name string) string { return name + " " + fieldTypeContent + tag }
+		if len(names) == 0 {
+			// Embedded field, e.g. `Foo`, `*Foo`, `pkg.Foo` or `Foo[T]`
+			typeIdentifier, qualified := embeddedTypeName(fieldType)
+			if typeIdentifier == nil {
+				continue
+			}
+			name := typeIdentifier.Content(content)
+			if !qualified {
+				embedded = append(embedded, name)
+			}
+			names = []string{name}
+			label = func(string) string {
+				// The field itself rather than its type, to include the * of embedded pointers.
+				return strings.TrimSpace(strings.TrimSuffix(field.Content(content), strings.TrimSpace(tag))) + tag
+			}
+		}
+		for _, name := range names {
+			if !options.Unexported && !isExported(name) {
+				continue
+			}
+			fields = append(fields, schema.Section{
+				ID:         typeName + "." + name,
+				ShortLabel: name,
+				Visibility: visibility(name),
+				Label:      schema.Markdown(label(name)),
+				Detail:     schema.Markdown(docs),
+				SearchKey:  []string{typeName, ".", name},
+				Location:   indexer.NodeLocation(path, field),
+			})
+		}
+	}
+	return fields, embedded
+}
+
+// interfaceMethods returns sections for the exported methods of an interface type, and the names of
+// embedded interfaces declared in the same package.
+func interfaceMethods(content []byte, path, typeName string, interfaceType *sitter.Node, options goOptions) ([]schema.Section, []string) {
+	var (
+		methods  []schema.Section
+		embedded []string
+	)
+	for i := 0; i < int(interfaceType.NamedChildCount()); i++ {
+		elem := interfaceType.NamedChild(i)
+		switch elem.Type() {
+		case "method_spec", "method_elem":
+			nameNode := elem.ChildByFieldName("name")
+			if nameNode == nil {
+				continue
+			}
+			name := nameNode.Content(content)
+			if !options.Unexported && !isExported(name) {
+				continue
+			}
+			methods = append(methods, schema.Section{
+				ID:         typeName + "." + name,
+				ShortLabel: name,
+				Visibility: visibility(name),
+				Label:      schema.Markdown(elem.Content(content)),
+				Detail:     schema.Markdown(commentsToMarkdown(content, nodeDocs(elem))),
+				SearchKey:  []string{typeName, ".", name},
+				Location:   indexer.NodeLocation(path, elem),
+			})
+		case "interface_type_name", "constraint_elem", "type_elem":
+			// Embedded interface, e.g. `io.Reader` or `Foo`; type sets such as `~int | ~string`
+			// are not embedded interfaces.
+			if elem.NamedChildCount() != 1 {
+				continue
+			}
+			if child := elem.NamedChild(0); child.Type() == "type_identifier" {
+				embedded = append(embedded, child.Content(content))
+			}
+		}
+	}
+	return methods, embedded
+}
+
+// promotedMethods returns sections for the methods promoted to the given type from the types it
+// embeds (recursively), excluding those with the same name as one of its own fields or methods.
+func promotedMethods(pkgDir, typeName string, own []schema.Section, methodsByType map[string][]schema.Section, embeddedByType map[string][]string) []schema.Section {
+	seen := map[string]bool{}
+	for _, section := range own {
+		seen[section.ShortLabel] = true
+	}
+	var (
+		promoted []schema.Section
+		visited  = map[string]bool{typeName: true}
+		queue    = embeddedByType[pkgDir+"."+typeName]
+	)
+	// Breadth-first, as methods of shallower embedded types take precedence.
+	for len(queue) > 0 {
+		var next []string
+		for _, embedded := range queue {
+			if visited[embedded] {
+				continue
+			}
+			visited[embedded] = true
+			for _, method := range methodsByType[pkgDir+"."+embedded] {
+				if seen[method.ShortLabel] {
+					continue
+				}
+				seen[method.ShortLabel] = true
+				promoted = append(promoted, schema.Section{
+					ID:         typeName + "." + method.ShortLabel,
+					ShortLabel: method.ShortLabel,
+					Visibility: method.Visibility,
+					Label:      method.Label,
+					Detail:     schema.Markdown(fmt.Sprintf("Promoted from embedded `%s`, see `%s.%s`.\n\n%s", embedded, embedded, method.ShortLabel, method.Detail)),
+					SearchKey:  []string{typeName, ".", method.ShortLabel},
+					Location:   method.Location,
+				})
+			}
+			next = append(next, embeddedByType[pkgDir+"."+embedded]...)
+		}
+		queue = next
+	}
+	return promoted
+}
+
+// nodeDocs returns the comments documenting a declaration, struct field or interface method: those
+// on the lines directly above it or, failing that, a comment following it on the same line.
+func nodeDocs(node *sitter.Node) []*sitter.Node {
+	var docs []*sitter.Node
+	row := node.StartPoint().Row
+	for prev := node.PrevNamedSibling(); prev != nil && prev.Type() == "comment"; prev = prev.PrevNamedSibling() {
+		if prev.EndPoint().Row+1 != row {
+			break
+		}
+		docs = append([]*sitter.Node{prev}, docs...)
+		row = prev.StartPoint().Row
+	}
+	if len(docs) == 0 {
+		if next := node.NextNamedSibling(); next != nil && next.Type() == "comment" && next.StartPoint().Row == node.EndPoint().Row {
+			docs = append(docs, next)
+		}
+	}
+	return docs
+}
+
+// embeddedTypeName returns the type identifier naming an embedded field's type, and whether it is
+// qualified by a package name (i.e. declared in another package.)
+func embeddedTypeName(fieldType *sitter.Node) (name *sitter.Node, qualified bool) {
+	switch fieldType.Type() {
+	case "type_identifier":
+		return fieldType, false
+	case "qualified_type":
+		return fieldType.ChildByFieldName("name"), true
+	case "generic_type", "pointer_type":
+		for i := 0; i < int(fieldType.NamedChildCount()); i++ {
+			if name, qualified := embeddedTypeName(fieldType.NamedChild(i)); name != nil {
+				return name, qualified
+			}
+		}
+	}
+	return nil, false
+}
+
+func namedChildOfType(node *sitter.Node, typ string) *sitter.Node {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if child := node.NamedChild(i); child.Type() == typ {
+			return child
+		}
+	}
+	return nil
+}
+
+// visibility returns the visibility of a Go identifier, i.e. whether it is exported.
+func visibility(name string) schema.Visibility {
+	if isExported(name) {
+		return schema.VisibilityPublic
+	}
+	return schema.VisibilityPrivate
+}
+
+func isExported(name string) bool {
+	firstRune := []rune(name)[0]
+	return string(firstRune) == strings.ToUpper(string(firstRune)) && string(firstRune) != "_"
+}
+
 func commentsToMarkdown(content []byte, captures []*sitter.Node) string {
 	// Turn /* multiline */ and // single line comments into plain text.
 	var joined []string
@@ -543,23 +1387,99 @@ func godocToMarkdown(godoc string) string {
 	return buf.String()
 }
 
-type packageInfo struct {
-	path string
-	docs string
+// goOptions are the Go-specific options which may be configured in doctree.yaml, e.g.:
+//
+//	options:
+//	  go:
+//	    unexported: true
+type goOptions struct {
+	// Unexported indicates unexported declarations should be indexed too.
+	Unexported bool `yaml:"unexported"`
+
+	// Targets are the GOOS/GOARCH platforms to index for, e.g. "linux/amd64". Overridden by the
+	// targets given on the command line. Defaults to defaultTargets.
+	Targets []string `yaml:"targets"`
 }
 
-func firstCaptureContentOr(content []byte, captures []*sitter.Node, defaultValue string) string {
-	if len(captures) > 0 {
-		return captures[0].Content(content)
-	}
-	return defaultValue
+// goFile is the result of indexing a single Go source file, as stored in the indexer.FileCache.
+//
+// Search keys of sections are relative to the package, e.g. ["Client", ".", "Do"], as the import
+// path of the package depends on the go.mod file it belongs to. See withImportPath.
+type goFile struct {
+	Path    string `json:"path"`
+	PkgName string `json:"pkgName"`
+	PkgDir  string `json:"pkgDir"` // slash-separated, "." for the indexed directory
+	PkgDocs string `json:"pkgDocs"`
+
+	Consts []schema.Section `json:"consts"`
+	Vars   []schema.Section `json:"vars"`
+	Types  []schema.Section `json:"types"`
+	Funcs  []schema.Section `json:"funcs"`
+
+	// Constants and variables of a type declared in the package, by type name. If the type is not
+	// found in the package, they are shown with other constants and variables.
+	TypedConsts map[string][]schema.Section `json:"typedConsts"`
+	TypedVars   map[string][]schema.Section `json:"typedVars"`
+
+	// Names of the types returned by each function which may be declared in the package, used to
+	// find constructors. See resultTypeNames.
+	FuncResults map[string][]string `json:"funcResults"`
+
+	// Methods by receiver type name. For interface types, the methods of the interface.
+	Methods map[string][]schema.Section `json:"methods"`
+
+	// Embedded types declared in the same package (and so whose methods are promoted), by the
+	// name of the embedding struct or interface type.
+	Embedded map[string][]string `json:"embedded"`
+
+	// Examples declared in the file, only for _test.go files.
+	Examples []goExample `json:"examples,omitempty"`
 }
 
-func getCaptures(q *sitter.Query, m *sitter.QueryMatch) map[string][]*sitter.Node {
-	captures := map[string][]*sitter.Node{}
-	for _, c := range m.Captures {
-		cname := q.CaptureNameForId(c.Index)
-		captures[cname] = append(captures[cname], c.Node)
+// goExample is an example function, e.g. ExampleClient_Do, see
+// https://pkg.go.dev/testing#hdr-Examples
+type goExample struct {
+	// Target is the ID of the section the example is for, e.g. "Client.Do", or empty for the
+	// package.
+	Target string `json:"target"`
+
+	// Suffix distinguishes multiple examples for the same target, e.g. "basic" for
+	// ExampleClient_Do_basic.
+	Suffix string `json:"suffix,omitempty"`
+
+	Docs   string `json:"docs,omitempty"`
+	Code   string `json:"code"`
+	Output string `json:"output,omitempty"`
+
+	// Unordered indicates the output is an "Unordered output:" comment.
+	Unordered bool `json:"unordered,omitempty"`
+}
+
+func (e goExample) markdown() string {
+	var buf strings.Builder
+	buf.WriteString("**Example**")
+	if e.Suffix != "" {
+		fmt.Fprintf(&buf, " (%s)", e.Suffix)
+	}
+	buf.WriteString("\n\n")
+	if e.Docs != "" {
+		buf.WriteString(e.Docs + "\n\n")
 	}
-	return captures
+	fmt.Fprintf(&buf, "```go\n%s\n```", e.Code)
+	if e.Output != "" {
+		if e.Unordered {
+			buf.WriteString("\n\nOutput (in any order):")
+		} else {
+			buf.WriteString("\n\nOutput:")
+		}
+		fmt.Fprintf(&buf, "\n\n```\n%s\n```", e.Output)
+	}
+	return buf.String()
+}
+
+type packageInfo struct {
+	name     string
+	path     string
+	docs     string
+	location *schema.Location
 }
@@ -0,0 +1,541 @@
+package golang
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func Test_resultTypeNames(t *testing.T) {
+	tests := []struct {
+		result string
+		want   []string
+	}{
+		{result: "", want: nil},
+		{result: "*Client", want: []string{"Client"}},
+		{result: "(*Client, error)", want: []string{"Client", "error"}},
+		{result: "(c Client[T], err error)", want: []string{"Client", "error"}},
+		{result: "(a, b *Client)", want: []string{"Client"}},
+		{result: "(Map[K, V], error)", want: []string{"Map", "error"}},
+		{result: "(chan int, error)", want: []string{"error"}},
+		{result: "chan Client", want: nil},
+		{result: "(func() Client, error)", want: []string{"error"}},
+		{result: "(map[string]Client, error)", want: []string{"error"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.result, func(t *testing.T) {
+			autogold.Want(tc.result, tc.want).Equal(t, resultTypeNames(tc.result))
+		})
+	}
+}
+
+func Test_parseExampleName(t *testing.T) {
+	tests := []struct {
+		name           string
+		target, suffix string
+		ok             bool
+	}{
+		{name: "", ok: true},
+		{name: "_basic", suffix: "basic", ok: true},
+		{name: "F", target: "F", ok: true},
+		{name: "T_M", target: "T.M", ok: true},
+		{name: "T_M_suffix", target: "T.M", suffix: "suffix", ok: true},
+		{name: "T_suffix", target: "T", suffix: "suffix", ok: true},
+		{name: "s"},
+		{name: "T_M_Bad"},
+		{name: "T_M_a_b"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target, suffix, ok := parseExampleName(tc.name)
+			if target != tc.target || suffix != tc.suffix || ok != tc.ok {
+				t.Fatalf("got (%q, %q, %v), want (%q, %q, %v)", target, suffix, ok, tc.target, tc.suffix, tc.ok)
+			}
+		})
+	}
+}
+
+func Test_splitExampleOutput(t *testing.T) {
+	tests := []struct {
+		name, body   string
+		code, output string
+		unordered    bool
+	}{
+		{
+			name: "no-output",
+			body: "{\n\tfmt.Println(\"hi\")\n}",
+			code: "fmt.Println(\"hi\")",
+		},
+		{
+			name:   "output",
+			body:   "{\n\tif true {\n\t\tfmt.Println(\"hi\")\n\t}\n\t// Output: hi\n}",
+			code:   "if true {\n\tfmt.Println(\"hi\")\n}",
+			output: "hi",
+		},
+		{
+			name:      "unordered-output",
+			body:      "{\n\tfmt.Println(1)\n\tfmt.Println(2)\n\t// Unordered output:\n\t// 2\n\t// 1\n}",
+			code:      "fmt.Println(1)\nfmt.Println(2)",
+			output:    "2\n1",
+			unordered: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code, output, unordered := splitExampleOutput(tc.body)
+			if code != tc.code || output != tc.output || unordered != tc.unordered {
+				t.Fatalf("got (%q, %q, %v), want (%q, %q, %v)", code, output, unordered, tc.code, tc.output, tc.unordered)
+			}
+		})
+	}
+}
+
+func TestIndexDir(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"go.mod": `module example.com/root
+
+go 1.18
+`,
+		"root.go": `// Package root is a test package.
+package root
+
+import "io"
+
+// Color is a color.
+type Color int
+
+// Colors.
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+const (
+	// Max is the maximum.
+	Max = 10
+	min = 0
+)
+
+// Default is the default color.
+var Default Color = Red
+
+// Client sends requests.
+type Client struct {
+	// Name of the client.
+	Name string
+	Base
+	*io.Reader
+	hidden int
+}
+
+// Do sends a request.
+func (c *Client) Do() error { return nil }
+
+// Base is embedded in Client.
+type Base struct{}
+
+// Close closes it.
+func (b Base) Close() error { return nil }
+
+// Do is shadowed by Client.Do.
+func (b Base) Do() {}
+
+// Doer does things.
+type Doer interface {
+	io.Closer
+	// Do does it.
+	Do() error
+}
+
+// List is a generic list.
+type List[T any] struct{}
+
+// Push appends v.
+func (l *List[T]) Push(v T) {}
+
+// Len returns the length.
+func (l List[T]) Len() int { return 0 }
+
+// NewClient returns a new client.
+func NewClient() (*Client, error) { return nil, nil }
+
+// NewClientPair returns two clients.
+func NewClientPair() (a, b *Client) { return nil, nil }
+
+// Channel returns a channel.
+func Channel() (chan int, error) { return nil, nil }
+
+// OldClient returns a client.
+//
+// Deprecated: Use NewClient instead.
+func OldClient() Client { return Client{} }
+`,
+		"root_test.go": `package root_test
+
+import "fmt"
+
+func Example() {
+	fmt.Println("package")
+}
+
+func ExampleClient_Do_basic() {
+	fmt.Println("hello")
+	// Output: hello
+}
+
+func ExampleList_Push() {
+	fmt.Println("push")
+}
+
+func ExampleNewClient() {
+	fmt.Println(1)
+	fmt.Println(2)
+	// Unordered output:
+	// 2
+	// 1
+}
+
+func Examples() {}
+`,
+		"lib/lib.go": `package lib
+
+// F is a function.
+func F() {}
+`,
+		"nested/go.mod": `module example.com/nested
+`,
+		"nested/n.go": `package nested
+
+// G is a function.
+func G() {}
+`,
+		"nested/sub/s.go": `package sub
+
+// H is a function.
+func H() {}
+`,
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index, err := (&goIndexer{}).IndexDir(context.Background(), dir, indexer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Summarize each section by its ID and search key, indented by depth, with flags.
+	var got, examples []string
+	var summarize func(sections []schema.Section, depth int)
+	summarize = func(sections []schema.Section, depth int) {
+		for _, s := range sections {
+			line := strings.Repeat("  ", depth) + s.ID + " " + strings.Join(s.SearchKey, "")
+			if s.Deprecated {
+				line += " (deprecated)"
+			}
+			if strings.HasPrefix(string(s.Detail), "Promoted from") {
+				line += " (promoted)"
+			}
+			if i := strings.Index(string(s.Detail), "**Example**"); i >= 0 {
+				line += " (example)"
+				examples = append(examples, s.ID+": "+string(s.Detail[i:]))
+			}
+			got = append(got, line)
+			summarize(s.Children, depth+1)
+		}
+	}
+	var pages []schema.Page
+	for _, library := range index.Libraries {
+		for _, page := range library.Pages {
+			page.Title = library.Name + ": " + page.Title
+			pages = append(pages, page)
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Path < pages[j].Path })
+	for _, page := range pages {
+		line := page.Path + " " + strings.Join(page.SearchKey, "") + " (" + page.Title + ")"
+		if strings.Contains(string(page.Detail), "**Example**") {
+			line += " (example)"
+		}
+		got = append(got, line)
+		summarize(page.Sections, 1)
+	}
+	autogold.Want("sections", []string{
+		"example.com/nested example.com/nested (example.com/nested: Package nested)",
+		"  func ",
+		"    G example.com/nested.G",
+		"example.com/nested/sub example.com/nested/sub (example.com/nested: Package sub)",
+		"  func ",
+		"    H example.com/nested/sub.H",
+		"example.com/root example.com/root (example.com/root: Package root) (example)",
+		"  const ",
+		"    const-Max ",
+		"      Max example.com/root.Max",
+		"  type ",
+		"    Color example.com/root.Color",
+		"      const-Red ",
+		"        Red example.com/root.Red",
+		"        Green example.com/root.Green",
+		"        Blue example.com/root.Blue",
+		"      Default example.com/root.Default",
+		"    Client example.com/root.Client",
+		"      Client.Name example.com/root.Client.Name",
+		"      Client.Base example.com/root.Client.Base",
+		"      Client.Reader example.com/root.Client.Reader",
+		"      NewClient example.com/root.NewClient (example)",
+		"      NewClientPair example.com/root.NewClientPair",
+		"      OldClient example.com/root.OldClient (deprecated)",
+		"      Client.Do example.com/root.Client.Do (example)",
+		"      Client.Close example.com/root.Client.Close (promoted)",
+		"    Base example.com/root.Base",
+		"      Base.Close example.com/root.Base.Close",
+		"      Base.Do example.com/root.Base.Do",
+		"    Doer example.com/root.Doer",
+		"      Doer.Do example.com/root.Doer.Do",
+		"    List example.com/root.List",
+		"      List.Push example.com/root.List.Push (example)",
+		"      List.Len example.com/root.List.Len",
+		"  func ",
+		"    Channel example.com/root.Channel",
+		"example.com/root/lib example.com/root/lib (example.com/root: Package lib)",
+		"  func ",
+		"    F example.com/root/lib.F",
+	}).Equal(t, got)
+	autogold.Want("examples", []string{
+		"NewClient: **Example**\n\n```go\nfmt.Println(1)\nfmt.Println(2)\n```\n\nOutput (in any order):\n\n```\n2\n1\n```",
+		"Client.Do: **Example** (basic)\n\n```go\nfmt.Println(\"hello\")\n```\n\nOutput:\n\n```\nhello\n```",
+		"List.Push: **Example**\n\n```go\nfmt.Println(\"push\")\n```",
+	}).Equal(t, examples)
+}
+
+func Test_findLibraries(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"go.mod":           "module example.com/root\n\ngo 1.18\n",
+		"tools/go.mod":     "// Tools.\nmodule \"example.com/root/tools\"\n",
+		"broken/go.mod":    "go 1.18\n",
+		"vendor/x/go.mod":  "module example.com/x\n",
+		"tools/sub/sub.go": "package sub\n",
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	libraries, err := findLibraries(dir, indexer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, library := range libraries {
+		got = append(got, library.Dir+": "+library.Library.Name)
+	}
+	autogold.Want("libraries", []string{".: example.com/root", "tools: example.com/root/tools"}).Equal(t, got)
+}
+
+func Test_constVarDecl(t *testing.T) {
+	content := []byte(`package p
+
+// Kind is a kind.
+type Kind int
+
+// Kinds of things.
+const (
+	A Kind = iota // A is the first.
+	B
+	c
+)
+
+// Mixed types, shown with other constants.
+const (
+	D, E      = 10, 11
+	F    Kind = 12
+)
+
+// Max is the maximum.
+const Max = 10
+
+const (
+	Single = "x"
+)
+
+var (
+	// Default is the default kind.
+	Default Kind = A
+	Other   Kind
+)
+
+var Verbose = false
+
+var _ = Max
+`)
+	file, err := indexFile(context.Background(), "p.go", content, goOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summarize := func(sections []schema.Section) []string {
+		var got []string
+		for _, s := range sections {
+			got = append(got, s.ID+": "+string(s.Label))
+			for _, child := range s.Children {
+				got = append(got, "  "+child.ID+": "+string(child.Label))
+			}
+		}
+		return got
+	}
+	autogold.Want("consts", []string{
+		"const-D: const (D, E, F)", "  D: const D",
+		"  E: const E",
+		"  F: const F",
+		"Max: const Max",
+		"const-Single: const (Single)",
+		"  Single: const Single",
+	}).Equal(t, summarize(file.Consts))
+	autogold.Want("typed consts", []string{"const-A: const (A, B)", "  A: const A", "  B: const B"}).Equal(t, summarize(file.TypedConsts["Kind"]))
+	autogold.Want("vars", []string{"Verbose: var Verbose"}).Equal(t, summarize(file.Vars))
+	autogold.Want("typed vars", []string{
+		"var-Default: var (Default, Other)", "  Default: var Default",
+		"  Other: var Other",
+	}).Equal(t, summarize(file.TypedVars["Kind"]))
+}
+
+func TestIndexDir_typedConsts(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"go.mod": "module example.com/p\n",
+		"kind.go": `package p
+
+// Kind is a kind.
+type Kind int
+
+const (
+	A Kind = iota
+	B
+)
+
+// Default is the default kind.
+var Default Kind = A
+
+// Mode is not declared in this package.
+const Fast mode = 1
+`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index, err := (&goIndexer{}).IndexDir(context.Background(), dir, indexer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	var summarize func(sections []schema.Section, depth int)
+	summarize = func(sections []schema.Section, depth int) {
+		for _, s := range sections {
+			got = append(got, strings.Repeat("  ", depth)+s.ID)
+			summarize(s.Children, depth+1)
+		}
+	}
+	summarize(index.Libraries[0].Pages[0].Sections, 0)
+	autogold.Want("sections", []string{
+		"const", "  Fast", "type", "  Kind", "    const-A", "      A", "      B", "    Default",
+	}).Equal(t, got)
+}
+
+func TestIndexDir_includePrivate(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"go.mod": "module example.com/p\n",
+		"p.go": `package p
+
+// Client is exported.
+type Client struct {
+	Name  string
+	token string
+}
+
+func (c Client) Do()    {}
+func (c Client) reset() {}
+
+func New() Client { return Client{} }
+
+func helper() {}
+
+type state int
+`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts indexer.Options
+		want autogold.Value
+	}{
+		{
+			name: "default",
+			want: autogold.Want("default", []string{
+				"type", "  Client public", "    Client.Name public",
+				"    New public",
+				"    Client.Do public",
+			}),
+		},
+		{
+			name: "include private",
+			opts: indexer.Options{IncludePrivate: true},
+			want: autogold.Want("include private", []string{
+				"type", "  Client public", "    Client.Name public",
+				"    Client.token private",
+				"    New public",
+				"    Client.Do public",
+				"    Client.reset private",
+				"  state private",
+				"func",
+				"  helper private",
+			}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			index, err := (&goIndexer{}).IndexDir(context.Background(), dir, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			var summarize func(sections []schema.Section, depth int)
+			summarize = func(sections []schema.Section, depth int) {
+				for _, s := range sections {
+					line := strings.Repeat("  ", depth) + s.ID
+					if s.Visibility != "" {
+						line += " " + string(s.Visibility)
+					}
+					got = append(got, line)
+					summarize(s.Children, depth+1)
+				}
+			}
+			summarize(index.Libraries[0].Pages[0].Sections, 0)
+			tc.want.Equal(t, got)
+		})
+	}
+}
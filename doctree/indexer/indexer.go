@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -19,6 +20,7 @@ import (
 	"github.com/sourcegraph/doctree/doctree/apischema"
 	"github.com/sourcegraph/doctree/doctree/git"
 	"github.com/sourcegraph/doctree/doctree/schema"
+	"gopkg.in/yaml.v3"
 )
 
 // Language describes an indexer for a specific language.
@@ -30,7 +32,66 @@ type Language interface {
 	Extensions() []string
 
 	// IndexDir indexes a directory of code likely to contain sources in this language recursively.
-	IndexDir(ctx context.Context, dir string) (*schema.Index, error)
+	//
+	// Pages are grouped into a Library for each library described by the language's manifest
+	// files (e.g. go.mod or package.json), see GroupLibraries. Libraries without a version are
+	// versioned by the commit being indexed.
+	IndexDir(ctx context.Context, dir string, opts Options) (*schema.Index, error)
+}
+
+// Options describes how a Language should index a directory.
+type Options struct {
+	// Cache of the results of indexing files previously. Indexers should consult it before parsing
+	// a file, and record the result of parsing files in it. May be nil.
+	Cache *FileCache
+
+	// Include and Exclude are gitignore-style patterns, relative to the indexed directory, of files
+	// to index and not index respectively. If Include is empty, all files are included. Exclude
+	// takes precedence over Include.
+	//
+	// Indexers should find files to index using Walk or Sources, which respect these.
+	Include, Exclude []string
+
+	// Targets are the platforms to index platform-specific code for, in a language-specific format
+	// (e.g. "linux/amd64" for Go.) If empty, each language indexes for its default targets.
+	Targets []string
+
+	// IncludePrivate indicates private symbols (e.g. unexported Go identifiers, or Python names
+	// starting with an underscore) should be indexed too, with schema.VisibilityPrivate.
+	IncludePrivate bool
+
+	// LanguageOptions are the options specific to the language being indexed, as configured in the
+	// project's doctree.yaml file. Use DecodeLanguageOptions to decode them.
+	LanguageOptions *yaml.Node
+
+	// ProjectName is the name of the project being indexed, e.g. "github.com/sourcegraph/doctree",
+	// which the frontend URLs of its pages begin with (see PageURL.) Set by RunIndexers.
+	ProjectName string
+}
+
+// DecodeLanguageOptions decodes the language-specific options into v, which should be a pointer
+// to a struct with yaml tags. If no options are configured, v is left unchanged.
+func (o Options) DecodeLanguageOptions(v interface{}) error {
+	if o.LanguageOptions == nil {
+		return nil
+	}
+	if err := o.LanguageOptions.Decode(v); err != nil {
+		return errors.Wrap(err, "Decode")
+	}
+	return nil
+}
+
+// cacheKey returns a string which changes whenever options that affect the results of indexing
+// individual files change, so that cached results are not used.
+func (o Options) cacheKey() string {
+	var languageOptions []byte
+	if o.LanguageOptions != nil {
+		languageOptions, _ = yaml.Marshal(o.LanguageOptions)
+	}
+	if o.IncludePrivate {
+		languageOptions = append(languageOptions, "\nincludePrivate"...)
+	}
+	return hashContent(languageOptions)
 }
 
 // Registered indexers by language ID ("go", "objc", "cpp", etc.)
@@ -44,11 +105,22 @@ func Register(indexer Language) {
 // IndexDir indexes the specified directory recursively. It looks at the file extension of every
 // file, and then asks the registered indexers for each language to index.
 //
+// Files which have not changed since they were recorded in the manifest are not parsed again. The
+// manifest is updated to describe the files that were indexed.
+//
+// The project's doctree.yaml configuration file, if any, is applied on top of opts.
+//
 // Returns the successful indexes and any errors.
-func IndexDir(ctx context.Context, dir string) (map[string]*schema.Index, error) {
+func IndexDir(ctx context.Context, dir string, manifest *Manifest, opts Options) (map[string]*schema.Index, error) {
+	config, err := ReadConfig(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "ReadConfig")
+	}
+	opts = config.Options(opts)
+
 	// Identify all file extensions in the directory recursively.
 	extensions := map[string]struct{}{}
-	if err := fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+	if err := Walk(dir, opts, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err // error walking dir
 		}
@@ -59,16 +131,21 @@ func IndexDir(ctx context.Context, dir string) (map[string]*schema.Index, error)
 		}
 		return nil
 	}); err != nil {
-		return nil, errors.Wrap(err, "WalkDir")
+		return nil, errors.Wrap(err, "Walk")
 	}
 
-	// Map extensions to indexers.
-	indexersByExtension := map[string][]Language{}
+	// Identify the languages to index: those with a file extension found in the directory. Each is
+	// indexed once, even if several of its extensions are found.
+	var indexers []Language
 	for _, language := range Registered {
+		if !config.LanguageEnabled(language.Name().ID) {
+			continue
+		}
 		for _, ext := range language.Extensions() {
-			indexers := indexersByExtension[ext]
-			indexers = append(indexers, language)
-			indexersByExtension[ext] = indexers
+			if _, ok := extensions[ext]; ok {
+				indexers = append(indexers, language)
+				break
+			}
 		}
 	}
 
@@ -84,35 +161,62 @@ func IndexDir(ctx context.Context, dir string) (map[string]*schema.Index, error)
 		mu      sync.Mutex
 		errs    error
 		results = map[string]*schema.Index{}
+
+		previousFiles   = manifest.Files
+		previousOptions = manifest.Options
 	)
+	manifest.Version = projectDirVersion
+	manifest.Files = map[string]map[string]ManifestFile{}
+	manifest.Options = map[string]string{}
 	// TODO: configurable parallelism?
-	for ext := range extensions {
-		ext := ext
-		for _, indexer := range indexersByExtension[ext] {
-			indexer := indexer
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				start := time.Now()
-				index, err := indexer.IndexDir(ctx, dir)
-				if index != nil {
-					index.GitRepository, _ = git.URIForFile(dir)
-					index.GitCommitID, _ = git.RevParse(dir, false, "HEAD")
-					index.GitRefName, _ = git.RevParse(dir, true, "HEAD")
-					index.DurationSeconds = time.Since(start).Seconds()
-					index.CreatedAt = time.Now().Format(time.RFC3339)
-					index.Directory = absDir
+	for _, indexer := range indexers {
+		indexer := indexer
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			opts := opts
+			if languageOptions, ok := config.LanguageOptions[indexer.Name().ID]; ok {
+				opts.LanguageOptions = &languageOptions
+			}
+			cache := NewFileCache(nil)
+			if previousOptions[indexer.Name().ID] == opts.cacheKey() {
+				cache = NewFileCache(previousFiles[indexer.Name().ID])
+			}
+			opts.Cache = cache
+			index, err := indexer.IndexDir(ctx, dir, opts)
+			if index != nil {
+				index.GitRepository, _ = git.URIForFile(dir)
+				index.GitCommitID, _ = git.RevParse(dir, false, "HEAD")
+				for i := range index.Libraries {
+					library := &index.Libraries[i]
+					if library.Version == "" && index.GitCommitID != "" {
+						// No version was found, e.g. go.mod has none, so use the commit being indexed.
+						library.Version = index.GitCommitID
+						library.VersionType = "commit"
+					}
 				}
-
-				mu.Lock()
-				defer mu.Unlock()
-				if err != nil {
-					errs = multierror.Append(errs, errors.Wrap(err, indexer.Name().ID+": IndexDir"))
-				} else {
-					results[indexer.Name().ID] = index
+				if len(index.Libraries) == 1 {
+					// The override describes a single library, so it can't apply to a monorepo.
+					config.Library.Apply(&index.Libraries[0])
 				}
-			}()
-		}
+				index.GitRefName, _ = git.RevParse(dir, true, "HEAD")
+				index.GitDirectory, _ = git.RevParse(dir, false, "--show-prefix")
+				index.DurationSeconds = time.Since(start).Seconds()
+				index.CreatedAt = time.Now().Format(time.RFC3339)
+				index.Directory = absDir
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = multierror.Append(errs, errors.Wrap(err, indexer.Name().ID+": IndexDir"))
+			} else {
+				results[indexer.Name().ID] = index
+				manifest.Files[indexer.Name().ID] = cache.Files()
+				manifest.Options[indexer.Name().ID] = opts.cacheKey()
+			}
+		}()
 	}
 	wg.Wait()
 	return results, errs
@@ -213,7 +317,7 @@ func GetIndex(ctx context.Context, dataDir, indexDataDir, projectName string, au
 		return nil, errors.Wrap(err, "ReadDir")
 	}
 	for _, info := range dir {
-		if !info.IsDir() && info.Name() != "search-index.sinter" && info.Name() != "version" {
+		if !info.IsDir() && info.Name() != "search-index.sinter" && info.Name() != "version" && info.Name() != "manifest" {
 			lang := info.Name()
 
 			indexFile := filepath.Join(indexDataDir, indexName, lang)
@@ -256,6 +360,50 @@ func GetIndex(ctx context.Context, dataDir, indexDataDir, projectName string, au
 	return indexes, nil
 }
 
+// WithPermalinks returns a copy of the page in which the location of the page, its sections and
+// subpages have a URL linking to the source code on the code host at the commit that was indexed.
+func WithPermalinks(page schema.Page, index schema.Index, templates git.URLTemplates) schema.Page {
+	permalink := func(location *schema.Location) *schema.Location {
+		if location == nil {
+			return nil
+		}
+		cpy := *location
+		cpy.URL = templates.Permalink(
+			index.GitRepository,
+			index.GitCommitID,
+			path.Join(index.GitDirectory, location.Path),
+			location.StartLine,
+			location.EndLine,
+		)
+		return &cpy
+	}
+
+	var withSections func(sections []schema.Section) []schema.Section
+	withSections = func(sections []schema.Section) []schema.Section {
+		if sections == nil {
+			return nil
+		}
+		cpy := make([]schema.Section, 0, len(sections))
+		for _, section := range sections {
+			section.Location = permalink(section.Location)
+			section.Children = withSections(section.Children)
+			cpy = append(cpy, section)
+		}
+		return cpy
+	}
+
+	page.Location = permalink(page.Location)
+	page.Sections = withSections(page.Sections)
+	if page.Subpages != nil {
+		subpages := make([]schema.Page, 0, len(page.Subpages))
+		for _, subpage := range page.Subpages {
+			subpages = append(subpages, WithPermalinks(subpage, index, templates))
+		}
+		page.Subpages = subpages
+	}
+	return page
+}
+
 func CloneAndIndexIfOutdated(ctx context.Context, projectName, repositoryURL, dataDir, indexedCommit string) error {
 	// Clone the repository into a temp dir.
 	dir, err := os.MkdirTemp(os.TempDir(), "doctree-clone")
@@ -279,7 +427,7 @@ func CloneAndIndexIfOutdated(ctx context.Context, projectName, repositoryURL, da
 	}
 	if indexedCommit != latestGitCommit {
 		// Index the repository.
-		if err := RunIndexers(ctx, repoDir, dataDir, projectName); err != nil {
+		if err := RunIndexers(ctx, repoDir, dataDir, projectName, Options{}); err != nil {
 			return errors.Wrap(err, "RunIndexers")
 		}
 	}
@@ -304,7 +452,7 @@ func cloneAndIndex(ctx context.Context, repositoryURL, dataDir string) error {
 
 	// Index the repository.
 	projectName := strings.TrimPrefix(repositoryURL, "https://")
-	if err := RunIndexers(ctx, filepath.Join(dir, "repo"), dataDir, projectName); err != nil {
+	if err := RunIndexers(ctx, filepath.Join(dir, "repo"), dataDir, projectName, Options{}); err != nil {
 		return errors.Wrap(err, "RunIndexers")
 	}
 	return err
@@ -321,12 +469,22 @@ func decodeProjectName(name string) string {
 type AutoIndexedProject struct {
 	// Name of the project to be auto-indexed
 	Name string `json:"name"`
+
+	// Include and Exclude patterns to use when indexing the project, see Options.
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+
+	// IncludePrivate indicates private symbols should be indexed too, see Options.
+	IncludePrivate bool `json:"includePrivate,omitempty"`
 }
 
 // Runs all the registered language indexes along with the search indexer and stores the results.
 //
+// Files which have not changed since the project was last indexed are not parsed again, but the
+// project's indexes and search index are still rebuilt and written in full.
+//
 // If an error is returned, it may be the case that some indexers succeeded while others failed.
-func RunIndexers(ctx context.Context, dir, dataDir, projectName string) error {
+func RunIndexers(ctx context.Context, dir, dataDir, projectName string, opts Options) error {
 	var err error
 
 	// Ensure the doctree data dir exists, and that it has a version file.
@@ -334,9 +492,20 @@ func RunIndexers(ctx context.Context, dir, dataDir, projectName string) error {
 		return errors.Wrap(err, "ensureDataDir")
 	}
 
+	// Read the manifest describing files we indexed previously, if any, so that we only need to
+	// parse files which have changed.
+	indexDataDir := filepath.Join(dataDir, "index")
+	projectDir := filepath.Join(indexDataDir, encodeProjectName(projectName))
+	manifest, manifestErr := ReadManifest(filepath.Join(projectDir, "manifest"))
+	if manifestErr != nil {
+		log.Println("ignoring manifest:", manifestErr)
+		manifest = &Manifest{}
+	}
+
 	// IndexDir may partially complete, with some indexers succeeding while others fail. In this
 	// case indexes and indexErr are both != nil.
-	indexes, indexErr := IndexDir(ctx, dir)
+	opts.ProjectName = projectName
+	indexes, indexErr := IndexDir(ctx, dir, manifest, opts)
 	for _, index := range indexes {
 		fmt.Printf("%v: indexed %v files (%v bytes) in %v\n", index.Language.ID, index.NumFiles, index.NumBytes, time.Duration(index.DurationSeconds*float64(time.Second)).Round(time.Millisecond))
 	}
@@ -344,15 +513,16 @@ func RunIndexers(ctx context.Context, dir, dataDir, projectName string) error {
 		err = multierror.Append(err, errors.Wrap(indexErr, "IndexDir"))
 	}
 
+	// Link references between symbols, now that we know about every symbol in the project.
+	ResolveLinks(projectName, indexes)
+
 	// Write indexes that we did produce.
-	indexDataDir := filepath.Join(dataDir, "index")
 	writeErr := WriteIndexes(projectName, indexDataDir, indexes)
 	if writeErr != nil {
 		err = multierror.Append(err, errors.Wrap(writeErr, "WriteIndexes"))
 	}
 
 	// Index for search the indexes that we did produce.
-	projectDir := filepath.Join(indexDataDir, encodeProjectName(projectName))
 	searchErr := IndexForSearch(projectName, indexDataDir, indexes)
 	if searchErr != nil {
 		if rmErr := os.RemoveAll(projectDir); rmErr != nil {
@@ -370,6 +540,13 @@ func RunIndexers(ctx context.Context, dir, dataDir, projectName string) error {
 		err = multierror.Append(err, errors.Wrap(searchErr, "WriteFile (version)"))
 	}
 
+	// Write the manifest, for the next time we index this project.
+	if writeErr == nil && searchErr == nil && versionErr == nil {
+		if manifestErr := WriteManifest(filepath.Join(projectDir, "manifest"), manifest); manifestErr != nil {
+			err = multierror.Append(err, errors.Wrap(manifestErr, "WriteManifest"))
+		}
+	}
+
 	return err
 }
 
@@ -378,7 +555,7 @@ func RunIndexers(ctx context.Context, dir, dataDir, projectName string) error {
 // this file is how we'd determine which directories need to be re-indexed / removed.
 //
 // An incrementing integer. No relation to other version numbers.
-const projectDirVersion = "2"
+const projectDirVersion = "14"
 
 // The version stored in e.g. ~/.doctree/version - indicating the version of the overall data
 // directory. If we need to change the directory structure in some way, change the autoindex file
@@ -0,0 +1,770 @@
+// Package java provides a doctree indexer implementation for Java.
+package java
+
+import (
+	"context"
+	"encoding/xml"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/java"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func init() {
+	indexer.Register(&javaIndexer{})
+}
+
+// Implements the indexer.Language interface.
+type javaIndexer struct{}
+
+func (i *javaIndexer) Name() schema.Language { return schema.LanguageJava }
+
+func (i *javaIndexer) Extensions() []string { return []string{"java"} }
+
+// Categories of types, in the order they are shown on a page.
+var categories = []struct{ id, label string }{
+	{"class", "Classes"},
+	{"interface", "Interfaces"},
+	{"enum", "Enums"},
+	{"record", "Records"},
+	{"annotation", "Annotation types"},
+}
+
+// javaPackage is a package, documented by the files declaring it.
+type javaPackage struct {
+	name     string
+	docs     string
+	location *schema.Location
+	sections map[string][]schema.Section
+}
+
+func (i *javaIndexer) IndexDir(ctx context.Context, dir string, opts indexer.Options) (*schema.Index, error) {
+	// Find Java sources
+	sources, err := indexer.Sources(dir, opts, ".java")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sources")
+	}
+	dirFS := os.DirFS(dir)
+
+	files := 0
+	bytes := 0
+	packages := map[string]*javaPackage{}
+	for _, path := range sources {
+		if indexer.IsTestFile(path, testDirs) || filepath.Base(path) == "module-info.java" {
+			continue
+		}
+		content, err := fs.ReadFile(dirFS, path)
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadFile")
+		}
+		files += 1
+		bytes += len(content)
+
+		file := &javaFile{}
+		if !opts.Cache.Get(path, content, file) {
+			file, err = indexFile(ctx, path, content, opts.IncludePrivate)
+			if err != nil {
+				return nil, errors.Wrap(err, path)
+			}
+			if err := opts.Cache.Put(path, content, file); err != nil {
+				return nil, errors.Wrap(err, "Put")
+			}
+		}
+
+		pkg, ok := packages[file.Package]
+		if !ok {
+			pkg = &javaPackage{
+				name:     file.Package,
+				location: &schema.Location{Path: filepath.ToSlash(path)},
+				sections: map[string][]schema.Section{},
+			}
+			packages[file.Package] = pkg
+		}
+		if filepath.Base(path) == "package-info.java" {
+			pkg.docs = file.PackageDocs
+			pkg.location = &schema.Location{Path: filepath.ToSlash(path)}
+		}
+		for category, sections := range file.Sections {
+			pkg.sections[category] = append(pkg.sections[category], sections...)
+		}
+	}
+
+	var pkgNames []string
+	for pkgName, pkg := range packages {
+		if len(pkg.sections) > 0 || pkg.docs != "" {
+			pkgNames = append(pkgNames, pkgName)
+		}
+	}
+	sort.Strings(pkgNames)
+
+	var pages []schema.Page
+	for _, pkgName := range pkgNames {
+		pkg := packages[pkgName]
+		pkgSearchKey := packageSearchKey(pkgName)
+
+		var sections []schema.Section
+		for _, category := range categories {
+			children := pkg.sections[category.id]
+			if len(children) == 0 {
+				continue
+			}
+			sort.SliceStable(children, func(i, j int) bool { return children[i].ShortLabel < children[j].ShortLabel })
+			sections = append(sections, schema.Section{
+				ID:         category.id,
+				ShortLabel: category.id,
+				Label:      schema.Markdown(category.label),
+				SearchKey:  []string{},
+				Category:   true,
+				Children:   withPackage(pkgSearchKey, children),
+			})
+		}
+
+		// Link to the packages directly nested within the package, e.g. com.example.util within
+		// com.example
+		var subpages []schema.Page
+		for _, subName := range pkgNames {
+			if pkgName != "" && strings.HasPrefix(subName, pkgName+".") && !strings.Contains(strings.TrimPrefix(subName, pkgName+"."), ".") {
+				subpages = append(subpages, schema.Page{
+					Path:      subName,
+					Title:     packageTitle(subName),
+					SearchKey: []string{},
+					Location:  packages[subName].location,
+					Sections:  []schema.Section{},
+				})
+			}
+		}
+
+		pagePath := pkgName
+		if pagePath == "" {
+			pagePath = "default"
+		}
+		pages = append(pages, schema.Page{
+			Path:      pagePath,
+			Title:     packageTitle(pkgName),
+			Detail:    schema.Markdown(pkg.docs),
+			SearchKey: pkgSearchKey,
+			Location:  pkg.location,
+			Sections:  sections,
+			Subpages:  subpages,
+		})
+	}
+
+	libraries, err := findLibraries(dir, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "findLibraries")
+	}
+
+	return &schema.Index{
+		SchemaVersion: schema.LatestVersion,
+		Language:      schema.LanguageJava,
+		NumFiles:      files,
+		NumBytes:      bytes,
+		Libraries:     indexer.GroupLibraries(libraries, pages, indexer.DefaultLibrary(dir)),
+	}, nil
+}
+
+func packageTitle(pkgName string) string {
+	if pkgName == "" {
+		return "Unnamed package"
+	}
+	return "Package " + pkgName
+}
+
+// packageSearchKey returns the search key of a package, e.g. ["com", ".", "example"] for
+// com.example, or none for the unnamed package.
+func packageSearchKey(pkgName string) []string {
+	key := []string{}
+	if pkgName == "" {
+		return key
+	}
+	for i, part := range strings.Split(pkgName, ".") {
+		if i > 0 {
+			key = append(key, ".")
+		}
+		key = append(key, part)
+	}
+	return key
+}
+
+// withPackage prefixes the search keys of sections (which are relative to their package) and their
+// children with the search key of the package.
+func withPackage(pkgSearchKey []string, sections []schema.Section) []schema.Section {
+	for i := range sections {
+		if len(sections[i].SearchKey) > 0 && len(pkgSearchKey) > 0 {
+			key := append(append([]string{}, pkgSearchKey...), ".")
+			sections[i].SearchKey = append(key, sections[i].SearchKey...)
+		}
+		sections[i].Children = withPackage(pkgSearchKey, sections[i].Children)
+	}
+	return sections
+}
+
+var (
+	gradleGroup       = regexp.MustCompile(`(?m)^\s*group\s*=?\s*["']([^"']+)["']`)
+	gradleVersion     = regexp.MustCompile(`(?m)^\s*version\s*=?\s*["']([^"']+)["']`)
+	gradleProjectName = regexp.MustCompile(`(?m)^\s*rootProject\.name\s*=\s*["']([^"']+)["']`)
+	mavenProperty     = regexp.MustCompile(`\$\{([^}]+)\}`)
+)
+
+// findLibraries finds Maven and Gradle projects in dir, i.e. directories containing a pom.xml or
+// build.gradle (or build.gradle.kts) file. Where a directory has both, the pom.xml is used.
+func findLibraries(dir string, opts indexer.Options) ([]indexer.LibraryRoot, error) {
+	buildFiles, err := indexer.FindFiles(dir, opts, "pom.xml", "build.gradle", "build.gradle.kts")
+	if err != nil {
+		return nil, errors.Wrap(err, "FindFiles")
+	}
+	sort.Slice(buildFiles, func(i, j int) bool {
+		// pom.xml sorts before build.gradle files in the same directory.
+		di, dj := path.Dir(filepath.ToSlash(buildFiles[i])), path.Dir(filepath.ToSlash(buildFiles[j]))
+		if di != dj {
+			return di < dj
+		}
+		return path.Base(buildFiles[i]) == "pom.xml"
+	})
+
+	var libraries []indexer.LibraryRoot
+	seen := map[string]bool{}
+	for _, buildFile := range buildFiles {
+		buildFile = filepath.ToSlash(buildFile)
+		libDir := path.Dir(buildFile)
+		if seen[libDir] {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(buildFile)))
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadFile")
+		}
+
+		var group, name, version string
+		if path.Base(buildFile) == "pom.xml" {
+			var pom struct {
+				GroupID    string `xml:"groupId"`
+				ArtifactID string `xml:"artifactId"`
+				Version    string `xml:"version"`
+				Parent     struct {
+					GroupID string `xml:"groupId"`
+					Version string `xml:"version"`
+				} `xml:"parent"`
+				Properties struct {
+					Entries []struct {
+						XMLName xml.Name
+						Value   string `xml:",chardata"`
+					} `xml:",any"`
+				} `xml:"properties"`
+			}
+			if err := xml.Unmarshal(content, &pom); err != nil {
+				return nil, errors.Wrap(err, buildFile)
+			}
+			// The group and version are inherited from the parent POM if not given.
+			group, name, version = pom.GroupID, pom.ArtifactID, pom.Version
+			if group == "" {
+				group = pom.Parent.GroupID
+			}
+			if version == "" {
+				version = pom.Parent.Version
+			}
+			properties := map[string]string{"project.version": version, "project.parent.version": pom.Parent.Version}
+			for _, entry := range pom.Properties.Entries {
+				properties[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+			}
+			version = mavenProperty.ReplaceAllStringFunc(version, func(ref string) string {
+				if value, ok := properties[mavenProperty.FindStringSubmatch(ref)[1]]; ok {
+					return value
+				}
+				return ref
+			})
+		} else {
+			if m := gradleGroup.FindSubmatch(content); m != nil {
+				group = string(m[1])
+			}
+			if m := gradleVersion.FindSubmatch(content); m != nil {
+				version = string(m[1])
+			}
+			// The project is named by the settings file next to it, or by its directory.
+			for _, settingsFile := range []string{"settings.gradle", "settings.gradle.kts"} {
+				settings, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(libDir), settingsFile))
+				if err != nil {
+					continue
+				}
+				if m := gradleProjectName.FindSubmatch(settings); m != nil {
+					name = string(m[1])
+					break
+				}
+			}
+			if name == "" {
+				name = indexer.DefaultLibrary(filepath.Join(dir, filepath.FromSlash(libDir))).Name
+			}
+		}
+		if name == "" {
+			continue
+		}
+		seen[libDir] = true
+
+		if group != "" {
+			name = group + ":" + name
+		}
+		library := schema.Library{Name: name, ID: name, Version: version}
+		if version != "" {
+			library.VersionType = "semver"
+		}
+		libraries = append(libraries, indexer.LibraryRoot{Dir: libDir, Library: library})
+	}
+	return libraries, nil
+}
+
+// javaFile is the result of indexing a single Java source file, as stored in the
+// indexer.FileCache. Search keys are relative to the package.
+type javaFile struct {
+	Package string `json:"package"`
+
+	// PackageDocs are the docs of the package, given by a package-info.java file.
+	PackageDocs string `json:"packageDocs"`
+
+	// Sections documenting types, by category ID, e.g. "class".
+	Sections map[string][]schema.Section `json:"sections"`
+}
+
+// indexFile indexes a single Java source file. Only public and protected types and members are
+// documented, unless includePrivate is true.
+func indexFile(ctx context.Context, path string, content []byte, includePrivate bool) (*javaFile, error) {
+	// Parse the file with tree-sitter.
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(java.GetLanguage())
+
+	tree, err := parser.ParseCtx(ctx, nil, rewriteRecords(content))
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseCtx")
+	}
+	defer tree.Close()
+
+	// Inspect the root node.
+	n := tree.RootNode()
+
+	f := &fileIndexer{content: content, path: filepath.ToSlash(path), includePrivate: includePrivate}
+	file := &javaFile{Sections: map[string][]schema.Section{}}
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		child := n.NamedChild(i)
+		if child.Type() == "package_declaration" {
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if name := child.NamedChild(j); name.Type() == "scoped_identifier" || name.Type() == "identifier" {
+					file.Package = name.Content(content)
+				}
+			}
+			if filepath.Base(path) == "package-info.java" {
+				file.PackageDocs, _ = f.docs(child)
+			}
+			continue
+		}
+		if section, category, ok := f.typeDecl(child, nil, "", false); ok {
+			file.Sections[category] = append(file.Sections[category], section)
+		}
+	}
+	return file, nil
+}
+
+// recordHeader matches the start of a record declaration, up to its components, e.g.
+// "record Point<T>("
+var recordHeader = regexp.MustCompile(`\brecord(\s+[\p{L}_$][\p{L}\p{N}_$]*\s*(?:<[^(){};]*>)?\s*)\(`)
+
+// rewriteRecords rewrites record declarations, e.g. `record Point(int x, int y) implements Shape {`,
+// as class declarations, e.g. `class  Point                implements Shape {`, as the tree-sitter
+// grammar only parses records nested within a class and without an implements clause. The
+// components are replaced by spaces, so that the positions of nodes are unchanged.
+func rewriteRecords(content []byte) []byte {
+	locs := recordHeader.FindAllIndex(content, -1)
+	if len(locs) == 0 {
+		return content
+	}
+	out := append([]byte{}, content...)
+	for _, loc := range locs {
+		start, end := loc[1]-1, -1
+		depth := 0
+		for i := start; i < len(out) && end < 0; i++ {
+			switch out[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			case ';', '{', '}':
+				i = len(out) // not a record declaration
+			}
+		}
+		if end < 0 {
+			continue
+		}
+		copy(out[loc[0]:], "class ")
+		for i := start; i <= end; i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
+
+// fileIndexer indexes the types within a single Java file.
+type fileIndexer struct {
+	content        []byte
+	path           string
+	includePrivate bool
+}
+
+// typeDecl returns the section documenting a class, interface, enum, record or annotation type
+// declaration and its members, its category, and whether it should be documented at all.
+// parentID is the ID of the type it is nested within, if any. inInterface reports whether it is
+// declared within an interface, and so is implicitly public.
+func (f *fileIndexer) typeDecl(decl *sitter.Node, searchKeyPrefix []string, parentID string, inInterface bool) (schema.Section, string, bool) {
+	var category string
+	switch decl.Type() {
+	case "class_declaration":
+		category = "class"
+		if keyword := childOfType(decl, "class"); keyword != nil && strings.HasPrefix(string(f.content[keyword.StartByte():]), "record") {
+			category = "record"
+		}
+	case "record_declaration":
+		category = "record"
+	case "interface_declaration":
+		category = "interface"
+	case "enum_declaration":
+		category = "enum"
+	case "annotation_type_declaration":
+		category = "annotation"
+	default:
+		return schema.Section{}, "", false
+	}
+	section, ok := f.section(decl, searchKeyPrefix, parentID, inInterface)
+	if !ok {
+		return schema.Section{}, "", false
+	}
+	section.Label = schema.Markdown(f.header(decl))
+	var members typeMembers
+	if category == "record" {
+		members.fields = f.recordComponents(decl, section)
+	}
+	if body := decl.ChildByFieldName("body"); body != nil {
+		f.members(body, section, category == "interface" || category == "annotation", &members)
+	}
+	section.Children = members.list()
+	return section, category, true
+}
+
+// typeMembers are the sections documenting the members of a type.
+type typeMembers struct {
+	constants, fields, constructors, methods, types []schema.Section
+}
+
+// list returns the sections documenting the members, ordered by their kind: enum constants, fields,
+// constructors, methods and nested types.
+func (m *typeMembers) list() []schema.Section {
+	var sections []schema.Section
+	for _, group := range [][]schema.Section{m.constants, m.fields, m.constructors, m.methods, m.types} {
+		sections = append(sections, group...)
+	}
+	return sections
+}
+
+// members adds the sections documenting the members declared in the body of a type.
+func (f *fileIndexer) members(body *sitter.Node, parent schema.Section, inInterface bool, members *typeMembers) {
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		member := body.NamedChild(i)
+		switch member.Type() {
+		case "enum_body_declarations":
+			// The members of an enum following its constants.
+			f.members(member, parent, false, members)
+
+		case "enum_constant":
+			section, _ := f.section(member, parent.SearchKey, parent.ID, true)
+			section.Label = schema.Markdown(indexer.Truncate(f.header(member)))
+			members.constants = append(members.constants, section)
+
+		case "field_declaration", "constant_declaration":
+			for j := 0; j < int(member.NamedChildCount()); j++ {
+				declarator := member.NamedChild(j)
+				if declarator.Type() != "variable_declarator" {
+					continue
+				}
+				section, ok := f.section(member, parent.SearchKey, parent.ID, inInterface)
+				name := declarator.ChildByFieldName("name")
+				if !ok || name == nil {
+					continue
+				}
+				section.ID = parent.ID + "." + name.Content(f.content)
+				section.ShortLabel = name.Content(f.content)
+				section.SearchKey = indexer.SearchKey(parent.SearchKey, section.ShortLabel)
+				label := f.modifiers(member) + " " + member.ChildByFieldName("type").Content(f.content) + " " + declarator.Content(f.content)
+				section.Label = schema.Markdown(indexer.Truncate(indexer.SingleLine(label)))
+				members.fields = append(members.fields, section)
+			}
+
+		case "constructor_declaration", "method_declaration", "annotation_type_element_declaration":
+			section, ok := f.section(member, parent.SearchKey, parent.ID, inInterface)
+			if !ok {
+				continue
+			}
+			section.Label = schema.Markdown(f.header(member))
+			switch member.Type() {
+			case "constructor_declaration":
+				// Constructors and methods are identified by their parameter types too, as they
+				// may be overloaded.
+				section.ID += "(" + f.parameterTypes(member) + ")"
+				members.constructors = append(members.constructors, section)
+			case "method_declaration":
+				section.ID += "(" + f.parameterTypes(member) + ")"
+				members.methods = append(members.methods, section)
+			default:
+				members.methods = append(members.methods, section)
+			}
+
+		default:
+			if section, _, ok := f.typeDecl(member, parent.SearchKey, parent.ID, inInterface); ok {
+				members.types = append(members.types, section)
+			}
+		}
+	}
+}
+
+// recordComponents returns the sections documenting the components of a record, e.g. x and y of
+// `record Point(int x, int y)`, which are public as they have accessor methods.
+func (f *fileIndexer) recordComponents(decl *sitter.Node, record schema.Section) []schema.Section {
+	name := decl.ChildByFieldName("name")
+	if name == nil {
+		return nil
+	}
+	// The components are read from the source, as they are not parsed (see rewriteRecords.)
+	rest := string(f.content[name.EndByte():])
+	start := strings.Index(rest, "(")
+	if start < 0 {
+		return nil
+	}
+	var components []string
+	depth, last := 0, start+1
+	for i := start; i < len(rest); i++ {
+		switch rest[i] {
+		case '(', '<':
+			depth++
+		case ')', '>':
+			depth--
+		case ',':
+			if depth == 1 {
+				components = append(components, rest[last:i])
+				last = i + 1
+			}
+		}
+		if depth == 0 {
+			components = append(components, rest[last:i])
+			break
+		}
+	}
+
+	var sections []schema.Section
+	for _, component := range components {
+		component = indexer.SingleLine(component)
+		fields := strings.Fields(component)
+		if len(fields) == 0 {
+			continue
+		}
+		componentName := fields[len(fields)-1]
+		sections = append(sections, schema.Section{
+			ID:         record.ID + "." + componentName,
+			ShortLabel: componentName,
+			Label:      schema.Markdown(component),
+			Visibility: schema.VisibilityPublic,
+			SearchKey:  indexer.SearchKey(record.SearchKey, componentName),
+			Location:   record.Location,
+		})
+	}
+	return sections
+}
+
+// section returns a section documenting a named declaration within the given type, without a
+// label, or false if it is not visible outside of its package (unless includePrivate is true.)
+func (f *fileIndexer) section(decl *sitter.Node, searchKeyPrefix []string, parentID string, inInterface bool) (schema.Section, bool) {
+	pub := isPublic(f.content, decl, inInterface)
+	if !pub && !f.includePrivate {
+		return schema.Section{}, false
+	}
+	visibility := schema.VisibilityPublic
+	if !pub {
+		visibility = schema.VisibilityPrivate
+	}
+	name := ""
+	if nameNode := decl.ChildByFieldName("name"); nameNode != nil {
+		name = nameNode.Content(f.content)
+	}
+	id := name
+	if parentID != "" {
+		id = parentID + "." + name
+	}
+	docs, deprecated := f.docs(decl)
+	return schema.Section{
+		ID:         id,
+		ShortLabel: name,
+		Visibility: visibility,
+		Detail:     schema.Markdown(docs),
+		SearchKey:  indexer.SearchKey(searchKeyPrefix, name),
+		Location:   indexer.NodeLocation(f.path, decl),
+		Deprecated: deprecated || hasAnnotation(f.content, decl, "Deprecated"),
+	}, true
+}
+
+// isPublic reports whether a declaration is visible outside of its package, i.e. is public or
+// protected, or is declared within an interface without being private.
+func isPublic(content []byte, decl *sitter.Node, inInterface bool) bool {
+	if decl.Type() == "enum_constant" {
+		return true
+	}
+	modifiers := childOfType(decl, "modifiers")
+	if modifiers == nil {
+		return inInterface
+	}
+	for i := 0; i < int(modifiers.ChildCount()); i++ {
+		switch modifiers.Child(i).Type() {
+		case "public", "protected":
+			return true
+		case "private":
+			return false
+		}
+	}
+	return inInterface
+}
+
+// hasAnnotation reports whether a declaration has the named annotation, e.g. @Deprecated.
+func hasAnnotation(content []byte, decl *sitter.Node, name string) bool {
+	modifiers := childOfType(decl, "modifiers")
+	if modifiers == nil {
+		return false
+	}
+	for i := 0; i < int(modifiers.NamedChildCount()); i++ {
+		annotation := modifiers.NamedChild(i)
+		if annotation.Type() != "marker_annotation" && annotation.Type() != "annotation" {
+			continue
+		}
+		if annotationName := annotation.ChildByFieldName("name"); annotationName != nil {
+			if n := annotationName.Content(content); n == name || strings.HasSuffix(n, "."+name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// modifiers returns the modifiers of a declaration, e.g. "public static", without annotations.
+func (f *fileIndexer) modifiers(decl *sitter.Node) string {
+	modifiers := childOfType(decl, "modifiers")
+	if modifiers == nil {
+		return ""
+	}
+	var keywords []string
+	for i := 0; i < int(modifiers.ChildCount()); i++ {
+		if child := modifiers.Child(i); !child.IsNamed() {
+			keywords = append(keywords, child.Content(f.content))
+		}
+	}
+	return strings.Join(keywords, " ")
+}
+
+// header returns the declaration without its annotations and body, on a single line, e.g.
+// "public static <T> List<T> of(T... values) throws IOException" or "public class Foo extends Bar"
+func (f *fileIndexer) header(decl *sitter.Node) string {
+	start, end := decl.StartByte(), decl.EndByte()
+	if modifiers := childOfType(decl, "modifiers"); modifiers != nil {
+		start = modifiers.EndByte()
+	}
+	if body := decl.ChildByFieldName("body"); body != nil {
+		end = body.StartByte()
+	} else if body := childOfType(decl, "class_body"); body != nil {
+		end = body.StartByte() // e.g. the body of an enum constant
+	}
+	header := indexer.SingleLine(f.modifiers(decl) + " " + string(f.content[start:end]))
+	return strings.TrimRight(header, "; ")
+}
+
+// parameterTypes returns the types of the parameters of a method or constructor without type
+// arguments, e.g. "int,List" for `(int x, List<String> y)`
+func (f *fileIndexer) parameterTypes(decl *sitter.Node) string {
+	params := decl.ChildByFieldName("parameters")
+	if params == nil {
+		return ""
+	}
+	var types []string
+	for i := 0; i < int(params.NamedChildCount()); i++ {
+		param := params.NamedChild(i)
+		var typ string
+		switch param.Type() {
+		case "formal_parameter":
+			if typeNode := param.ChildByFieldName("type"); typeNode != nil {
+				typ = typeNode.Content(f.content)
+			}
+		case "spread_parameter":
+			// e.g. "String... values"
+			for j := 0; j < int(param.NamedChildCount()); j++ {
+				if child := param.NamedChild(j); child.Type() != "modifiers" && child.Type() != "variable_declarator" {
+					typ = child.Content(f.content) + "..."
+					break
+				}
+			}
+		default:
+			continue
+		}
+		types = append(types, stripTypeArguments(indexer.SingleLine(typ)))
+	}
+	return strings.Join(types, ",")
+}
+
+// stripTypeArguments removes type arguments from a type, e.g. "Map" for "Map<String, Integer>"
+func stripTypeArguments(typ string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range typ {
+		switch {
+		case r == '<':
+			depth++
+		case r == '>':
+			depth--
+		case depth == 0 && r != ' ':
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// docs returns the Markdown docs of a declaration from the Javadoc comment preceding it, and
+// whether it is marked @deprecated.
+func (f *fileIndexer) docs(node *sitter.Node) (string, bool) {
+	prev := node.PrevNamedSibling()
+	if prev == nil || prev.Type() != "block_comment" {
+		return "", false
+	}
+	comment := prev.Content(f.content)
+	if !strings.HasPrefix(comment, "/**") || comment == "/**/" {
+		return "", false
+	}
+	return javadoc(comment)
+}
+
+// childOfType returns the first child of node with the given type, or nil.
+func childOfType(node *sitter.Node, typ string) *sitter.Node {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if child := node.Child(i); child.Type() == typ {
+			return child
+		}
+	}
+	return nil
+}
+
+// testDirs are the directories containing tests rather than library code, e.g.
+// src/test/java/FooTest.java, which are not indexed.
+var testDirs = []string{"test", "tests"}
@@ -0,0 +1,246 @@
+package java
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func Test_rewriteRecords(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{
+			name:    "implements",
+			content: "public record Point(int x, int y) implements Shape {}",
+			want:    "public class  Point               implements Shape {}",
+		},
+		{
+			name:    "generic multi-line",
+			content: "record Pair<A, B>(\n    A first,\n    B second) {}",
+			want:    "class  Pair<A, B> \n            \n              {}",
+		},
+		{
+			name:    "not a record",
+			content: "int record(int x);\nvoid record() {}",
+			want:    "int record(int x);\nvoid record() {}",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(rewriteRecords([]byte(tc.content))); got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestIndexDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"pom.xml": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0.0</version>
+</project>
+`,
+		"src/main/java/com/example/package-info.java": `/** The example application. */
+package com.example;
+`,
+		"src/main/java/com/example/App.java": `package com.example;
+
+/** The application. */
+public class App {
+    /** The name. */
+    protected String name;
+
+    private int count;
+
+    /** Runs it. */
+    public void run() {}
+
+    void helper() {}
+}
+
+class Hidden {}
+`,
+		"src/main/java/com/example/util/Strings.java": `package com.example.util;
+
+public interface Strings {
+    String trim(String s);
+}
+`,
+		"src/main/java/com/example/util/internal/Impl.java": `package com.example.util.internal;
+
+public record Impl(int x) implements Runnable {
+    public void run() {}
+}
+`,
+		"src/main/java/Main.java": `public class Main {
+    public static void main(String[] args) {}
+}
+`,
+		"src/test/java/com/example/AppTest.java": `package com.example;
+
+public class AppTest {}
+`,
+	})
+
+	tests := []struct {
+		name string
+		opts indexer.Options
+		want autogold.Value
+	}{
+		{
+			name: "public",
+			want: autogold.Want("public", []string{
+				"library com.example:app 1.0.0", "default (Unnamed package) src/main/java/Main.java: ",
+				"  class ",
+				"    Main Main",
+				"      Main.main(String[]) Main.main",
+				"com.example (Package com.example) src/main/java/com/example/package-info.java: com.example.util",
+				"  class ",
+				"    App com.example.App",
+				"      App.name com.example.App.name",
+				"      App.run() com.example.App.run",
+				"com.example.util (Package com.example.util) src/main/java/com/example/util/Strings.java: com.example.util.internal",
+				"  interface ",
+				"    Strings com.example.util.Strings",
+				"      Strings.trim(String) com.example.util.Strings.trim",
+				"com.example.util.internal (Package com.example.util.internal) src/main/java/com/example/util/internal/Impl.java: ",
+				"  record ",
+				"    Impl com.example.util.internal.Impl",
+				"      Impl.x com.example.util.internal.Impl.x",
+				"      Impl.run() com.example.util.internal.Impl.run",
+			}),
+		},
+		{
+			name: "include private",
+			opts: indexer.Options{IncludePrivate: true},
+			want: autogold.Want("include private", []string{
+				"library com.example:app 1.0.0", "default (Unnamed package) src/main/java/Main.java: ",
+				"  class ",
+				"    Main Main",
+				"      Main.main(String[]) Main.main",
+				"com.example (Package com.example) src/main/java/com/example/package-info.java: com.example.util",
+				"  class ",
+				"    App com.example.App",
+				"      App.name com.example.App.name",
+				"      App.count com.example.App.count (private)",
+				"      App.run() com.example.App.run",
+				"      App.helper() com.example.App.helper (private)",
+				"    Hidden com.example.Hidden (private)",
+				"com.example.util (Package com.example.util) src/main/java/com/example/util/Strings.java: com.example.util.internal",
+				"  interface ",
+				"    Strings com.example.util.Strings",
+				"      Strings.trim(String) com.example.util.Strings.trim",
+				"com.example.util.internal (Package com.example.util.internal) src/main/java/com/example/util/internal/Impl.java: ",
+				"  record ",
+				"    Impl com.example.util.internal.Impl",
+				"      Impl.x com.example.util.internal.Impl.x",
+				"      Impl.run() com.example.util.internal.Impl.run",
+			}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			index, err := (&javaIndexer{}).IndexDir(context.Background(), dir, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Each page as "path (title) location: subpages", followed by its sections.
+			var got []string
+			var add func(sections []schema.Section, depth int)
+			add = func(sections []schema.Section, depth int) {
+				for _, section := range sections {
+					line := strings.Repeat("  ", depth) + section.ID + " " + strings.Join(section.SearchKey, "")
+					if section.Visibility == schema.VisibilityPrivate {
+						line += " (private)"
+					}
+					got = append(got, line)
+					add(section.Children, depth+1)
+				}
+			}
+			for _, library := range index.Libraries {
+				got = append(got, "library "+library.Name+" "+library.Version)
+				for _, page := range library.Pages {
+					var subpages []string
+					for _, subpage := range page.Subpages {
+						subpages = append(subpages, subpage.Path)
+					}
+					got = append(got, page.Path+" ("+page.Title+") "+page.Location.Path+": "+strings.Join(subpages, ", "))
+					add(page.Sections, 1)
+				}
+			}
+			tc.want.Equal(t, got)
+		})
+	}
+}
+
+func Test_findLibraries(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"pom.xml": `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <version>2.0.0</version>
+  </parent>
+  <artifactId>root</artifactId>
+</project>
+`,
+		"props/pom.xml": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>props</artifactId>
+  <version>${revision}</version>
+  <properties>
+    <revision>1.2.3</revision>
+  </properties>
+</project>
+`,
+		"both/pom.xml":      "<project><artifactId>both-maven</artifactId></project>\n",
+		"both/build.gradle": "version = '9.9.9'\n",
+		"gradle/build.gradle": `group = 'org.example'
+version '0.1.0'
+`,
+		"gradle/settings.gradle":         "rootProject.name = 'named'\n",
+		"kotlin-dsl/build.gradle.kts":    "group = \"org.kts\"\n",
+		".gitignore":                     "build/\n",
+		"build/generated/pom.xml":        "<project><artifactId>ignored</artifactId></project>\n",
+		"unnamed/pom.xml":                "<project></project>\n",
+		"src/main/resources/pom.xml.bak": "",
+	})
+
+	libraries, err := findLibraries(dir, indexer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, library := range libraries {
+		got = append(got, library.Dir+": "+library.Library.Name+" "+library.Library.Version)
+	}
+	autogold.Want("libraries", []string{
+		".: com.example:root 2.0.0", "both: both-maven ",
+		"gradle: org.example:named 0.1.0",
+		"kotlin-dsl: org.kts:kotlin-dsl ",
+		"props: com.example:props 1.2.3",
+	}).Equal(t, got)
+}
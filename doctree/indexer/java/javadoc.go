@@ -0,0 +1,206 @@
+package java
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/doctree/doctree/indexer"
+)
+
+// javadocTag is a Javadoc block tag, e.g. "@param x the value"
+type javadocTag struct {
+	name, text string
+}
+
+// javadocLines splits a Javadoc comment into its description and block tags.
+func javadocLines(comment string) (description []string, tags []javadocTag) {
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/**"), "*/")
+	inPre := false
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		line = strings.TrimPrefix(line, " ")
+		if strings.Contains(line, "<pre>") {
+			inPre = true
+		}
+		if strings.Contains(line, "</pre>") {
+			inPre = false
+		}
+		if !inPre && strings.HasPrefix(line, "@") {
+			name, text, _ := strings.Cut(line, " ")
+			tags = append(tags, javadocTag{name: name, text: text})
+			continue
+		}
+		if len(tags) > 0 {
+			tags[len(tags)-1].text += "\n" + line
+			continue
+		}
+		description = append(description, line)
+	}
+	return description, tags
+}
+
+// javadoc converts a Javadoc comment to Markdown: its description followed by tables of the
+// parameters and exceptions, and the return value, documented by block tags such as @param.
+// Reports whether the comment has a @deprecated tag.
+func javadoc(comment string) (docs string, deprecated bool) {
+	description, tags := javadocLines(comment)
+	out := []string{javadocToMarkdown(strings.Join(description, "\n"))}
+	var params, typeParams, throws [][]string
+	var returns, see []string
+	for _, t := range tags {
+		text := javadocToMarkdown(t.text)
+		switch t.name {
+		case "@param":
+			name, desc := cutWord(t.text)
+			desc = javadocToMarkdown(desc)
+			if strings.HasPrefix(name, "<") {
+				typeParams = append(typeParams, []string{indexer.Code(strings.Trim(name, "<>")), desc})
+			} else {
+				params = append(params, []string{indexer.Code(name), desc})
+			}
+		case "@return":
+			returns = append(returns, text)
+		case "@throws", "@exception":
+			typ, desc := cutWord(t.text)
+			desc = javadocToMarkdown(desc)
+			throws = append(throws, []string{indexer.Code(typ), desc})
+		case "@deprecated":
+			deprecated = true
+			out = append(out, indexer.Deprecated(text))
+		case "@see":
+			see = append(see, text)
+		case "@since":
+			out = append(out, "Since "+text)
+		}
+	}
+	if len(returns) > 0 {
+		out = append(out, "**Returns**\n\n"+strings.Join(returns, "\n\n"))
+	}
+	out = append(out,
+		indexer.Table("Parameters", []string{"Name", "Description"}, params),
+		indexer.Table("Type parameters", []string{"Name", "Description"}, typeParams),
+		indexer.Table("Throws", []string{"Type", "Description"}, throws),
+	)
+	if len(see) > 0 {
+		out = append(out, "See "+strings.Join(see, ", "))
+	}
+
+	var nonEmpty []string
+	for _, s := range out {
+		if s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n"), deprecated
+}
+
+// cutWord splits text after its first word, e.g. the name of a parameter, and its description.
+func cutWord(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	end := strings.IndexAny(text, " \t\n")
+	if end < 0 {
+		return text, ""
+	}
+	return text[:end], strings.TrimSpace(text[end:])
+}
+
+var (
+	htmlPre       = regexp.MustCompile(`(?is)<pre>\s*(?:<code>)?(.*?)(?:</code>)?\s*</pre>`)
+	htmlParagraph = regexp.MustCompile(`(?i)\s*</?p>\s*`)
+	htmlBreak     = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlListItem  = regexp.MustCompile(`(?i)\s*<li>\s*`)
+	htmlList      = regexp.MustCompile(`(?i)\s*</?(?:ul|ol)>\s*`)
+	htmlListEnd   = regexp.MustCompile(`(?i)\s*</li>`)
+	htmlCode      = regexp.MustCompile(`(?i)</?(?:code|tt)>`)
+	htmlBold      = regexp.MustCompile(`(?i)</?(?:b|strong)>`)
+	htmlItalic    = regexp.MustCompile(`(?i)</?(?:i|em)>`)
+	htmlLink      = regexp.MustCompile(`(?is)<a\s+href="([^"]*)"\s*>(.*?)</a>`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+)
+
+// javadocToMarkdown converts Javadoc text, which is HTML with inline tags such as {@code x}, to
+// Markdown. Only the HTML elements commonly used in Javadoc are converted, others are left as-is.
+func javadocToMarkdown(text string) string {
+	var blocks []string
+	text = htmlPre.ReplaceAllStringFunc(text, func(pre string) string {
+		body := htmlPre.FindStringSubmatch(pre)[1]
+		body = strings.ReplaceAll(inlineTags(body, true), "&lt;", "<")
+		body = strings.ReplaceAll(strings.ReplaceAll(body, "&gt;", ">"), "&amp;", "&")
+		blocks = append(blocks, "```java\n"+strings.Trim(body, "\n")+"\n```")
+		return "\x00"
+	})
+	text = inlineTags(text, false)
+	text = htmlParagraph.ReplaceAllString(text, "\n\n")
+	text = htmlBreak.ReplaceAllString(text, "\n")
+	text = htmlListEnd.ReplaceAllString(text, "")
+	text = htmlListItem.ReplaceAllString(text, "\n* ")
+	text = htmlList.ReplaceAllString(text, "\n\n")
+	text = htmlCode.ReplaceAllString(text, "`")
+	text = htmlBold.ReplaceAllString(text, "**")
+	text = htmlItalic.ReplaceAllString(text, "*")
+	text = htmlLink.ReplaceAllString(text, "[$2]($1)")
+	for _, block := range blocks {
+		text = strings.Replace(text, "\x00", "\n\n"+block+"\n\n", 1)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
+
+// inlineTags converts Javadoc inline tags, e.g. {@code x} or {@link Foo#bar label}, to Markdown.
+// Within code blocks (inPre), they are replaced by their text.
+func inlineTags(text string, inPre bool) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(text, "{@")
+		if start < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		b.WriteString(text[:start])
+
+		// The tag may contain braces itself, e.g. {@code Map<String, {x}>}
+		depth, end := 0, -1
+		for i := start; i < len(text) && end < 0; i++ {
+			switch text[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			b.WriteString(text[start:])
+			return b.String()
+		}
+		name, arg := cutWord(text[start+2 : end])
+		text = text[end+1:]
+
+		switch {
+		case inPre:
+			b.WriteString(arg)
+		case name == "code":
+			b.WriteString(indexer.Code(arg))
+		case name == "literal":
+			b.WriteString(arg)
+		case name == "link" || name == "linkplain" || name == "value":
+			// e.g. {@link Foo#bar(int) label}, shown as its label or as "Foo.bar(int)"
+			ref, label := cutWord(arg)
+			if open, close := strings.Index(arg, "("), strings.Index(arg, ")"); open >= 0 && open < len(ref) && close > open {
+				ref, label = arg[:close+1], strings.TrimSpace(arg[close+1:]) // e.g. "#bar(int, String)"
+			}
+			if label == "" {
+				label = strings.TrimPrefix(strings.ReplaceAll(ref, "#", "."), ".")
+				if name != "linkplain" {
+					label = indexer.Code(label)
+				}
+			}
+			b.WriteString(label)
+		case name == "inheritDoc", name == "docRoot":
+		default:
+			b.WriteString(arg)
+		}
+	}
+}
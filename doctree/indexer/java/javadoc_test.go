@@ -0,0 +1,63 @@
+package java
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func Test_javadoc(t *testing.T) {
+	tests := []struct {
+		name           string
+		comment        string
+		want           string
+		wantDeprecated bool
+	}{
+		{
+			name:    "tags",
+			comment: "/**\n * Adds two {@code int}s.\n *\n * @param a the first\n * @param <T> the type\n * @return the sum\n * @throws IllegalArgumentException if {@code a < 0}\n * @since 1.2\n */",
+			want:    "Adds two `int`s.\n\nSince 1.2\n\n**Returns**\n\nthe sum\n\n**Parameters**\n\n| Name | Description |\n| --- | --- |\n| `a` | the first |\n\n**Type parameters**\n\n| Name | Description |\n| --- | --- |\n| `T` | the type |\n\n**Throws**\n\n| Type | Description |\n| --- | --- |\n| `IllegalArgumentException` | if `a < 0` |",
+		},
+		{
+			name:           "deprecated",
+			comment:        "/** @deprecated */",
+			want:           "Deprecated.",
+			wantDeprecated: true,
+		},
+		{
+			name:           "deprecated-link",
+			comment:        "/**\n * Adds.\n * @deprecated use {@link #plus(int, int)} instead\n * @see Math#addExact(int, int)\n */",
+			want:           "Adds.\n\nDeprecated: use `plus(int, int)` instead\n\nSee Math#addExact(int, int)",
+			wantDeprecated: true,
+		},
+		{
+			name:    "html",
+			comment: "/**\n * <p>Lists:\n * <ul>\n *   <li>one</li>\n *   <li><b>two</b></li>\n * </ul>\n * See <a href=\"https://example.com\">here</a>.\n */",
+			want:    "Lists:\n\n* one\n* **two**\n\nSee [here](https://example.com).",
+		},
+		{
+			name:    "pre",
+			comment: "/**\n * Example:\n * <pre>{@code\n * List<String> xs = new ArrayList<>();\n * @Override is not a tag\n * }</pre>\n */",
+			want:    "Example:\n\n```java\nList<String> xs = new ArrayList<>();\n@Override is not a tag\n```",
+		},
+		{
+			name:    "inline-tags",
+			comment: "/** Links {@link Foo}, {@link Foo#bar label} and {@linkplain #baz()}. {@inheritDoc} */",
+			want:    "Links `Foo`, label and baz().",
+		},
+		{
+			name:    "unterminated-inline-tag",
+			comment: "/** Unterminated {@code x */",
+			want:    "Unterminated {@code x",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, deprecated := javadoc(tc.comment)
+			autogold.Want(tc.name, tc.want).Equal(t, got)
+			if deprecated != tc.wantDeprecated {
+				t.Errorf("deprecated = %v, want %v", deprecated, tc.wantDeprecated)
+			}
+		})
+	}
+}
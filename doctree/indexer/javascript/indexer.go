@@ -3,13 +3,13 @@ package javascript
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
-	jsdoc "github.com/DaivikDave/tree-sitter-jsdoc/bindings/go"
 	"github.com/pkg/errors"
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/javascript"
@@ -26,34 +26,29 @@ type javascriptIndexer struct{}
 
 func (i *javascriptIndexer) Name() schema.Language { return schema.LanguageJavaScript }
 
-func (i *javascriptIndexer) Extensions() []string { return []string{"js"} }
+func (i *javascriptIndexer) Extensions() []string { return []string{"js", "mjs", "cjs", "jsx"} }
 
-func (i *javascriptIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index, error) {
+// Categories of declarations, in the order they are shown on a page.
+var categories = []struct{ id, label string }{
+	{"var", "Variables"},
+	{"func", "Functions"},
+	{"class", "Classes"},
+	{"type", "Types"},
+}
+
+func (i *javascriptIndexer) IndexDir(ctx context.Context, dir string, opts indexer.Options) (*schema.Index, error) {
 	// Find JavaScript sources
-	var sources []string
-	if err := fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err // error walking dir
-		}
-		if !d.IsDir() {
-			ext := filepath.Ext(path)
-			if ext == ".js" {
-				sources = append(sources, path)
-			}
-		}
-		return nil
-	}); err != nil {
-		return nil, errors.Wrap(err, "WalkDir")
+	sources, err := indexer.Sources(dir, opts, ".js", ".mjs", ".cjs", ".jsx")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sources")
 	}
 
 	files := 0
 	bytes := 0
-	mods := map[string]moduleInfo{}
-	functionsByMod := map[string][]schema.Section{}
-	classesByMod := map[string][]schema.Section{}
-
+	var pages []schema.Page
+	pagePaths := map[string]bool{}
 	for _, path := range sources {
-		if strings.Contains(path, "test_") || strings.Contains(path, "_test") || strings.Contains(path, "tests") || strings.Contains(path, "node_modules") {
+		if indexer.IsTestFile(path, testDirs, testFiles...) {
 			continue
 		}
 
@@ -66,533 +61,557 @@ func (i *javascriptIndexer) IndexDir(ctx context.Context, dir string) (*schema.I
 		files += 1
 		bytes += len(content)
 
-		// Parse the file with tree-sitter.
-		parser := sitter.NewParser()
-		defer parser.Close()
-		parser.SetLanguage(javascript.GetLanguage())
-
-		tree, err := parser.ParseCtx(ctx, nil, content)
-		if err != nil {
-			return nil, errors.Wrap(err, "ParseCtx")
-		}
-		defer tree.Close()
-
-		// Inspect the root node.
-		n := tree.RootNode()
-
-		// Module clauses
-		var modName string = strings.ReplaceAll(strings.TrimSuffix(path, "."), "/", ".")
-		{
-			query, err := sitter.NewQuery([]byte(`
-			(
-				(comment)* @module_docs
-				.
-				(import_statement)*
-				)
-			`), javascript.GetLanguage())
+		file := &javascriptFile{}
+		if !opts.Cache.Get(path, content, file) {
+			file, err = indexFile(ctx, path, content, opts.IncludePrivate)
 			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
+				return nil, errors.Wrap(err, path)
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			mods[modName] = moduleInfo{path: path, docs: ""}
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				modDocs := joinCaptures(content, captures["module_docs"], "\n")
-				modDocs = sanitizeDocs(modDocs)
-
-				mods[modName] = moduleInfo{path: path, docs: modDocs}
+			if err := opts.Cache.Put(path, content, file); err != nil {
+				return nil, errors.Wrap(err, "Put")
 			}
 		}
 
-		funcDefQuery := functionDefinitionQuery()
-
-		// Function definitions
-		{
-			modFunctions, err := getFunctions(n, content, funcDefQuery, []string{modName})
-			if err != nil {
-				return nil, err
-			}
-
-			functionsByMod[modName] = modFunctions
-
+		// Modules are named by their path without extension, so that e.g. foo/index.js is "foo" as
+		// it is imported, or by their full path if that would be ambiguous (e.g. with foo.js).
+		slashPath := filepath.ToSlash(path)
+		modName := moduleName(slashPath)
+		if pagePaths[modName] {
+			modName = slashPath
 		}
-
-		// Classes and their methods
-		{
-			// Find out all the classes
-			query, err := sitter.NewQuery([]byte(`
-			(
-				[
-					(
-						(comment)* @class_docs
-						.
-						(class_declaration
-							name: (identifier) @class_name
-							(class_heritage (identifier) @superclasses)? 
-						 	body: (class_body) 
-						) @class_declaration
-					)
-					(
-						(comment)* @class_docs
-						.
-						(export_statement
-							value: (class
-								name: (identifier) @class_name
-								(class_heritage (identifier) @superclasses)? 
-									body: (class_body) 
-							) @class_declaration
-						)	    
-					)
-				]
-			)
-			`), javascript.GetLanguage())
-			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
-			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			// Iterate over the classes
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				className := firstCaptureContentOr(content, captures["class_name"], "")
-				superClasses := firstCaptureContentOr(content, captures["superclasses"], "")
-				classDocs := firstCaptureContentOr(content, captures["class_docs"], "\n")
-				classDocs = extractClassDocs(classDocs)
-
-				classLabel := schema.Markdown("class " + className + superClasses)
-				classes := classesByMod[modName]
-
-				// Extract class methods:
-				classFuncQuery := `
-							(_
-								(comment)* @func_docs
-								.
-								member: (method_definition
-									name: (property_identifier) @func_name
-									parameters: (formal_parameters) @func_params
-								)    
-							)
-				`
-
-				var classMethods []schema.Section
-				classBodyNodes := captures["class_declaration"]
-				if len(classBodyNodes) > 0 {
-					classMethods, err = getFunctions(
-						classBodyNodes[0], content, classFuncQuery,
-						[]string{modName, ".", className},
-					)
-					if err != nil {
-						return nil, err
-					}
-				}
-				classes = append(classes, schema.Section{
-					ID:         className,
-					ShortLabel: className,
-					Label:      classLabel,
-					Detail:     schema.Markdown(classDocs),
-					SearchKey:  []string{modName, ".", className},
-					Children:   classMethods,
-				})
-				classesByMod[modName] = classes
+		pagePaths[modName] = true
+		modSearchKey := moduleSearchKey(modName)
+
+		var sections []schema.Section
+		for _, category := range categories {
+			children := file.Sections[category.id]
+			if len(children) == 0 {
+				continue
 			}
-		}
-	}
-
-	var pages []schema.Page
-	for modName, moduleInfo := range mods {
-		sections := []schema.Section{}
-
-		if funcSections, ok := functionsByMod[modName]; ok && len(funcSections) > 0 {
 			sections = append(sections, schema.Section{
-				ID:         "func",
-				ShortLabel: "func",
-				Label:      "Functions",
+				ID:         category.id,
+				ShortLabel: category.id,
+				Label:      schema.Markdown(category.label),
 				SearchKey:  []string{},
 				Category:   true,
-				Children:   functionsByMod[modName],
-			})
-		}
-
-		if classSections, ok := classesByMod[modName]; ok && len(classSections) > 0 {
-			sections = append(sections, schema.Section{
-				ID:         "class",
-				ShortLabel: "class",
-				Label:      "Classes",
-				SearchKey:  []string{},
-				Category:   true,
-				Children:   classesByMod[modName],
+				Children:   withModule(modSearchKey, children),
 			})
 		}
 
 		if len(sections) > 0 {
 			pages = append(pages, schema.Page{
-				Path:      moduleInfo.path,
+				Path:      modName,
 				Title:     "Module " + modName,
-				Detail:    schema.Markdown(moduleInfo.docs),
-				SearchKey: []string{modName},
+				Detail:    schema.Markdown(file.ModDocs),
+				SearchKey: modSearchKey,
+				Location:  &schema.Location{Path: slashPath},
 				Sections:  sections,
 			})
 		}
 	}
 
+	libraries, err := findLibraries(dir, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "findLibraries")
+	}
+
 	return &schema.Index{
 		SchemaVersion: schema.LatestVersion,
 		Language:      schema.LanguageJavaScript,
 		NumFiles:      files,
 		NumBytes:      bytes,
-		Libraries: []schema.Library{
-			{
-				Name:        "TODO",
-				ID:          "TODO",
-				Version:     "TODO",
-				VersionType: "TODO",
-				Pages:       pages,
-			},
-		},
+		Libraries:     indexer.GroupLibraries(libraries, pages, indexer.DefaultLibrary(dir)),
 	}, nil
 }
 
-func getFunctions(node *sitter.Node, content []byte, q string, searchKeyPrefix []string) ([]schema.Section, error) {
-	var functions []schema.Section
-	query, err := sitter.NewQuery([]byte(q), javascript.GetLanguage())
-	if err != nil {
-		return nil, errors.Wrap(err, "NewQuery")
+// moduleName returns the name of the module at the given slash-separated path, i.e. the path it is
+// imported by: without extension, or the directory for index files.
+func moduleName(p string) string {
+	p = strings.TrimSuffix(p, path.Ext(p))
+	if path.Base(p) == "index" && path.Dir(p) != "." {
+		p = path.Dir(p)
 	}
-	defer query.Close()
-
-	cursor := sitter.NewQueryCursor()
-	defer cursor.Close()
-	cursor.Exec(query, node)
+	return p
+}
 
-	for {
-		match, ok := cursor.NextMatch()
-		if !ok {
-			break
-		}
-		captures := getCaptures(query, match)
-		funcDocs := joinCaptures(content, captures["func_docs"], "\n")
-		funcDocs = extractFunctionDocs(funcDocs)
-		funcName := firstCaptureContentOr(content, captures["func_name"], "")
-		funcParams := firstCaptureContentOr(content, captures["func_params"], "")
-		funcIdentifier := firstCaptureContentOr(content, captures["var_identifier"], "")
-		isArrowFunction := firstCaptureContentOr(content, captures["arrow_function"], "")
-		funcLabel := schema.Markdown("function " + funcName + funcParams)
-		if funcIdentifier != "" {
-			funcName = funcIdentifier
-			if isArrowFunction != "" {
-				funcLabel = schema.Markdown(funcName + " = " + funcParams)
-			} else {
-				funcLabel = schema.Markdown(funcName + " =  function " + funcParams)
-			}
+// moduleSearchKey returns the search key of a module, e.g. ["src", "/", "foo"] for src/foo
+func moduleSearchKey(modName string) []string {
+	var key []string
+	for i, part := range strings.Split(modName, "/") {
+		if i > 0 {
+			key = append(key, "/")
 		}
-		functions = append(functions, schema.Section{
-			ID:         funcName,
-			ShortLabel: funcName,
-			Label:      funcLabel,
-			Detail:     schema.Markdown(funcDocs),
-			SearchKey:  append(searchKeyPrefix, ".", funcName),
-		})
+		key = append(key, part)
 	}
-
-	return functions, nil
+	return key
 }
 
-func extractClassDocs(s string) string {
-	// JSDoc comments must start with a /**
-	// sequence in order to be recognized by the JSDoc parser.
-	// Comments beginning with /*, /***, or more than 3 stars are ignored by Jsdoc Parser.
-	if !strings.HasPrefix(s, "/**") && strings.HasPrefix(s, "/***") {
-		return sanitizeDocs(s)
+// withModule prefixes the search keys of sections (which are relative to their module) and their
+// children with the search key of the module.
+func withModule(modSearchKey []string, sections []schema.Section) []schema.Section {
+	for i := range sections {
+		if len(sections[i].SearchKey) > 0 {
+			key := append(append([]string{}, modSearchKey...), ".")
+			sections[i].SearchKey = append(key, sections[i].SearchKey...)
+		}
+		sections[i].Children = withModule(modSearchKey, sections[i].Children)
 	}
+	return sections
+}
 
-	comment := []byte(s)
-	classDocs := ""
-	node, err := sitter.ParseCtx(context.Background(), comment, jsdoc.GetLanguage())
+// findLibraries finds npm packages in dir, i.e. directories containing a package.json file.
+func findLibraries(dir string, opts indexer.Options) ([]indexer.LibraryRoot, error) {
+	packageFiles, err := indexer.FindFiles(dir, opts, "package.json")
 	if err != nil {
-		return ""
+		return nil, errors.Wrap(err, "FindFiles")
 	}
-	query, err := sitter.NewQuery([]byte(`
-	(document
-		(description)? @class_description
-		(tag
-			(tag_name)? @tag_name
-			(type)? @identifier_type
-			(identifier)? @identifier_name
-			(description)? @identifier_description
-		)
-	)*
-	`), jsdoc.GetLanguage())
-	if err != nil {
-		return ""
+	var libraries []indexer.LibraryRoot
+	for _, packageFile := range packageFiles {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(packageFile)))
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadFile")
+		}
+		var pkg struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(content, &pkg); err != nil {
+			return nil, errors.Wrap(err, packageFile)
+		}
+		if pkg.Name == "" {
+			continue // e.g. a private workspace root
+		}
+		library := schema.Library{Name: pkg.Name, ID: pkg.Name, Version: pkg.Version}
+		if pkg.Version != "" {
+			library.VersionType = "semver"
+		}
+		libraries = append(libraries, indexer.LibraryRoot{Dir: path.Dir(packageFile), Library: library})
 	}
+	return libraries, nil
+}
 
-	defer query.Close()
+// indexFile indexes a single JavaScript source file.
+//
+// If the file is a module, i.e. it has ES module exports (export ...) or CommonJS exports
+// (module.exports = ... or exports.foo = ...), only the exported declarations are public. Otherwise
+// every top-level declaration is, as with scripts declaring globals. Private declarations, and
+// those whose names start with an underscore (by convention), are only included if includePrivate
+// is true.
+func indexFile(ctx context.Context, path string, content []byte, includePrivate bool) (*javascriptFile, error) {
+	// Parse the file with tree-sitter.
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(javascript.GetLanguage())
+
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseCtx")
+	}
+	defer tree.Close()
 
-	cursor := sitter.NewQueryCursor()
-	defer cursor.Close()
-	cursor.Exec(query, node)
+	// Inspect the root node.
+	n := tree.RootNode()
 
-	propertiesSection := ""
-	for {
-		match, ok := cursor.NextMatch()
-		if !ok {
-			break
+	f := &fileIndexer{
+		content:        content,
+		path:           filepath.ToSlash(path),
+		includePrivate: includePrivate,
+		exportedNames:  map[string]bool{},
+		lists:          map[string]*sectionList{},
+	}
+	f.exports(n)
+
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		stmt := n.NamedChild(i)
+		switch stmt.Type() {
+		case "comment":
+			f.typedefs(stmt)
+		case "export_statement":
+			if decl := stmt.ChildByFieldName("declaration"); decl != nil {
+				f.declaration(decl, stmt, true)
+			} else if value := stmt.ChildByFieldName("value"); value != nil {
+				f.value("default", value, stmt) // export default ...
+			}
+		case "expression_statement":
+			f.commonJSExport(stmt)
+		default:
+			f.declaration(stmt, stmt, !f.isModule)
 		}
-		captures := getCaptures(query, match)
-		classDescription := firstCaptureContentOr(comment, captures["class_description"], "")
-		classDocs = fmt.Sprintf("%s\n", classDescription)
+	}
 
-		identifierType := firstCaptureContentOr(comment, captures["identifier_type"], "")
-		if identifierType != "" {
-			identifierType = fmt.Sprintf(" (%s)", identifierType)
-		}
-		identifierName := firstCaptureContentOr(comment, captures["identifier_name"], "")
-		identifierDescription := firstCaptureContentOr(comment, captures["identifier_description"], "")
-		if identifierDescription != "" {
-			identifierDescription = fmt.Sprintf(": %s", identifierDescription)
-		}
+	file := &javascriptFile{ModDocs: f.moduleDocs(n), Sections: map[string][]schema.Section{}}
+	for category, list := range f.lists {
+		file.Sections[category] = list.list()
+	}
+	return file, nil
+}
+
+// fileIndexer indexes the declarations within a single JavaScript file.
+type fileIndexer struct {
+	content        []byte
+	path           string
+	includePrivate bool
+
+	// isModule indicates the file has ES module or CommonJS exports.
+	isModule bool
 
-		tagName := firstCaptureContentOr(comment, captures["tag_name"], "")
-		switch tagName {
-		case "@property":
-			propertiesSection += fmt.Sprintf("\n\t%s%s%s", identifierName, identifierType, identifierDescription)
+	// Names exported separately from their declaration, e.g. "export { foo }" or
+	// "module.exports = { foo }"
+	exportedNames map[string]bool
+
+	// Sections documenting declarations, by category ID.
+	lists map[string]*sectionList
+}
+
+// exports records whether the file is a module, and the names of declarations exported separately
+// from their declaration.
+func (f *fileIndexer) exports(n *sitter.Node) {
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		stmt := n.NamedChild(i)
+		switch stmt.Type() {
+		case "import_statement":
+			f.isModule = true
+		case "export_statement":
+			f.isModule = true
+			for j := 0; j < int(stmt.NamedChildCount()); j++ {
+				clause := stmt.NamedChild(j)
+				if clause.Type() != "export_clause" {
+					continue
+				}
+				for k := 0; k < int(clause.NamedChildCount()); k++ {
+					if name := clause.NamedChild(k).ChildByFieldName("name"); name != nil {
+						f.exportedNames[name.Content(f.content)] = true
+					}
+				}
+			}
+			if value := stmt.ChildByFieldName("value"); value != nil && value.Type() == "identifier" {
+				f.exportedNames[value.Content(f.content)] = true // export default foo
+			}
+		case "expression_statement":
+			target, value := commonJSAssignment(f.content, stmt)
+			if target == "" {
+				continue
+			}
+			f.isModule = true
+			if value.Type() == "identifier" {
+				f.exportedNames[value.Content(f.content)] = true // module.exports = foo
+			} else if value.Type() == "object" && target == "module.exports" {
+				// module.exports = { foo, bar: baz }
+				for j := 0; j < int(value.NamedChildCount()); j++ {
+					property := value.NamedChild(j)
+					if property.Type() == "shorthand_property_identifier" {
+						f.exportedNames[property.Content(f.content)] = true
+					} else if v := property.ChildByFieldName("value"); property.Type() == "pair" && v != nil && v.Type() == "identifier" {
+						f.exportedNames[v.Content(f.content)] = true
+					}
+				}
+			}
 		}
 	}
+}
 
-	if len(propertiesSection) > 0 {
-		classDocs += fmt.Sprintf("\n Properties:\n%s", propertiesSection)
+// commonJSAssignment returns the target ("module.exports", "module.exports.foo" or "exports.foo")
+// and value of a CommonJS export assignment statement, or "" if stmt is not one.
+func commonJSAssignment(content []byte, stmt *sitter.Node) (string, *sitter.Node) {
+	if stmt.NamedChildCount() == 0 || stmt.NamedChild(0).Type() != "assignment_expression" {
+		return "", nil
 	}
-
-	return classDocs
+	assignment := stmt.NamedChild(0)
+	left, right := assignment.ChildByFieldName("left"), assignment.ChildByFieldName("right")
+	if left == nil || right == nil {
+		return "", nil
+	}
+	target := strings.Join(strings.Fields(left.Content(content)), "")
+	if target == "module.exports" || strings.HasPrefix(target, "module.exports.") || strings.HasPrefix(target, "exports.") {
+		return target, right
+	}
+	return "", nil
 }
 
-func extractFunctionDocs(s string) string {
-	// JSDoc comments must start with a /**
-	// sequence in order to be recognized by the JSDoc parser.
-	// Comments beginning with /*, /***, or more than 3 stars are ignored by Jsdoc Parser.
-	if !strings.HasPrefix(s, "/**") && strings.HasPrefix(s, "/***") {
-		return sanitizeDocs(s)
+// commonJSExport documents the value exported by a CommonJS export assignment statement, e.g.
+// "exports.foo = function() {}" or "module.exports = class Foo {}". Values exported by name, e.g.
+// "module.exports = { foo }", are documented by their declaration instead.
+func (f *fileIndexer) commonJSExport(stmt *sitter.Node) {
+	target, value := commonJSAssignment(f.content, stmt)
+	if target == "" || value.Type() == "identifier" {
+		return
+	}
+	if target == "module.exports" {
+		if value.Type() == "object" {
+			for j := 0; j < int(value.NamedChildCount()); j++ {
+				property := value.NamedChild(j)
+				if property.Type() == "method_definition" {
+					f.value(property.ChildByFieldName("name").Content(f.content), property, property)
+				} else if v := property.ChildByFieldName("value"); property.Type() == "pair" && v != nil && v.Type() != "identifier" {
+					f.value(strings.Trim(property.ChildByFieldName("key").Content(f.content), `"'`), v, property)
+				}
+			}
+			return
+		}
+		f.value("module.exports", value, stmt)
+		return
 	}
+	f.value(target[strings.LastIndex(target, ".")+1:], value, stmt)
+}
 
-	comment := []byte(s)
-	funcDocs := ""
-	node, err := sitter.ParseCtx(context.Background(), comment, jsdoc.GetLanguage())
-	if err != nil {
-		return ""
+// value documents an exported value, e.g. of "export default" or "exports.foo = ...", by the name
+// it is exported as (unless it is a named function or class.) docNode is the statement the doc
+// comment of the value precedes.
+func (f *fileIndexer) value(name string, value, docNode *sitter.Node) {
+	switch value.Type() {
+	case "function_declaration", "class_declaration", "generator_function_declaration":
+		f.declaration(value, docNode, true) // export default function foo() {}
+		return
+	case "function", "function_expression", "generator_function", "class":
+		if valueName := value.ChildByFieldName("name"); valueName != nil {
+			name = valueName.Content(f.content)
+		}
 	}
-	query, err := sitter.NewQuery([]byte(`
-	(document
-		(description)? @func_description
-		(tag
-			(tag_name)? @tag_name
-			(type)? @identifier_type
-			(identifier)? @identifier_name
-			(description)? @identifier_description
-		)
-	)*
-	`), jsdoc.GetLanguage())
-	if err != nil {
-		return ""
+	section, ok := f.section(value, docNode, nil, name, true)
+	if !ok {
+		return
+	}
+	switch value.Type() {
+	case "method_definition":
+		section.Label = schema.Markdown(f.signature(value)) // module.exports = { foo() {} }
+		f.add("func", section)
+	case "function", "function_expression", "generator_function", "arrow_function":
+		section.Label = schema.Markdown(name + " = " + f.signature(value))
+		f.add("func", section)
+	case "class":
+		section.Label = schema.Markdown(f.signature(value))
+		section.Children = f.classMembers(value.ChildByFieldName("body"), section.SearchKey)
+		f.add("class", section)
+	default:
+		section.Label = schema.Markdown(indexer.Truncate(name + " = " + f.signature(value)))
+		f.add("var", section)
+	}
+}
+
+// declaration documents a declaration statement. docNode is the statement the doc comment of the
+// declaration precedes, e.g. an export statement containing it.
+func (f *fileIndexer) declaration(decl, docNode *sitter.Node, exported bool) {
+	switch decl.Type() {
+	case "function_declaration", "generator_function_declaration":
+		section, ok := f.section(decl, docNode, nil, decl.ChildByFieldName("name").Content(f.content), exported)
+		if ok {
+			section.Label = schema.Markdown(f.signature(decl))
+			f.add("func", section)
+		}
+	case "class_declaration":
+		section, ok := f.section(decl, docNode, nil, decl.ChildByFieldName("name").Content(f.content), exported)
+		if ok {
+			section.Label = schema.Markdown(f.signature(decl))
+			section.Children = f.classMembers(decl.ChildByFieldName("body"), section.SearchKey)
+			f.add("class", section)
+		}
+	case "lexical_declaration", "variable_declaration":
+		kind := strings.Fields(decl.Content(f.content))[0] // const, let or var
+		for i := 0; i < int(decl.NamedChildCount()); i++ {
+			declarator := decl.NamedChild(i)
+			name := declarator.ChildByFieldName("name")
+			if declarator.Type() != "variable_declarator" || name == nil || name.Type() != "identifier" {
+				continue // e.g. a destructuring pattern
+			}
+			section, ok := f.section(declarator, docNode, nil, name.Content(f.content), exported)
+			if !ok {
+				continue
+			}
+			category, label := "var", kind+" "+indexer.Truncate(f.signature(declarator))
+			if value := declarator.ChildByFieldName("value"); value != nil {
+				switch value.Type() {
+				case "arrow_function", "function", "function_expression", "generator_function":
+					category, label = "func", kind+" "+name.Content(f.content)+" = "+f.signature(value)
+				case "class":
+					category, label = "class", kind+" "+name.Content(f.content)+" = "+f.signature(value)
+					section.Children = f.classMembers(value.ChildByFieldName("body"), section.SearchKey)
+				}
+			}
+			section.Label = schema.Markdown(label)
+			f.add(category, section)
+		}
 	}
+}
 
-	defer query.Close()
+// section returns a section documenting the named declaration, with its docs but no label, or
+// false if it is private and private declarations are not included.
+func (f *fileIndexer) section(decl, docNode *sitter.Node, searchKeyPrefix []string, name string, exported bool) (schema.Section, bool) {
+	public := (exported || f.exportedNames[name]) && !isPrivate(name)
+	if !public && !f.includePrivate {
+		return schema.Section{}, false
+	}
+	docs, deprecated := f.docs(docNode)
+	key := indexer.SearchKey(searchKeyPrefix, name)
+	section := schema.Section{
+		ID:         strings.Join(key, ""),
+		ShortLabel: name,
+		Visibility: schema.VisibilityPublic,
+		Detail:     schema.Markdown(docs),
+		SearchKey:  key,
+		Location:   indexer.NodeLocation(f.path, decl),
+		Deprecated: deprecated,
+	}
+	if !public {
+		section.Visibility = schema.VisibilityPrivate
+	}
+	return section, true
+}
 
-	cursor := sitter.NewQueryCursor()
-	defer cursor.Close()
-	cursor.Exec(query, node)
+func (f *fileIndexer) add(category string, section schema.Section) {
+	if f.lists[category] == nil {
+		f.lists[category] = newSectionList()
+	}
+	f.lists[category].add(section)
+}
 
-	argsSection := ""
-	returnSection := ""
-	for {
-		match, ok := cursor.NextMatch()
-		if !ok {
-			break
+// classMembers returns the sections documenting the fields and methods of a class.
+func (f *fileIndexer) classMembers(body *sitter.Node, classKey []string) []schema.Section {
+	list := newSectionList()
+	for i := 0; body != nil && i < int(body.NamedChildCount()); i++ {
+		member := body.NamedChild(i)
+		var name *sitter.Node
+		switch member.Type() {
+		case "method_definition":
+			name = member.ChildByFieldName("name")
+		case "field_definition", "public_field_definition":
+			name = member.ChildByFieldName("property")
 		}
-		captures := getCaptures(query, match)
-		funcDescription := firstCaptureContentOr(comment, captures["func_description"], "")
-		funcDocs = fmt.Sprintf("%s\n", funcDescription)
-
-		identifierType := firstCaptureContentOr(comment, captures["identifier_type"], "")
-		if identifierType != "" {
-			identifierType = fmt.Sprintf(" (%s)", identifierType)
+		if name == nil {
+			continue // e.g. comments or static blocks
 		}
-		identifierName := firstCaptureContentOr(comment, captures["identifier_name"], "")
-		identifierDescription := firstCaptureContentOr(comment, captures["identifier_description"], "")
-		if identifierDescription != "" {
-			identifierDescription = fmt.Sprintf(": %s", identifierDescription)
+		memberName := name.Content(f.content)
+		private := strings.HasPrefix(memberName, "#") || isPrivate(memberName)
+		if private && !f.includePrivate {
+			continue
 		}
-
-		tagName := firstCaptureContentOr(comment, captures["tag_name"], "")
-		switch tagName {
-		case "@param":
-			argsSection += fmt.Sprintf("\n\t%s%s%s", identifierName, identifierType, identifierDescription)
-		case "@return":
-			returnSection += fmt.Sprintf("\n\t%s%s%s", identifierName, identifierType, identifierDescription)
+		section, _ := f.section(member, member, classKey, memberName, true)
+		if private {
+			section.Visibility = schema.VisibilityPrivate
 		}
+		section.Label = schema.Markdown(indexer.Truncate(f.signature(member)))
+		list.add(section)
 	}
+	return list.list()
+}
 
-	if len(argsSection) > 0 {
-		funcDocs += fmt.Sprintf("\n Arguments:\n%s", argsSection)
+// typedefs documents the types declared by @typedef and @callback tags in a comment, e.g.
+// "/** @typedef {Object} Point @property {number} x */"
+func (f *fileIndexer) typedefs(comment *sitter.Node) {
+	for _, typedef := range jsdocTypedefs(comment.Content(f.content)) {
+		public := !isPrivate(typedef.name)
+		if !public && !f.includePrivate {
+			continue
+		}
+		section := schema.Section{
+			ID:         typedef.name,
+			ShortLabel: typedef.name,
+			Label:      schema.Markdown(typedef.label),
+			Detail:     schema.Markdown(typedef.docs),
+			SearchKey:  []string{typedef.name},
+			Location:   indexer.NodeLocation(f.path, comment),
+			Visibility: schema.VisibilityPublic,
+			Deprecated: typedef.deprecated,
+		}
+		if !public {
+			section.Visibility = schema.VisibilityPrivate
+		}
+		f.add("type", section)
 	}
+}
 
-	if len(returnSection) > 0 {
-		funcDocs += fmt.Sprintf("\n Returns:\n%s", returnSection)
+// moduleDocs returns the docs of the module: the first doc comment of the file if it has a
+// @module, @file or @fileoverview tag, or is not attached to the declaration following it (i.e. is
+// followed by a blank line.)
+func (f *fileIndexer) moduleDocs(n *sitter.Node) string {
+	if n.NamedChildCount() == 0 {
+		return ""
+	}
+	first := n.NamedChild(0)
+	comment := first.Content(f.content)
+	if first.Type() != "comment" || !strings.HasPrefix(comment, "/**") || strings.Contains(comment, "@typedef") {
+		return ""
 	}
+	next := first.NextNamedSibling()
+	tagged := strings.Contains(comment, "@module") || strings.Contains(comment, "@file")
+	if !tagged && next != nil && next.StartPoint().Row <= first.EndPoint().Row+1 {
+		return "" // docs of the following declaration
+	}
+	docs, _ := jsdoc(comment)
+	return docs
+}
 
-	return funcDocs
+// docs returns the Markdown docs of a declaration from the JSDoc comment preceding it, and
+// whether it is marked @deprecated.
+func (f *fileIndexer) docs(node *sitter.Node) (string, bool) {
+	prev := node.PrevNamedSibling()
+	if prev == nil || prev.Type() != "comment" {
+		return "", false
+	}
+	comment := prev.Content(f.content)
+	if !strings.HasPrefix(comment, "/**") || prev.EndPoint().Row+1 < node.StartPoint().Row {
+		return "", false
+	}
+	if len(jsdocTypedefs(comment)) > 0 {
+		return "", false // documents a type, see typedefs
+	}
+	return jsdoc(comment)
 }
 
-func sanitizeDocs(s string) string {
-	if strings.HasPrefix(s, "//") {
-		s = strings.ReplaceAll(s, "\n//", "\n")
-		return strings.TrimPrefix(s, "//")
-	} else if strings.HasPrefix(s, "/*") {
-		return strings.TrimSuffix(strings.TrimPrefix(s, "/*"), "*/")
+// signature returns the source of a declaration up to its body, if any, on a single line. e.g.
+// "function foo(a, b)" or "class Foo extends Bar"
+func (f *fileIndexer) signature(decl *sitter.Node) string {
+	signature := decl.Content(f.content)
+	if body := decl.ChildByFieldName("body"); body != nil {
+		signature = string(f.content[decl.StartByte():body.StartByte()])
 	}
-	return s
+	signature = strings.Join(strings.Fields(signature), " ")
+	return strings.TrimRight(signature, ";, ")
 }
 
-func functionDefinitionQuery() string {
-	functionDefinition := `(
-		function
-			name: (identifier)? @func_name
-			parameters: (formal_parameters) @func_params
-	) `
-
-	arrowFunctionDefinition := `(
-		arrow_function
-			parameters: (formal_parameters) @func_params
-	) @arrow_function`
-
-	// function myfunc(){}
-	funcDeclaration := ` 			
-	(
-		(comment)* @func_docs
-		.
-		(
-			function_declaration
-				name: (identifier) @func_name
-				parameters: (formal_parameters) @func_params
-		)
-	)
-	`
-	// var myfunction = function(a,b){}
-	// var myfunction = (a,b) => {}
-	funcAssignmentExpression := fmt.Sprintf(`(
-		(comment)* @func_docs
-		.
-		(lexical_declaration
-			(_
-				name: (identifier) @var_identifier
-				value: [
-					%s
-					%s
-				]
-				
-			
-			)
-		)
-	)`, functionDefinition, arrowFunctionDefinition)
-
-	// export default myfunc = function(){}
-	// export default myfunc = () => {}
-	funcExportExpression := fmt.Sprintf(`(
-		(comment)* @func_docs
-		.
-		(export_statement
-			(lexical_declaration
-				(_
-					name: (identifier) @var_identifier
-					value: [
-						%s
-						%s
-					]
-								
-				)
-			)?
-			value:([
-				%s
-				%s
-			])?
-		)
-		
-	)`, functionDefinition, arrowFunctionDefinition, functionDefinition, arrowFunctionDefinition)
-
-	// module.exports = function(){}
-	funcExpressionStatementAssignment := fmt.Sprintf(`
-	(
-		(comment)* @func_docs
-		.
-		(expression_statement
-			(assignment_expression
-				left: (_) @var_identifier
-				right: 
-				[
-					%s
-					%s
-				]			
-			)
-		)
-	)`, functionDefinition, arrowFunctionDefinition)
-
-	query := fmt.Sprintf(`
-	(
-		[
-			%s
-			%s
-			%s
-			%s	
-		]
-	 )
-	`, funcDeclaration, funcAssignmentExpression, funcExportExpression, funcExpressionStatementAssignment)
-	return query
+// sectionList collects the sections documenting declarations, merging those with the same ID
+// (e.g. a function exported both by its declaration and by "module.exports = { foo }".)
+type sectionList struct {
+	sections []schema.Section
+	byID     map[string]int
 }
 
-type moduleInfo struct {
-	path string
-	docs string
+func newSectionList() *sectionList {
+	return &sectionList{byID: map[string]int{}}
 }
 
-func firstCaptureContentOr(content []byte, captures []*sitter.Node, defaultValue string) string {
-	if len(captures) > 0 {
-		return captures[0].Content(content)
+func (l *sectionList) add(section schema.Section) {
+	i, ok := l.byID[section.ID]
+	if !ok {
+		l.byID[section.ID] = len(l.sections)
+		l.sections = append(l.sections, section)
+		return
+	}
+	if l.sections[i].Detail == "" {
+		l.sections[i].Detail = section.Detail
 	}
-	return defaultValue
 }
 
-func joinCaptures(content []byte, captures []*sitter.Node, sep string) string {
-	var v []string
-	for _, capture := range captures {
-		v = append(v, capture.Content(content))
-	}
-	return strings.Join(v, sep)
+func (l *sectionList) list() []schema.Section {
+	return l.sections
 }
 
-func getCaptures(q *sitter.Query, m *sitter.QueryMatch) map[string][]*sitter.Node {
-	captures := map[string][]*sitter.Node{}
-	for _, c := range m.Captures {
-		cname := q.CaptureNameForId(c.Index)
-		captures[cname] = append(captures[cname], c.Node)
-	}
-	return captures
+// isPrivate reports whether a name is private by convention, i.e. starts with an underscore.
+func isPrivate(name string) bool {
+	return strings.HasPrefix(name, "_")
 }
+
+// javascriptFile is the result of indexing a single JavaScript source file, as stored in the
+// indexer.FileCache. Search keys are relative to the module.
+type javascriptFile struct {
+	ModDocs string `json:"modDocs"`
+
+	// Sections documenting declarations, by category ID, e.g. "func".
+	Sections map[string][]schema.Section `json:"sections"`
+}
+
+// testDirs and testFiles describe files likely to contain tests rather than library code, e.g.
+// foo.test.js, foo.spec.js or __tests__/foo.js, which are not indexed.
+var (
+	testDirs  = []string{"test", "tests", "__tests__"}
+	testFiles = []string{"*.test", "*.spec", "test_*", "*_test"}
+)
@@ -0,0 +1,76 @@
+package javascript
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+)
+
+func Test_findLibraries(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"package.json":                  `{"private": true, "workspaces": ["packages/*"]}`,
+		"packages/a/package.json":       `{"name": "@scope/a", "version": "1.2.3"}`,
+		"packages/b/package.json":       `{"name": "b"}`,
+		"node_modules/dep/package.json": `{"name": "dep", "version": "0.0.1"}`,
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	libraries, err := findLibraries(dir, indexer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, library := range libraries {
+		got = append(got, library.Dir+": "+library.Library.Name+" "+library.Library.Version)
+	}
+	autogold.Want("libraries", []string{"packages/a: @scope/a 1.2.3", "packages/b: b "}).Equal(t, got)
+}
+
+func TestIndexDir_moduleNames(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"foo.js":       "export function foo() {}\n",
+		"foo/index.js": "export function fooIndex() {}\n",
+		"bar.js":       "export function bar() {}\n",
+		"bar.mjs":      "export function barModule() {}\n",
+		"baz/index.js": "export function baz() {}\n",
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index, err := (&javascriptIndexer{}).IndexDir(context.Background(), dir, indexer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, library := range index.Libraries {
+		for _, page := range library.Pages {
+			got = append(got, page.Path+": "+page.Location.Path)
+		}
+	}
+	sort.Strings(got)
+	autogold.Want("pages", []string{
+		"bar.mjs: bar.mjs", "bar: bar.js", "baz: baz/index.js",
+		"foo.js: foo.js",
+		"foo: foo/index.js",
+	}).Equal(t, got)
+}
@@ -0,0 +1,211 @@
+package javascript
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/doctree/doctree/indexer"
+)
+
+// jsdocBlockTags are the block tags which are documented, and so may follow other text on a line.
+var jsdocBlockTags = []string{
+	"@param", "@arg", "@argument", "@property", "@prop", "@returns", "@return", "@throws",
+	"@exception", "@deprecated", "@description", "@desc", "@summary", "@example", "@see", "@since",
+	"@typedef", "@callback",
+}
+
+// jsdocField is the argument of a tag such as @param, e.g. "{number} [x=1] - The value."
+type jsdocField struct {
+	typ, name, defaultValue, desc string
+	optional                      bool
+}
+
+// parseField parses the argument of a tag. If named is false, the tag has no name, e.g.
+// "@returns {number} The value."
+func parseField(text string, named bool) jsdocField {
+	var field jsdocField
+	text = strings.TrimSpace(text)
+
+	// The type may contain braces itself, e.g. "{{x: number}}"
+	if strings.HasPrefix(text, "{") {
+		depth, end := 0, -1
+		for i, r := range text {
+			if r == '{' {
+				depth++
+			} else if r == '}' {
+				depth--
+			}
+			if depth == 0 {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			// Unterminated, e.g. "{number x", the rest is the type.
+			field.typ = strings.TrimSpace(text[1:])
+			return field
+		}
+		field.typ = strings.TrimSpace(text[1:end])
+		text = strings.TrimSpace(text[end+1:])
+	}
+	if named && text != "" {
+		if strings.HasPrefix(text, "[") {
+			// Optional, e.g. "[x]" or "[x=1]"
+			name, rest, ok := strings.Cut(text[1:], "]")
+			if !ok {
+				// Unterminated, e.g. "[x The value.", the name is the first word.
+				name, rest, _ = strings.Cut(text[1:], " ")
+			}
+			field.name, field.defaultValue, _ = strings.Cut(name, "=")
+			field.name = strings.TrimSpace(field.name)
+			field.optional = true
+			text = rest
+		} else {
+			end := strings.IndexAny(text, " \t\n")
+			if end < 0 {
+				end = len(text)
+			}
+			field.name, text = text[:end], text[end:]
+		}
+		if strings.HasSuffix(field.typ, "=") {
+			field.typ, field.optional = strings.TrimSuffix(field.typ, "="), true // e.g. "{number=}"
+		}
+	}
+	text = strings.TrimSpace(text)
+	text = strings.TrimSpace(strings.TrimPrefix(text, "-"))
+	field.desc = text
+	return field
+}
+
+// jsdoc converts a JSDoc comment to Markdown: its description followed by tables of the
+// parameters, return value and exceptions documented by block tags such as @param. Reports
+// whether the comment has a @deprecated tag.
+func jsdoc(comment string) (docs string, deprecated bool) {
+	description, tags := indexer.BlockTags(comment, jsdocBlockTags...)
+	return formatJSDoc(description, tags)
+}
+
+func formatJSDoc(description []string, tags []indexer.BlockTag) (docs string, deprecated bool) {
+	out := []string{strings.TrimSpace(strings.Join(description, "\n"))}
+	var params, properties, returns, throws [][]string
+	for _, t := range tags {
+		text := strings.TrimSpace(t.Text)
+		switch t.Name {
+		case "@param", "@arg", "@argument":
+			params = append(params, fieldRow(parseField(text, true)))
+		case "@property", "@prop":
+			properties = append(properties, fieldRow(parseField(text, true)))
+		case "@returns", "@return":
+			field := parseField(text, false)
+			returns = append(returns, []string{indexer.Code(field.typ), field.desc})
+		case "@throws", "@exception":
+			field := parseField(text, false)
+			throws = append(throws, []string{indexer.Code(field.typ), field.desc})
+		case "@deprecated":
+			deprecated = true
+			out = append(out, indexer.Deprecated(text))
+		case "@description", "@desc", "@summary":
+			out = append(out, text)
+		case "@example":
+			out = append(out, indexer.Example(text, "js"))
+		case "@see":
+			out = append(out, "See "+text)
+		case "@since":
+			out = append(out, "Since "+text)
+		}
+	}
+	out = append(out,
+		indexer.Table("Parameters", []string{"Name", "Type", "Description"}, params),
+		indexer.Table("Properties", []string{"Name", "Type", "Description"}, properties),
+		indexer.Table("Returns", []string{"Type", "Description"}, returns),
+		indexer.Table("Throws", []string{"Type", "Description"}, throws),
+	)
+
+	var nonEmpty []string
+	for _, s := range out {
+		if s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n"), deprecated
+}
+
+// fieldRow returns the table row documenting a parameter or property.
+func fieldRow(field jsdocField) []string {
+	name := indexer.Code(field.name)
+	if field.optional {
+		name += " (optional)"
+	}
+	desc := field.desc
+	if field.defaultValue != "" {
+		desc = strings.TrimSpace(desc + " Default: " + indexer.Code(field.defaultValue))
+	}
+	return []string{name, indexer.Code(field.typ), desc}
+}
+
+// jsdocTypedef is a type declared by a @typedef or @callback tag.
+type jsdocTypedef struct {
+	name, label, docs string
+	deprecated        bool
+}
+
+// jsdocTypedefs returns the types declared by @typedef and @callback tags in a JSDoc comment, e.g.
+// "@typedef {Object} Point" followed by "@property {number} x" tags. The tags following each
+// declaration, up to the next one, document it.
+func jsdocTypedefs(comment string) []jsdocTypedef {
+	if !strings.HasPrefix(comment, "/**") {
+		return nil
+	}
+	description, tags := indexer.BlockTags(comment, jsdocBlockTags...)
+
+	var typedefs []jsdocTypedef
+	for i := 0; i < len(tags); i++ {
+		var field jsdocField
+		switch tags[i].Name {
+		case "@typedef":
+			field = parseField(tags[i].Text, true)
+		case "@callback":
+			field = parseField("{} "+tags[i].Text, true)
+		default:
+			continue
+		}
+		if field.name == "" {
+			continue
+		}
+		var defTags []indexer.BlockTag
+		for i+1 < len(tags) && tags[i+1].Name != "@typedef" && tags[i+1].Name != "@callback" {
+			i++
+			defTags = append(defTags, tags[i])
+		}
+
+		// The description of the declaration is the text following its name or, for the first one,
+		// that of the comment.
+		var defDescription []string
+		if field.desc != "" {
+			defDescription = []string{field.desc}
+		} else if len(typedefs) == 0 {
+			defDescription = description
+		}
+		docs, deprecated := formatJSDoc(defDescription, defTags)
+
+		label := "typedef " + field.name
+		if field.typ != "" {
+			label += ": " + field.typ
+		}
+		if tags[i-len(defTags)].Name == "@callback" {
+			var params []string
+			for _, tag := range defTags {
+				if tag.Name == "@param" || tag.Name == "@arg" || tag.Name == "@argument" {
+					params = append(params, parseField(tag.Text, true).name)
+				}
+			}
+			label = "callback " + field.name + "(" + strings.Join(params, ", ") + ")"
+		}
+		typedefs = append(typedefs, jsdocTypedef{
+			name:       field.name,
+			label:      label,
+			docs:       docs,
+			deprecated: deprecated,
+		})
+	}
+	return typedefs
+}
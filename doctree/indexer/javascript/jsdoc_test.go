@@ -0,0 +1,127 @@
+package javascript
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func Test_jsdoc(t *testing.T) {
+	tests := []struct {
+		name           string
+		comment        string
+		want           string
+		wantDeprecated bool
+	}{
+		{
+			name:    "params",
+			comment: "/**\n * Adds.\n * @param {number} [x=1] - The value.\n * @param {string=} y Other.\n * @returns {boolean} Whether.\n * @throws {Error} When.\n */",
+			want:    "Adds.\n\n**Parameters**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `x` (optional) | `number` | The value. Default: `1` |\n| `y` (optional) | `string` | Other. |\n\n**Returns**\n\n| Type | Description |\n| --- | --- |\n| `boolean` | Whether. |\n\n**Throws**\n\n| Type | Description |\n| --- | --- |\n| `Error` | When. |",
+		},
+		{
+			name:           "deprecated",
+			comment:        "/** @deprecated */",
+			want:           "Deprecated.",
+			wantDeprecated: true,
+		},
+		{
+			name:           "deprecated-on-description-line",
+			comment:        "/** Adds. @deprecated use plus */",
+			want:           "Adds.\n\nDeprecated: use plus",
+			wantDeprecated: true,
+		},
+		{
+			name:    "tags-on-description-line",
+			comment: "/** Adds. @param {number} a The first. @returns {number} The sum. */",
+			want:    "Adds.\n\n**Parameters**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `a` | `number` | The first. |\n\n**Returns**\n\n| Type | Description |\n| --- | --- |\n| `number` | The sum. |",
+		},
+		{
+			name:    "example",
+			comment: "/**\n * @example\n * // @deprecated is not a tag here\n * add(1, 2)\n * @since 1.2\n */",
+			want:    "**Example**\n\n```js\n// @deprecated is not a tag here\nadd(1, 2)\n```\n\nSince 1.2",
+		},
+		{
+			name:    "unterminated-optional",
+			comment: "/** @param [ */",
+			want:    "**Parameters**\n\n| Name |\n| --- |\n| (optional) |",
+		},
+		{
+			name:    "unterminated-optional-with-type",
+			comment: "/** @param {number} [ */",
+			want:    "**Parameters**\n\n| Name | Type |\n| --- | --- |\n| (optional) | `number` |",
+		},
+		{
+			name:    "unterminated-optional-name",
+			comment: "/** @param {number} [x=1 The value. */",
+			want:    "**Parameters**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `x` (optional) | `number` | The value. Default: `1` |",
+		},
+		{
+			name:    "unterminated-type",
+			comment: "/** @param {number x The value. */",
+			want:    "**Parameters**\n\n| Type |\n| --- |\n| `number x The value.` |",
+		},
+		{
+			name:    "type-only",
+			comment: "/** @param {number} */",
+			want:    "**Parameters**\n\n| Type |\n| --- |\n| `number` |",
+		},
+		{
+			name:    "empty",
+			comment: "/** @param */",
+			want:    "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, deprecated := jsdoc(tc.comment)
+			autogold.Want(tc.name, tc.want).Equal(t, got)
+			if deprecated != tc.wantDeprecated {
+				t.Errorf("deprecated = %v, want %v", deprecated, tc.wantDeprecated)
+			}
+		})
+	}
+}
+
+func Test_jsdocTypedefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment string
+		want    []string
+	}{
+		{
+			name:    "typedef",
+			comment: "/**\n * A point.\n * @typedef {Object} Point\n * @property {number} x The x.\n * @property {number} [y=0] The y.\n * @deprecated Use Vector.\n */",
+			want: []string{
+				"typedef Point: Object",
+				"A point.\n\nDeprecated: Use Vector.\n\n**Properties**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `x` | `number` | The x. |\n| `y` (optional) | `number` | The y. Default: `0` |",
+			},
+		},
+		{
+			name:    "callback",
+			comment: "/**\n * @callback Handler Handles.\n * @param {Event} event The event.\n * @returns {boolean}\n * @typedef {string} ID\n */",
+			want: []string{
+				"callback Handler(event)",
+				"Handles.\n\n**Parameters**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `event` | `Event` | The event. |\n\n**Returns**\n\n| Type |\n| --- |\n| `boolean` |",
+				"typedef ID: string",
+				"",
+			},
+		},
+		{
+			name:    "single-line",
+			comment: "/** @typedef {Object} Point @property {number} x The x. */",
+			want: []string{
+				"typedef Point: Object",
+				"**Properties**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `x` | `number` | The x. |",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, typedef := range jsdocTypedefs(tc.comment) {
+				got = append(got, typedef.label, typedef.docs)
+			}
+			autogold.Want(tc.name, tc.want).Equal(t, got)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package indexer
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+// LibraryRoot is a directory containing a library, as described by a manifest file such as go.mod
+// or package.json.
+type LibraryRoot struct {
+	// Dir is the slash-separated path of the directory containing the library, relative to the
+	// indexed directory. "." for the indexed directory itself.
+	Dir string
+
+	// Library metadata, e.g. name and version. Pages are ignored.
+	Library schema.Library
+}
+
+// FindFiles returns the paths of all files in dir that should be indexed (see Walk) which have one
+// of the given names, e.g. "go.mod".
+func FindFiles(dir string, opts Options, names ...string) ([]string, error) {
+	var found []string
+	err := Walk(dir, opts, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err // error walking dir
+		}
+		if !d.IsDir() {
+			for _, name := range names {
+				if d.Name() == name {
+					found = append(found, p)
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Walk")
+	}
+	return found, nil
+}
+
+// GroupLibraries groups pages into libraries. Each page belongs to the library whose directory most
+// closely contains the file the page was produced from (see Page.Location), or its path otherwise.
+//
+// Pages which are not within any library root are placed into the fallback library. Libraries are
+// returned in order of their directory, and those without any pages are omitted.
+func GroupLibraries(roots []LibraryRoot, pages []schema.Page, fallback schema.Library) []schema.Library {
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Dir < roots[j].Dir })
+
+	libraries := make([]schema.Library, len(roots)+1)
+	libraries[0] = fallback
+	libraries[0].Pages = nil
+	for i, root := range roots {
+		libraries[i+1] = root.Library
+		libraries[i+1].Pages = nil
+	}
+	for _, page := range pages {
+		pagePath := strings.TrimPrefix(page.Path, "/")
+		if page.Location != nil && page.Location.Path != "" {
+			pagePath = page.Location.Path
+		}
+
+		// Find the most specific (longest) library directory containing the page.
+		best, bestLen := 0, -1
+		for i, root := range roots {
+			if !containsPath(root.Dir, pagePath) {
+				continue
+			}
+			if len(root.Dir) > bestLen {
+				best, bestLen = i+1, len(root.Dir)
+			}
+		}
+		libraries[best].Pages = append(libraries[best].Pages, page)
+	}
+
+	var result []schema.Library
+	for _, library := range libraries {
+		if len(library.Pages) > 0 {
+			result = append(result, library)
+		}
+	}
+	return result
+}
+
+// containsPath reports whether the slash-separated path p is within dir.
+func containsPath(dir, p string) bool {
+	dir, p = path.Clean(dir), path.Clean(p)
+	if dir == "." {
+		return true
+	}
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// DefaultLibrary returns the library pages belong to when they are not within any library root:
+// one named after the indexed directory. Its version is left empty, so that the commit being
+// indexed is used.
+func DefaultLibrary(dir string) schema.Library {
+	name := filepath.Base(dir)
+	if absDir, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(absDir)
+	}
+	return schema.Library{Name: name, ID: name}
+}
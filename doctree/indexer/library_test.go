@@ -0,0 +1,86 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func TestFindFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{
+		"go.mod",
+		"a/go.mod",
+		"a/b/go.mod",
+		"a/b/go.mod.bak",
+		"node_modules/dep/go.mod",
+		"testdata/go.mod",
+		"ignored/go.mod",
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("ignored/\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindFiles(dir, Options{}, "go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	autogold.Want("go.mod", []string{"a/b/go.mod", "a/go.mod", "go.mod"}).Equal(t, got)
+}
+
+func TestGroupLibraries(t *testing.T) {
+	page := func(pagePath, locationPath string) schema.Page {
+		page := schema.Page{Path: pagePath}
+		if locationPath != "" {
+			page.Location = &schema.Location{Path: locationPath}
+		}
+		return page
+	}
+	roots := []LibraryRoot{
+		{Dir: "libs/b", Library: schema.Library{Name: "b"}},
+		{Dir: ".", Library: schema.Library{Name: "root"}},
+		{Dir: "libs/a", Library: schema.Library{Name: "a"}},
+		{Dir: "libs/a/nested", Library: schema.Library{Name: "nested"}},
+		{Dir: "libs/empty", Library: schema.Library{Name: "empty"}},
+	}
+	pages := []schema.Page{
+		page("main", "main.go"),
+		page("libs/a", "libs/a/a.go"),
+		page("libs/a/nested/x", "libs/a/nested/x/x.go"),
+		page("libs/a/nestedfoo", "libs/a/nestedfoo/foo.go"),
+		page("/libs/b/sub", ""),
+		page("example.com/b", "libs/b/b.go"),
+	}
+
+	summarize := func(libraries []schema.Library) []string {
+		var got []string
+		for _, library := range libraries {
+			for _, page := range library.Pages {
+				got = append(got, library.Name+": "+page.Path)
+			}
+		}
+		return got
+	}
+	autogold.Want("with root", []string{
+		"root: main", "a: libs/a", "a: libs/a/nestedfoo", "nested: libs/a/nested/x",
+		"b: /libs/b/sub",
+		"b: example.com/b",
+	}).Equal(t, summarize(GroupLibraries(roots, pages, schema.Library{Name: "fallback"})))
+
+	nested := []LibraryRoot{{Dir: "libs/a/nested", Library: schema.Library{Name: "nested"}}}
+	autogold.Want("fallback", []string{
+		"fallback: main", "fallback: libs/a", "fallback: libs/a/nestedfoo",
+		"nested: libs/a/nested/x",
+	}).Equal(t, summarize(GroupLibraries(nested, pages[:4], schema.Library{Name: "fallback"})))
+}
@@ -0,0 +1,274 @@
+package indexer
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+// ResolveLinks rewrites references to symbols in the details of every page and section of the
+// project's indexes into links to the page/section documenting that symbol, and records links for
+// identifiers that appear in section labels (see schema.Section.LabelLinks.)
+//
+// Symbols are matched against the SearchKey of sections in any of the indexes, so a Markdown
+// document may link to Go functions in the same project, for example. The following forms are
+// recognized in details:
+//
+//	[Client.Do]       Go doc links
+//	`Client.Do`       code spans
+//	Client.Do         qualified identifiers in prose
+//	see Do            unqualified identifiers following "see"
+//
+// References are only rewritten if they resolve to exactly one symbol, preferring symbols on the
+// same page and then in the same language. Fenced code blocks and existing links are left intact.
+func ResolveLinks(projectName string, indexes map[string]*schema.Index) {
+	r := &linkResolver{projectName: projectName, symbols: map[string][]linkTarget{}}
+	for language, index := range indexes {
+		if index == nil || language == schema.LanguageMarkdown.ID {
+			// Markdown headings are not symbols.
+			continue
+		}
+		for _, lib := range index.Libraries {
+			for _, page := range lib.Pages {
+				r.collectPage(language, page)
+			}
+		}
+	}
+
+	for language, index := range indexes {
+		if index == nil {
+			continue
+		}
+		for l := range index.Libraries {
+			pages := index.Libraries[l].Pages
+			for p := range pages {
+				r.rewritePage(language, &pages[p])
+			}
+		}
+	}
+}
+
+// linkTarget is a page, or section of a page, which may be linked to.
+type linkTarget struct {
+	language, pagePath, sectionID string
+}
+
+type linkResolver struct {
+	projectName string
+
+	// symbols maps every name a symbol may be referred to by (e.g. "http.Client.Do", "Client.Do",
+	// "Do") to the sections documenting a symbol by that name.
+	symbols map[string][]linkTarget
+}
+
+func (r *linkResolver) collectPage(language string, page schema.Page) {
+	var collectSection func(s schema.Section)
+	collectSection = func(s schema.Section) {
+		if !s.Category && s.ID != "" && len(s.SearchKey) > 0 {
+			target := linkTarget{language: language, pagePath: page.Path, sectionID: s.ID}
+			for _, name := range searchKeyNames(s.SearchKey) {
+				r.symbols[name] = append(r.symbols[name], target)
+			}
+		}
+		for _, child := range s.Children {
+			collectSection(child)
+		}
+	}
+	for _, section := range page.Sections {
+		collectSection(section)
+	}
+	for _, subpage := range page.Subpages {
+		r.collectPage(language, subpage)
+	}
+}
+
+// searchKeyNames returns the names a symbol with the given search key may be referred to by: the
+// full key and every suffix of it following a separator, e.g. ["net", "/", "http", ".", "Get"]
+// yields "net/http.Get", "http.Get" and "Get".
+func searchKeyNames(searchKey []string) []string {
+	names := []string{strings.Join(searchKey, "")}
+	for i, part := range searchKey {
+		if i+1 < len(searchKey) && isSeparator(part) {
+			names = append(names, strings.Join(searchKey[i+1:], ""))
+		}
+	}
+	return names
+}
+
+func isSeparator(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *linkResolver) rewritePage(language string, page *schema.Page) {
+	self := linkTarget{language: language, pagePath: page.Path}
+	page.Detail = r.rewriteMarkdown(page.Detail, self)
+
+	var rewriteSection func(s *schema.Section)
+	rewriteSection = func(s *schema.Section) {
+		self := linkTarget{language: language, pagePath: page.Path, sectionID: s.ID}
+		s.Detail = r.rewriteMarkdown(s.Detail, self)
+		if !s.Category {
+			s.LabelLinks = r.labelLinks(s.Label, self)
+		}
+		for c := range s.Children {
+			rewriteSection(&s.Children[c])
+		}
+	}
+	for s := range page.Sections {
+		rewriteSection(&page.Sections[s])
+	}
+	for p := range page.Subpages {
+		r.rewritePage(language, &page.Subpages[p])
+	}
+}
+
+var (
+	// Matches (in order of precedence) the spans of Markdown text that we consider for linking.
+	linkablePattern = regexp.MustCompile(strings.Join([]string{
+		"`[^`]+`",                       // code span
+		`!?\[[^\]]*\]\([^)]*\)`,         // existing link or image
+		`<[^>\s]+>`,                     // autolink or HTML tag
+		`https?://\S+`,                  // bare URL
+		`\[[A-Za-z_][\w./]*\]`,          // Go doc link
+		`(?i:see )?` + qualifiedPattern, // qualified identifier
+		`(?i:see )` + identPattern,      // "see Foo"
+	}, "|"))
+
+	qualifiedPattern = identPattern + `(?:\.` + identPattern + `)+`
+	identPattern     = `[A-Za-z_][A-Za-z0-9_]*`
+
+	labelIdentPattern = regexp.MustCompile(identPattern + `(?:\.` + identPattern + `)*`)
+	symbolPattern     = regexp.MustCompile(`^[A-Za-z_][\w./]*$`)
+)
+
+// rewriteMarkdown rewrites references to symbols in Markdown text into links.
+func (r *linkResolver) rewriteMarkdown(markdown schema.Markdown, self linkTarget) schema.Markdown {
+	if markdown == "" {
+		return markdown
+	}
+	lines := strings.Split(string(markdown), "\n")
+	var fence string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+			continue // indented code block
+		}
+		lines[i] = linkablePattern.ReplaceAllStringFunc(line, func(match string) string {
+			return r.rewriteSpan(match, self)
+		})
+	}
+	return schema.Markdown(strings.Join(lines, "\n"))
+}
+
+func (r *linkResolver) rewriteSpan(span string, self linkTarget) string {
+	switch {
+	case strings.HasPrefix(span, "`"):
+		name := strings.Trim(span, "`")
+		if !symbolPattern.MatchString(name) {
+			return span
+		}
+		if target, ok := r.resolve(name, self); ok {
+			return "[" + span + "](" + r.url(target) + ")"
+		}
+	case strings.HasPrefix(span, "[") && strings.HasSuffix(span, "]"):
+		name := strings.TrimSuffix(strings.TrimPrefix(span, "["), "]")
+		if target, ok := r.resolve(name, self); ok {
+			return span + "(" + r.url(target) + ")"
+		}
+	case strings.HasPrefix(span, "!"), strings.HasPrefix(span, "["), strings.HasPrefix(span, "<"):
+		return span
+	case strings.HasPrefix(span, "http://"), strings.HasPrefix(span, "https://"):
+		return span
+	default:
+		prefix, name := "", span
+		if len(span) > 4 && strings.EqualFold(span[:4], "see ") {
+			prefix, name = span[:4], span[4:]
+		}
+		if target, ok := r.resolve(name, self); ok {
+			return prefix + "[" + name + "](" + r.url(target) + ")"
+		}
+	}
+	return span
+}
+
+// labelLinks returns links for the identifiers in a section label which refer to other symbols.
+func (r *linkResolver) labelLinks(label schema.Markdown, self linkTarget) []schema.Link {
+	var links []schema.Link
+	for _, name := range labelIdentPattern.FindAllString(string(label), -1) {
+		if target, ok := r.resolve(name, self); ok {
+			links = append(links, schema.Link{Text: name, URL: r.url(target)})
+		}
+	}
+	return links
+}
+
+// resolve finds the single symbol with the given name, preferring symbols on the same page and
+// then in the same language as self. References to self are not resolved.
+func (r *linkResolver) resolve(name string, self linkTarget) (linkTarget, bool) {
+	var candidates []linkTarget
+	seen := map[linkTarget]struct{}{}
+	for _, target := range r.symbols[name] {
+		if _, ok := seen[target]; ok {
+			continue
+		}
+		seen[target] = struct{}{}
+		candidates = append(candidates, target)
+	}
+	for _, target := range candidates {
+		if target == self {
+			return linkTarget{}, false
+		}
+	}
+	for _, prefer := range []func(t linkTarget) bool{
+		func(t linkTarget) bool { return t.language == self.language && t.pagePath == self.pagePath },
+		func(t linkTarget) bool { return t.language == self.language },
+		func(t linkTarget) bool { return true },
+	} {
+		var matches []linkTarget
+		for _, target := range candidates {
+			if prefer(target) {
+				matches = append(matches, target)
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0], true
+		}
+		if len(matches) > 1 {
+			return linkTarget{}, false // ambiguous
+		}
+	}
+	return linkTarget{}, false
+}
+
+// url returns the frontend URL of the target, e.g. "/github.com/foo/bar/-/go/-/baz?id=Client"
+func (r *linkResolver) url(target linkTarget) string {
+	return PageURL(r.projectName, target.language, target.pagePath, target.sectionID)
+}
+
+// PageURL returns the frontend URL of a page of a project, or of a section of the page if sectionID
+// is not empty, e.g. "/github.com/foo/bar/-/go/-/baz?id=Client"
+func PageURL(projectName, language, pagePath, sectionID string) string {
+	u := "/" + projectName + "/-/" + language + "/-/" + strings.TrimPrefix(pagePath, "/")
+	if sectionID != "" {
+		u += "?id=" + strings.ReplaceAll(url.QueryEscape(sectionID), "+", "%20")
+	}
+	return u
+}
@@ -0,0 +1,147 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func TestResolveLinks(t *testing.T) {
+	section := func(id string, searchKey []string, children ...schema.Section) schema.Section {
+		return schema.Section{ID: id, ShortLabel: id, Label: schema.Markdown(id), SearchKey: searchKey, Children: children}
+	}
+	// Builds the indexes of a project with Go, Python and Markdown, with the given detail on the
+	// page of the given language at the given path.
+	indexes := func(language, pagePath, detail string) map[string]*schema.Index {
+		pages := map[string][]schema.Page{
+			schema.LanguageGo.ID: {
+				{Path: "net/http", Sections: []schema.Section{
+					section("Client", []string{"http", ".", "Client"},
+						section("Client.Do", []string{"http", ".", "Client", ".", "Do"})),
+					section("Get", []string{"http", ".", "Get"}),
+					section("Head", []string{"http", ".", "Head"}),
+				}},
+				{Path: "net/url", Sections: []schema.Section{
+					section("Get", []string{"url", ".", "Values", ".", "Get"}),
+				}},
+			},
+			schema.LanguagePython.ID: {
+				{Path: "requests", Sections: []schema.Section{
+					section("Client", []string{"requests", ".", "Client"}),
+					section("Session", []string{"requests", ".", "Session"}),
+				}},
+			},
+			schema.LanguageMarkdown.ID: {
+				{Path: "README.md", Sections: []schema.Section{
+					section("Session", []string{"README", "#", "Session"}),
+				}},
+			},
+		}
+		result := map[string]*schema.Index{}
+		for lang, langPages := range pages {
+			for i := range langPages {
+				if lang == language && langPages[i].Path == pagePath {
+					langPages[i].Detail = schema.Markdown(detail)
+				}
+			}
+			result[lang] = &schema.Index{Libraries: []schema.Library{{Pages: langPages}}}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name, language, pagePath, detail string
+		want                             autogold.Value
+	}{
+		{
+			name: "code span", language: "go", pagePath: "net/http",
+			detail: "Use `Client.Do` to send requests.",
+			want:   autogold.Want("code span", "Use [`Client.Do`](/example.com/proj/-/go/-/net/http?id=Client.Do) to send requests."),
+		},
+		{
+			name: "doc link", language: "go", pagePath: "net/http",
+			detail: "Like [Client.Do], but simpler.",
+			want:   autogold.Want("doc link", "Like [Client.Do](/example.com/proj/-/go/-/net/http?id=Client.Do), but simpler."),
+		},
+		{
+			name: "see", language: "go", pagePath: "net/http",
+			detail: "To check a URL exists, see Head.",
+			want:   autogold.Want("see", "To check a URL exists, see [Head](/example.com/proj/-/go/-/net/http?id=Head)."),
+		},
+		{
+			name: "qualified identifier", language: "go", pagePath: "net/url",
+			detail: "Fetch it with http.Head first.",
+			want:   autogold.Want("qualified identifier", "Fetch it with [http.Head](/example.com/proj/-/go/-/net/http?id=Head) first."),
+		},
+		{
+			name: "not symbols", language: "go", pagePath: "net/http",
+			detail: "Options, e.g. timeouts, i.e. v1.2 or main.go; see the docs.",
+			want:   autogold.Want("not symbols", "Options, e.g. timeouts, i.e. v1.2 or main.go; see the docs."),
+		},
+		{
+			name: "ambiguous", language: "markdown", pagePath: "README.md",
+			detail: "Call `Get` or `Client`.",
+			want:   autogold.Want("ambiguous", "Call `Get` or `Client`."),
+		},
+		{
+			name: "same page", language: "go", pagePath: "net/http",
+			detail: "See `Get`.",
+			want:   autogold.Want("same page", "See [`Get`](/example.com/proj/-/go/-/net/http?id=Get)."),
+		},
+		{
+			name: "same language", language: "go", pagePath: "net/url",
+			detail: "Use a `Client`.",
+			want:   autogold.Want("same language", "Use a [`Client`](/example.com/proj/-/go/-/net/http?id=Client)."),
+		},
+		{
+			name: "cross language", language: "markdown", pagePath: "README.md",
+			detail: "Start with `Session`, see requests.Client.",
+			want:   autogold.Want("cross language", "Start with [`Session`](/example.com/proj/-/python/-/requests?id=Session), see [requests.Client](/example.com/proj/-/python/-/requests?id=Client)."),
+		},
+		{
+			name: "fenced code", language: "go", pagePath: "net/http",
+			detail: "Example:\n\n```go\nresp, err := http.Head(url) // see Head\n```\n\n    c.Do(`Client.Do`)",
+			want:   autogold.Want("fenced code", "Example:\n\n```go\nresp, err := http.Head(url) // see Head\n```\n\n    c.Do(`Client.Do`)"),
+		},
+		{
+			name: "existing links", language: "go", pagePath: "net/http",
+			detail: "[Client.Do](https://example.com/Client.Do), <http.Head> and https://example.com/http.Head",
+			want:   autogold.Want("existing links", "[Client.Do](https://example.com/Client.Do), <http.Head> and https://example.com/http.Head"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			indexes := indexes(tc.language, tc.pagePath, tc.detail)
+			ResolveLinks("example.com/proj", indexes)
+			var got schema.Markdown
+			for _, page := range indexes[tc.language].Libraries[0].Pages {
+				if page.Path == tc.pagePath {
+					got = page.Detail
+				}
+			}
+			tc.want.Equal(t, string(got))
+		})
+	}
+}
+
+func TestResolveLinks_labels(t *testing.T) {
+	indexes := map[string]*schema.Index{
+		schema.LanguageGo.ID: {Libraries: []schema.Library{{Pages: []schema.Page{{
+			Path: "net/http",
+			Sections: []schema.Section{
+				{ID: "Client", Label: "type Client struct", SearchKey: []string{"http", ".", "Client"}},
+				{ID: "NewClient", Label: "func NewClient() *Client", SearchKey: []string{"http", ".", "NewClient"}},
+			},
+		}}}}},
+	}
+	ResolveLinks("example.com/proj", indexes)
+	var got [][]schema.Link
+	for _, section := range indexes["go"].Libraries[0].Pages[0].Sections {
+		got = append(got, section.LabelLinks)
+	}
+	autogold.Want("label links", [][]schema.Link{
+		nil,
+		{{Text: "Client", URL: "/example.com/proj/-/go/-/net/http?id=Client"}},
+	}).Equal(t, got)
+}
@@ -0,0 +1,141 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Manifest records, for each language, every file that was indexed in a project along with a hash
+// of its content and the result of indexing it. It is stored alongside the project's indexes in
+// index/<project_name>/manifest, and allows re-indexing a project to only parse the files which
+// have changed since it was last indexed.
+type Manifest struct {
+	// Version of the project directory the manifest was written by. Results of indexing files are
+	// only reused if this matches projectDirVersion, as indexers may produce different output.
+	Version string `json:"version"`
+
+	// Files indexed by each language ID, keyed by file path relative to the indexed directory.
+	Files map[string]map[string]ManifestFile `json:"files"`
+
+	// Options describes, for each language ID, the options files were indexed with. Results are
+	// only reused if the options have not changed.
+	Options map[string]string `json:"options"`
+}
+
+// ManifestFile describes the result of indexing a single file.
+type ManifestFile struct {
+	// Hash of the file content.
+	Hash string `json:"hash"`
+
+	// Result of indexing the file, in a format specific to the language indexer. For example, the
+	// sections the file contributes to its page.
+	Result json.RawMessage `json:"result"`
+}
+
+// ReadManifest reads the manifest at the given path. If it does not exist, or was written by a
+// different version of doctree, an empty manifest is returned.
+func ReadManifest(path string) (*Manifest, error) {
+	manifest := &Manifest{Version: projectDirVersion, Files: map[string]map[string]ManifestFile{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "ReadFile")
+	}
+	var existing Manifest
+	if err := json.Unmarshal(data, &existing); err != nil {
+		return nil, errors.Wrap(err, "Unmarshal")
+	}
+	if existing.Version != projectDirVersion || existing.Files == nil {
+		return manifest, nil
+	}
+	return &existing, nil
+}
+
+// WriteManifest writes the manifest to the given path.
+func WriteManifest(path string, manifest *Manifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return errors.Wrap(err, "Marshal")
+	}
+	if err := os.WriteFile(path, data, 0o666); err != nil {
+		return errors.Wrap(err, "WriteFile")
+	}
+	return nil
+}
+
+// FileCache caches the results of indexing individual files for a language indexer, keyed by file
+// path and a hash of the file content. Language indexers use it to avoid parsing files which have
+// not changed since they were last indexed.
+//
+// A nil *FileCache is valid and caches nothing.
+type FileCache struct {
+	mu       sync.Mutex
+	previous map[string]ManifestFile
+	current  map[string]ManifestFile
+}
+
+// NewFileCache returns a cache containing the given results of previously indexing files, e.g.
+// from a Manifest.
+func NewFileCache(previous map[string]ManifestFile) *FileCache {
+	return &FileCache{previous: previous, current: map[string]ManifestFile{}}
+}
+
+// Get decodes the cached result of indexing the file at path into result (a pointer), if the file
+// was previously indexed with the same content. Reports whether the result was found.
+func (c *FileCache) Get(path string, content []byte, result interface{}) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.previous[path]
+	if !ok || cached.Hash != hashContent(content) {
+		return false
+	}
+	if err := json.Unmarshal(cached.Result, result); err != nil {
+		return false
+	}
+	c.current[path] = cached
+	return true
+}
+
+// Put records the result of indexing the file at path with the given content.
+//
+// The result is encoded immediately, so it is safe to modify after calling Put.
+func (c *FileCache) Put(path string, content []byte, result interface{}) error {
+	if c == nil {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "Marshal")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current[path] = ManifestFile{Hash: hashContent(content), Result: data}
+	return nil
+}
+
+// Files returns the files that were indexed (via Get or Put) using this cache. Files which were
+// cached previously but not seen since, e.g. because they were deleted, are not included.
+func (c *FileCache) Files() map[string]ManifestFile {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,192 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func TestFileCache(t *testing.T) {
+	type result struct{ Name string }
+
+	previous := NewFileCache(nil)
+	for path, content := range map[string]string{
+		"unchanged.txt": "unchanged",
+		"changed.txt":   "before",
+		"deleted.txt":   "deleted",
+	} {
+		if err := previous.Put(path, []byte(content), result{Name: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache := NewFileCache(previous.Files())
+	tests := []struct {
+		name, path, content string
+		want                *result
+	}{
+		{name: "unchanged", path: "unchanged.txt", content: "unchanged", want: &result{Name: "unchanged"}},
+		{name: "changed", path: "changed.txt", content: "after"},
+		{name: "new", path: "new.txt", content: "new"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got result
+			ok := cache.Get(tc.path, []byte(tc.content), &got)
+			if ok != (tc.want != nil) {
+				t.Fatalf("Get reported found=%v, want %v", ok, tc.want != nil)
+			}
+			if ok && got != *tc.want {
+				t.Fatalf("got %+v, want %+v", got, *tc.want)
+			}
+			if !ok {
+				if err := cache.Put(tc.path, []byte(tc.content), result{Name: tc.content}); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+
+	// Only files seen since the cache was created are recorded; deleted.txt drops out.
+	var files []string
+	for path := range cache.Files() {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	autogold.Want("files", []string{"changed.txt", "new.txt", "unchanged.txt"}).Equal(t, files)
+
+	var nilCache *FileCache
+	if nilCache.Get("unchanged.txt", []byte("unchanged"), &result{}) {
+		t.Fatal("nil cache reported a cached result")
+	}
+	if err := nilCache.Put("unchanged.txt", []byte("unchanged"), result{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewFileCache(nil)
+	if err := cache.Put("main.go", []byte("package main"), []string{"main"}); err != nil {
+		t.Fatal(err)
+	}
+	written := &Manifest{
+		Version: projectDirVersion,
+		Files:   map[string]map[string]ManifestFile{"go": cache.Files()},
+		Options: map[string]string{"go": Options{}.cacheKey()},
+	}
+
+	tests := []struct {
+		name     string
+		manifest *Manifest
+		want     int
+	}{
+		{name: "missing"},
+		{name: "current", manifest: written, want: 1},
+		{name: "stale version", manifest: &Manifest{Version: "0", Files: written.Files, Options: written.Options}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name)
+			if tc.manifest != nil {
+				if err := WriteManifest(path, tc.manifest); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := ReadManifest(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != projectDirVersion {
+				t.Fatalf("got version %q, want %q", got.Version, projectDirVersion)
+			}
+			if len(got.Files["go"]) != tc.want {
+				t.Fatalf("got %v cached files, want %v", len(got.Files["go"]), tc.want)
+			}
+		})
+	}
+}
+
+// countingIndexer indexes .txt files, counting how many it parses rather than reading from the
+// cache.
+type countingIndexer struct{ parsed []string }
+
+func (i *countingIndexer) Name() schema.Language { return schema.Language{Title: "Text", ID: "text"} }
+
+func (i *countingIndexer) Extensions() []string { return []string{"txt"} }
+
+func (i *countingIndexer) IndexDir(ctx context.Context, dir string, opts Options) (*schema.Index, error) {
+	i.parsed = nil
+	sources, err := Sources(dir, opts, ".txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range sources {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		var length int
+		if opts.Cache.Get(path, content, &length) {
+			continue
+		}
+		i.parsed = append(i.parsed, path)
+		if err := opts.Cache.Put(path, content, len(content)); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(i.parsed)
+	return &schema.Index{Language: i.Name()}, nil
+}
+
+func TestIndexDir_cache(t *testing.T) {
+	text := &countingIndexer{}
+	Registered[text.Name().ID] = text
+	defer delete(Registered, text.Name().ID)
+
+	dir := t.TempDir()
+	write := func(path, content string) {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.txt", "a")
+	write("b.txt", "b")
+
+	manifest := &Manifest{}
+	tests := []struct {
+		name   string
+		change func()
+		opts   Options
+		want   []string
+	}{
+		{name: "initial", want: []string{"a.txt", "b.txt"}},
+		{name: "unchanged"},
+		{name: "modified", change: func() { write("b.txt", "changed") }, want: []string{"b.txt"}},
+		{name: "include private", opts: Options{IncludePrivate: true}, want: []string{"a.txt", "b.txt"}},
+		{name: "language options", change: func() { write(ConfigFile, "options:\n  text:\n    wrap: true\n") }, opts: Options{IncludePrivate: true}, want: []string{"a.txt", "b.txt"}},
+		{name: "deleted", change: func() { os.Remove(filepath.Join(dir, "a.txt")) }, opts: Options{IncludePrivate: true}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.change != nil {
+				tc.change()
+			}
+			if _, err := IndexDir(context.Background(), dir, manifest, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+			autogold.Want(tc.name, tc.want).Equal(t, text.parsed)
+		})
+	}
+
+	var files []string
+	for path := range manifest.Files[text.Name().ID] {
+		files = append(files, path)
+	}
+	autogold.Want("manifest files", []string{"b.txt"}).Equal(t, files)
+}
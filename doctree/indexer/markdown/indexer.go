@@ -6,13 +6,15 @@ import (
 	"context"
 	"io/fs"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/adrg/frontmatter"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/doctree/doctree/indexer"
 	"github.com/sourcegraph/doctree/doctree/schema"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
 )
 
 func init() {
@@ -26,29 +28,18 @@ func (i *markdownIndexer) Name() schema.Language { return schema.LanguageMarkdow
 
 func (i *markdownIndexer) Extensions() []string { return []string{"md"} }
 
-func (i *markdownIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index, error) {
-	// Find Go sources
-	var sources []string
-	if err := fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err // error walking dir
-		}
-		if !d.IsDir() {
-			ext := filepath.Ext(path)
-			if ext == ".md" {
-				sources = append(sources, path)
-			}
-		}
-		return nil
-	}); err != nil {
-		return nil, errors.Wrap(err, "WalkDir")
+func (i *markdownIndexer) IndexDir(ctx context.Context, dir string, opts indexer.Options) (*schema.Index, error) {
+	// Find Markdown sources
+	sources, err := indexer.Sources(dir, opts, ".md")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sources")
 	}
 
 	files := 0
 	bytes := 0
 	pages := []schema.Page{}
+	dirFS := os.DirFS(dir)
 	for _, path := range sources {
-		dirFS := os.DirFS(dir)
 		content, err := fs.ReadFile(dirFS, path)
 		if err != nil {
 			return nil, errors.Wrap(err, "ReadFile")
@@ -56,41 +47,68 @@ func (i *markdownIndexer) IndexDir(ctx context.Context, dir string) (*schema.Ind
 		files += 1
 		bytes += len(content)
 
-		pages = append(pages, markdownToPage(content, path))
+		var page schema.Page
+		if !opts.Cache.Get(path, content, &page) {
+			page = markdownToPage(content, path)
+			if err := opts.Cache.Put(path, content, page); err != nil {
+				return nil, errors.Wrap(err, "Put")
+			}
+		}
+		pages = append(pages, page)
+	}
+	rewriteLinks(opts.ProjectName, pages)
+
+	nav, navFiles, err := findNavigation(dir, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "findNavigation")
 	}
+	pages = applyNavigation(pages, nav, navFiles)
 
+	// Documentation is not split by library, as Markdown files have no manifest describing them.
+	library := indexer.DefaultLibrary(dir)
+	library.Pages = pages
 	return &schema.Index{
 		SchemaVersion: schema.LatestVersion,
 		Language:      schema.LanguageMarkdown,
 		NumFiles:      files,
 		NumBytes:      bytes,
-		Libraries: []schema.Library{
-			{
-				Name:        "TODO",
-				ID:          "TODO",
-				Version:     "TODO",
-				VersionType: "TODO",
-				Pages:       pages,
-			},
-		},
+		Libraries:     []schema.Library{library},
 	}, nil
 }
 
 func markdownToPage(content []byte, path string) schema.Page {
-	// Strip frontmatter out of Markdown documents for now.
+	// Strip frontmatter out of Markdown documents, keeping the metadata doc sites commonly use.
 	var matter struct {
-		Title string   `yaml:"title"`
-		Name  string   `yaml:"name"`
-		Tags  []string `yaml:"tags"`
+		Title       string   `yaml:"title"`
+		Name        string   `yaml:"name"`
+		Description string   `yaml:"description"`
+		Tags        []string `yaml:"tags"`
+
+		// The page's position, as named by e.g. Hugo, Jekyll themes and Docusaurus.
+		Weight          *float64 `yaml:"weight"`
+		Order           *float64 `yaml:"order"`
+		NavOrder        *float64 `yaml:"nav_order"`
+		SidebarPosition *float64 `yaml:"sidebar_position"`
 	}
 	rest, _ := frontmatter.Parse(bytes.NewReader(content), &matter)
+	weight := 0
+	for _, w := range []*float64{matter.Weight, matter.Order, matter.NavOrder, matter.SidebarPosition} {
+		if w != nil {
+			weight = int(*w)
+			break
+		}
+	}
+
+	// The line number the remaining content begins on, after frontmatter.
+	startLine := 1 + bytes.Count(content, []byte("\n")) - bytes.Count(rest, []byte("\n"))
 
 	matterTitle := matter.Name
 	if matterTitle == "" {
 		matterTitle = matter.Title
 	}
 
-	primaryContent, childrenSections, firstHeaderName := markdownToSections(rest, 1, matterTitle)
+	headings := findHeadings(rest, startLine)
+	primaryContent, childrenSections, firstHeaderName := markdownToSections(rest, path, startLine, 1, matterTitle, headings)
 
 	pageTitle := matterTitle
 	if pageTitle == "" {
@@ -107,57 +125,130 @@ func markdownToPage(content []byte, path string) schema.Page {
 		pageTitle = pageTitle[:50]
 	}
 	return schema.Page{
-		Path:      path,
-		Title:     pageTitle,
-		Detail:    schema.Markdown(primaryContent),
-		SearchKey: searchKey,
-		Sections:  childrenSections,
+		Path:        path,
+		Title:       pageTitle,
+		Detail:      schema.Markdown(primaryContent),
+		Description: matter.Description,
+		Tags:        matter.Tags,
+		Weight:      weight,
+		SearchKey:   searchKey,
+		Location:    &schema.Location{Path: path},
+		Sections:    childrenSections,
 	}
 }
 
-func markdownToSections(content []byte, level int, pageTitle string) ([]byte, []schema.Section, string) {
-	sectionPrefix := []byte(strings.Repeat("#", level) + " ")
+// heading is a heading in a Markdown document.
+type heading struct {
+	level int
+	name  string
 
-	// Group all of the lines separated by a section prefix (e.g. "# heading 1")
-	var sectionContent [][][]byte
-	var lines [][]byte
-	for _, line := range bytes.Split(content, []byte("\n")) {
-		if bytes.HasPrefix(line, sectionPrefix) {
+	// The number of lines the heading spans, e.g. 2 for a setext heading (underlined by === or ---)
+	lines int
+}
+
+// findHeadings parses content as CommonMark and returns its top-level headings, keyed by the line
+// number they begin on. startLine is the line number content begins on in the file.
+//
+// Both ATX ("# heading") and setext (underlined) headings are found, while lines which merely look
+// like headings, e.g. "# comments" within fenced code blocks, are not.
+func findHeadings(content []byte, startLine int) map[int]heading {
+	headings := map[int]heading{}
+	doc := goldmark.DefaultParser().Parse(text.NewReader(content))
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		h, ok := n.(*ast.Heading)
+		if !ok || h.Lines().Len() == 0 {
+			continue // e.g. an empty heading "#"
+		}
+		first, last := h.Lines().At(0), h.Lines().At(h.Lines().Len()-1)
+		var names []string
+		for i := 0; i < h.Lines().Len(); i++ {
+			segment := h.Lines().At(i)
+			names = append(names, strings.TrimSpace(string(segment.Value(content))))
+		}
+
+		firstLine := bytes.Count(content[:first.Start], []byte("\n"))
+		lastLine := bytes.Count(content[:last.Start], []byte("\n"))
+		lines := lastLine - firstLine + 1
+		lineStart := bytes.LastIndexByte(content[:first.Start], '\n') + 1
+		if !bytes.HasPrefix(bytes.TrimLeft(content[lineStart:first.Start], " "), []byte("#")) {
+			lines++ // the underline of a setext heading
+		}
+		headings[startLine+firstLine] = heading{
+			level: h.Level,
+			name:  strings.Join(names, " "),
+			lines: lines,
+		}
+	}
+	return headings
+}
+
+// markdownToSections splits content into sections by headings of the given level. startLine is the
+// line number content begins on in the file, used for section locations, and headings are those
+// found in the file by findHeadings.
+func markdownToSections(content []byte, path string, startLine, level int, pageTitle string, headings map[int]heading) ([]byte, []schema.Section, string) {
+	isSectionHeading := func(line int) bool {
+		h, ok := headings[line]
+		return ok && h.level == level
+	}
+
+	// Group all of the lines separated by a section heading (e.g. "# heading 1"), tracking the
+	// line number each group starts on.
+	var (
+		sectionContent [][][]byte
+		sectionLines   []int
+		lines          [][]byte
+		linesStart     = startLine
+	)
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		if isSectionHeading(startLine + i) {
 			if len(lines) > 0 {
 				sectionContent = append(sectionContent, lines)
+				sectionLines = append(sectionLines, linesStart)
 			}
 			lines = nil
+			linesStart = startLine + i
 		}
 		lines = append(lines, line)
 	}
 	if len(lines) > 0 {
 		sectionContent = append(sectionContent, lines)
+		sectionLines = append(sectionLines, linesStart)
 	}
 
 	// Emit a section for each set of lines we accumulated.
 	var (
 		primaryContent  []byte
+		primaryStart    int
 		sections        = []schema.Section{}
 		firstHeaderName string
 	)
-	for _, lines := range sectionContent {
+	for i, lines := range sectionContent {
+		line := sectionLines[i]
 		var name string
-		if bytes.HasPrefix(lines[0], sectionPrefix) {
-			name = string(bytes.TrimPrefix(lines[0], sectionPrefix))
+		headingLines := 0
+		if isSectionHeading(line) {
+			name = headings[line].name
+			headingLines = headings[line].lines
+			if headingLines > len(lines) {
+				headingLines = len(lines)
+			}
 		}
 
 		if level == 1 && name == "" {
 			// This is the content before any heading in a document.
 			subPrimaryContent, subChildrenSections, _ := markdownToSections(
 				bytes.Join(lines, []byte("\n")),
+				path,
+				line,
 				level+1,
 				pageTitle,
+				headings,
 			)
-			primaryContent = subPrimaryContent
+			primaryContent, primaryStart = subPrimaryContent, line
 			sections = append(sections, subChildrenSections...)
 			continue
 		} else if name == "" {
-			primaryContent = bytes.Join(lines, []byte("\n"))
+			primaryContent, primaryStart = bytes.Join(lines, []byte("\n")), line
 			continue
 		}
 
@@ -168,19 +259,25 @@ func markdownToSections(content []byte, level int, pageTitle string) ([]byte, []
 				pageTitle = firstHeaderName
 			}
 			subPrimaryContent, subChildrenSections, _ := markdownToSections(
-				bytes.Join(lines[1:], []byte("\n")),
+				bytes.Join(lines[headingLines:], []byte("\n")),
+				path,
+				line+headingLines,
 				level+1,
 				pageTitle,
+				headings,
 			)
-			primaryContent = subPrimaryContent
+			primaryContent, primaryStart = subPrimaryContent, line+headingLines
 			sections = append(sections, subChildrenSections...)
 			continue
 		}
 
 		subPrimaryContent, subChildrenSections, _ := markdownToSections(
-			bytes.Join(lines[1:], []byte("\n")),
+			bytes.Join(lines[headingLines:], []byte("\n")),
+			path,
+			line+headingLines,
 			level+1,
 			pageTitle,
+			headings,
 		)
 
 		searchKey := headerSearchKey(pageTitle, name)
@@ -194,25 +291,42 @@ func markdownToSections(content []byte, level int, pageTitle string) ([]byte, []
 			Label:      schema.Markdown(name),
 			Detail:     schema.Markdown(subPrimaryContent),
 			SearchKey:  searchKey,
+			Location:   sectionLocation(path, line, lines),
 			Children:   subChildrenSections,
 		})
 	}
 
 	if len(sections) == 0 && level < 6 {
 		nonlinear := false
-		for _, line := range bytes.Split(primaryContent, []byte("\n")) {
-			if bytes.HasPrefix(line, []byte("#")) {
+		for i := range bytes.Split(primaryContent, []byte("\n")) {
+			if _, ok := headings[primaryStart+i]; ok {
 				nonlinear = true
 				break
 			}
 		}
 		if nonlinear {
-			return markdownToSections(content, level+1, pageTitle)
+			return markdownToSections(content, path, startLine, level+1, pageTitle, headings)
 		}
 	}
 	return primaryContent, sections, firstHeaderName
 }
 
+// sectionLocation returns the location of a section made up of the given lines, starting at the
+// given line number. Trailing blank lines are not considered part of the section.
+func sectionLocation(path string, startLine int, lines [][]byte) *schema.Location {
+	last := len(lines) - 1
+	for last > 0 && len(bytes.TrimSpace(lines[last])) == 0 {
+		last--
+	}
+	return &schema.Location{
+		Path:        path,
+		StartLine:   startLine,
+		StartColumn: 1,
+		EndLine:     startLine + last,
+		EndColumn:   len(lines[last]) + 1,
+	}
+}
+
 func headerSearchKey(pageTitle, section string) []string {
 	name := joinNames(pageTitle, section)
 	fields := strings.Fields(name)
@@ -1,6 +1,8 @@
 package markdown
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hexops/autogold"
@@ -27,6 +29,7 @@ Repo for https://ziglearn.org content. Feedback and PRs welcome.
 			" ",
 			"ziglearn",
 		},
+		Location: &schema.Location{Path: "README.md"},
 		Sections: []schema.Section{{
 			ID:         "How to run the tests",
 			ShortLabel: "How to run the tests",
@@ -49,6 +52,13 @@ Repo for https://ziglearn.org content. Feedback and PRs welcome.
 				" ",
 				"tests",
 			},
+			Location: &schema.Location{
+				Path:        "README.md",
+				StartLine:   5,
+				StartColumn: 1,
+				EndLine:     8,
+				EndColumn:   27,
+			},
 			Children: []schema.Section{},
 		}},
 	}).Equal(t, page)
@@ -92,6 +102,7 @@ content2-1
 			" ",
 			"heading1",
 		},
+		Location: &schema.Location{Path: "README.md"},
 		Sections: []schema.Section{
 			{
 				ID:         "heading2-0",
@@ -107,6 +118,13 @@ content2-1
 					" ",
 					"heading2-0",
 				},
+				Location: &schema.Location{
+					Path:        "README.md",
+					StartLine:   5,
+					StartColumn: 1,
+					EndLine:     23,
+					EndColumn:   11,
+				},
 				Children: []schema.Section{
 					{
 						ID:         "heading3-0",
@@ -122,6 +140,13 @@ content2-1
 							" ",
 							"heading3-0",
 						},
+						Location: &schema.Location{
+							Path:        "README.md",
+							StartLine:   9,
+							StartColumn: 1,
+							EndLine:     19,
+							EndColumn:   11,
+						},
 						Children: []schema.Section{
 							{
 								ID:         "heading4-0",
@@ -137,6 +162,13 @@ content2-1
 									" ",
 									"heading4-0",
 								},
+								Location: &schema.Location{
+									Path:        "README.md",
+									StartLine:   13,
+									StartColumn: 1,
+									EndLine:     15,
+									EndColumn:   11,
+								},
 								Children: []schema.Section{},
 							},
 							{
@@ -153,6 +185,13 @@ content2-1
 									" ",
 									"heading4-1",
 								},
+								Location: &schema.Location{
+									Path:        "README.md",
+									StartLine:   17,
+									StartColumn: 1,
+									EndLine:     19,
+									EndColumn:   11,
+								},
 								Children: []schema.Section{},
 							},
 						},
@@ -171,6 +210,13 @@ content2-1
 							" ",
 							"heading3-1",
 						},
+						Location: &schema.Location{
+							Path:        "README.md",
+							StartLine:   21,
+							StartColumn: 1,
+							EndLine:     23,
+							EndColumn:   11,
+						},
 						Children: []schema.Section{},
 					},
 				},
@@ -189,6 +235,13 @@ content2-1
 					" ",
 					"heading2-1",
 				},
+				Location: &schema.Location{
+					Path:        "README.md",
+					StartLine:   25,
+					StartColumn: 1,
+					EndLine:     27,
+					EndColumn:   11,
+				},
 				Children: []schema.Section{},
 			},
 		},
@@ -217,6 +270,8 @@ content2
 		Detail: schema.Markdown(`
 This content is not preceded by a heading.
 `),
+		Description: "yay",
+		Weight:      1,
 		SearchKey: []string{
 			"#",
 			" ",
@@ -224,6 +279,7 @@ This content is not preceded by a heading.
 			" ",
 			"title",
 		},
+		Location: &schema.Location{Path: "README.md"},
 		Sections: []schema.Section{{
 			ID:         "heading2",
 			ShortLabel: "heading2",
@@ -240,6 +296,13 @@ This content is not preceded by a heading.
 				" ",
 				"heading2",
 			},
+			Location: &schema.Location{
+				Path:        "README.md",
+				StartLine:   11,
+				StartColumn: 1,
+				EndLine:     13,
+				EndColumn:   9,
+			},
 			Children: []schema.Section{},
 		}},
 	}).Equal(t, page)
@@ -267,6 +330,7 @@ a
 			" ",
 			"Language",
 		},
+		Location: &schema.Location{Path: "README.md"},
 		Sections: []schema.Section{
 			{
 				ID:         "Download and Install",
@@ -291,6 +355,13 @@ a
 					" ",
 					"Install",
 				},
+				Location: &schema.Location{
+					Path:        "README.md",
+					StartLine:   2,
+					StartColumn: 1,
+					EndLine:     5,
+					EndColumn:   25,
+				},
 				Children: []schema.Section{
 					{
 						ID:         "Binary Distributions",
@@ -314,6 +385,13 @@ a
 							" ",
 							"Distributions",
 						},
+						Location: &schema.Location{
+							Path:        "README.md",
+							StartLine:   3,
+							StartColumn: 1,
+							EndLine:     4,
+							EndColumn:   2,
+						},
 						Children: []schema.Section{},
 					},
 					{
@@ -339,6 +417,13 @@ a
 							" ",
 							"Source",
 						},
+						Location: &schema.Location{
+							Path:        "README.md",
+							StartLine:   5,
+							StartColumn: 1,
+							EndLine:     5,
+							EndColumn:   25,
+						},
 						Children: []schema.Section{},
 					},
 				},
@@ -362,6 +447,13 @@ a
 					" ",
 					"Contributing",
 				},
+				Location: &schema.Location{
+					Path:        "README.md",
+					StartLine:   6,
+					StartColumn: 1,
+					EndLine:     6,
+					EndColumn:   17,
+				},
 				Children: []schema.Section{},
 			},
 		},
@@ -398,6 +490,7 @@ yay
 			"#",
 			" ",
 		},
+		Location: &schema.Location{Path: "README.md"},
 		Sections: []schema.Section{{
 			ID:         "Introduction to the Go compiler",
 			ShortLabel: "Introduction to the Go compiler",
@@ -416,6 +509,13 @@ yay
 				" ",
 				"compiler",
 			},
+			Location: &schema.Location{
+				Path:        "README.md",
+				StartLine:   7,
+				StartColumn: 1,
+				EndLine:     13,
+				EndColumn:   4,
+			},
 			Children: []schema.Section{{
 				ID:         "1. Parsing",
 				ShortLabel: "1. Parsing",
@@ -428,8 +528,99 @@ yay
 					" ",
 					"Parsing",
 				},
+				Location: &schema.Location{
+					Path:        "README.md",
+					StartLine:   11,
+					StartColumn: 1,
+					EndLine:     13,
+					EndColumn:   4,
+				},
 				Children: []schema.Section{},
 			}},
 		}},
 	}).Equal(t, page)
 }
+
+func Test_findHeadings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "setext",
+			content: "Title\n=====\n\nText\n\nSub heading\nspanning lines\n---\n\n## ATX ##\n",
+			want: []string{
+				"line 1, level 1, 2 lines: Title",
+				"line 6, level 2, 3 lines: Sub heading spanning lines",
+				"line 10, level 2, 1 lines: ATX",
+			},
+		},
+		{
+			name:    "code-blocks",
+			content: "# Title\n\n```sh\n# not a heading\n```\n\n    # indented code\n\n~~~\n## nor this\n~~~\n\n## Real\n",
+			want: []string{
+				"line 1, level 1, 1 lines: Title",
+				"line 13, level 2, 1 lines: Real",
+			},
+		},
+		{
+			name:    "not-headings",
+			content: "#\n\n#hashtag\n\n # Indented\n",
+			want:    []string{"line 5, level 1, 1 lines: Indented"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			headings := findHeadings([]byte(tc.content), 1)
+			var got []string
+			for line := 1; line <= strings.Count(tc.content, "\n"); line++ {
+				if h, ok := headings[line]; ok {
+					got = append(got, fmt.Sprintf("line %d, level %d, %d lines: %s", line, h.level, h.lines, h.name))
+				}
+			}
+			autogold.Want(tc.name, tc.want).Equal(t, got)
+		})
+	}
+}
+
+func Test_rewriteLinks(t *testing.T) {
+	pages := []schema.Page{
+		markdownToPage([]byte(`# Readme
+
+See [install](docs/INSTALL.md#from-source), [docs](docs/), [same](#usage), [web](https://example.com/a.md), [missing](#nope), [other](docs/INSTALL.md#nope), [image](logo.png) and `+"`[code](docs/INSTALL.md)`"+`.
+
+`+"```"+`
+[block](docs/INSTALL.md)
+`+"```"+`
+
+## Usage
+
+Read [the guide](docs/INSTALL.md).
+`), "README.md"),
+		markdownToPage([]byte("# Install\n\nBack to [readme](../README.md#usage).\n\n## From source!\n\nText.\n"), "docs/INSTALL.md"),
+		markdownToPage([]byte("# Docs\n\n[install](./INSTALL.md)\n"), "docs/index.md"),
+	}
+	rewriteLinks("example.com/proj", pages)
+
+	var got []string
+	for _, page := range pages {
+		got = append(got, string(page.Detail))
+		for _, section := range page.Sections {
+			got = append(got, string(section.Detail))
+		}
+	}
+	autogold.Want("links", []string{
+		"\nSee [install](/example.com/proj/-/markdown/-/docs/INSTALL.md?id=From%20source%21), [docs](/example.com/proj/-/markdown/-/docs/index.md), [same](/example.com/proj/-/markdown/-/README.md?id=Usage), [web](https://example.com/a.md), [missing](#nope), [other](/example.com/proj/-/markdown/-/docs/INSTALL.md), [image](logo.png) and `[code](docs/INSTALL.md)`.\n\n```\n[block](docs/INSTALL.md)\n```\n",
+		`
+Read [the guide](/example.com/proj/-/markdown/-/docs/INSTALL.md).
+`,
+		`
+Back to [readme](/example.com/proj/-/markdown/-/README.md?id=Usage).
+`,
+		"\nText.\n",
+		`
+[install](/example.com/proj/-/markdown/-/docs/INSTALL.md)
+`,
+	}).Equal(t, got)
+}
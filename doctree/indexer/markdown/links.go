@@ -0,0 +1,176 @@
+package markdown
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// rewriteLinks rewrites relative links between the given Markdown pages of the named project, e.g.
+// "[install](../INSTALL.md#from-source)", to the frontend URL of the matching doctree page and
+// section (e.g. "/github.com/foo/bar/-/markdown/-/INSTALL.md?id=From%20source"), so they work
+// wherever the page is rendered. Links to a directory lead to its README.md or index.md page.
+// Other links, e.g. to websites or images, are left as-is.
+func rewriteLinks(projectName string, pages []schema.Page) {
+	// The IDs of the sections of each page, by their anchor (e.g. "from-source") as rendered by
+	// GitHub and most other Markdown renderers.
+	anchors := map[string]map[string]string{}
+	for _, page := range pages {
+		pageAnchors := map[string]string{}
+		collectAnchors(page.Sections, pageAnchors)
+		anchors[filepath.ToSlash(page.Path)] = pageAnchors
+	}
+
+	for i := range pages {
+		pagePath := filepath.ToSlash(pages[i].Path)
+		pages[i].Detail = rewriteMarkdownLinks(pages[i].Detail, projectName, pagePath, anchors)
+		rewriteSectionLinks(pages[i].Sections, projectName, pagePath, anchors)
+	}
+}
+
+func collectAnchors(sections []schema.Section, anchors map[string]string) {
+	for _, section := range sections {
+		if _, ok := anchors[anchor(section.ID)]; !ok {
+			anchors[anchor(section.ID)] = section.ID
+		}
+		collectAnchors(section.Children, anchors)
+	}
+}
+
+func rewriteSectionLinks(sections []schema.Section, projectName, pagePath string, anchors map[string]map[string]string) {
+	for i := range sections {
+		sections[i].Detail = rewriteMarkdownLinks(sections[i].Detail, projectName, pagePath, anchors)
+		rewriteSectionLinks(sections[i].Children, projectName, pagePath, anchors)
+	}
+}
+
+// anchor returns the anchor of a heading, e.g. "from-source" for "From source!"
+func anchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case r == ' ' || r == '-':
+			b.WriteRune('-')
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// rewriteMarkdownLinks rewrites the relative links in Markdown content from the page at pagePath.
+// Links are found by parsing the content, so that e.g. code blocks are left intact.
+func rewriteMarkdownLinks(content schema.Markdown, projectName, pagePath string, anchors map[string]map[string]string) schema.Markdown {
+	source := []byte(content)
+	if !bytes.Contains(source, []byte("](")) {
+		return content
+	}
+
+	type replacement struct {
+		start, end int
+		dest       string
+	}
+	var replacements []replacement
+	doc := goldmark.DefaultParser().Parse(text.NewReader(source))
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		link, ok := n.(*ast.Link)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		dest, ok := rewriteLink(string(link.Destination), projectName, pagePath, anchors)
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+
+		// Find the destination in the source, following the text of the link: "[text](dest)"
+		textEnd := inlineEnd(link)
+		if textEnd < 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		start := bytes.Index(source[textEnd:], []byte("]("))
+		if start < 0 {
+			return ast.WalkSkipChildren, nil // e.g. a reference link, "[text][ref]"
+		}
+		start += textEnd + len("](")
+		for start < len(source) && (source[start] == ' ' || source[start] == '<') {
+			start++
+		}
+		if !bytes.HasPrefix(source[start:], link.Destination) {
+			return ast.WalkSkipChildren, nil // e.g. escaped characters in the destination
+		}
+		replacements = append(replacements, replacement{start, start + len(link.Destination), dest})
+		return ast.WalkSkipChildren, nil
+	})
+	if len(replacements) == 0 {
+		return content
+	}
+
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start > replacements[j].start })
+	for _, r := range replacements {
+		source = append(source[:r.start:r.start], append([]byte(r.dest), source[r.end:]...)...)
+	}
+	return schema.Markdown(source)
+}
+
+// inlineEnd returns the offset in the source at which the text within an inline node (e.g. a
+// link) ends, or -1 if it has none.
+func inlineEnd(n ast.Node) int {
+	end := -1
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if t, ok := child.(*ast.Text); ok && t.Segment.Stop > end {
+			end = t.Segment.Stop
+		}
+		if childEnd := inlineEnd(child); childEnd > end {
+			end = childEnd
+		}
+	}
+	return end
+}
+
+// rewriteLink returns the destination a relative link from the page at pagePath should have, or
+// false if it is not a link to a section or another page.
+func rewriteLink(dest, projectName, pagePath string, anchors map[string]map[string]string) (string, bool) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(u.Path, "/") || u.RawQuery != "" {
+		return "", false
+	}
+	if u.Path == "" && u.Fragment == "" {
+		return "", false
+	}
+
+	target := pagePath
+	if u.Path != "" {
+		target = path.Join(path.Dir(pagePath), u.Path)
+		if _, ok := anchors[target]; !ok {
+			// A directory, e.g. "docs/", leads to its README.md or index.md
+			for _, index := range []string{"README.md", "readme.md", "index.md"} {
+				if _, ok := anchors[path.Join(target, index)]; ok {
+					target = path.Join(target, index)
+					break
+				}
+			}
+		}
+	}
+	targetAnchors, ok := anchors[target]
+	if !ok {
+		return "", false // not a Markdown page, e.g. an image or source file
+	}
+
+	var sectionID string
+	if u.Fragment != "" {
+		if sectionID, ok = targetAnchors[anchor(u.Fragment)]; !ok && u.Path == "" {
+			return "", false // no such section on this page
+		}
+	}
+	return indexer.PageURL(projectName, schema.LanguageMarkdown.ID, target, sectionID), true
+}
@@ -0,0 +1,376 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+// navEntry is an entry in the navigation of a documentation site, e.g. a chapter listed in an
+// mdBook SUMMARY.md file.
+type navEntry struct {
+	title string
+
+	// Path of the Markdown page relative to the indexed directory, or "" for e.g. a section of the
+	// navigation without a page of its own.
+	path string
+
+	children []navEntry
+}
+
+// findNavigation finds the navigation files of documentation sites in dir, i.e. mdBook SUMMARY.md,
+// MkDocs mkdocs.yml and Docusaurus sidebars.js (or sidebars.json) files, and returns the entries
+// they list along with the paths of the navigation files themselves.
+func findNavigation(dir string, opts indexer.Options) ([]navEntry, []string, error) {
+	navFiles, err := indexer.FindFiles(dir, opts, "SUMMARY.md", "mkdocs.yml", "mkdocs.yaml", "sidebars.js", "sidebars.json")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "FindFiles")
+	}
+	sort.Strings(navFiles)
+
+	var nav []navEntry
+	for _, navFile := range navFiles {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(navFile)))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "ReadFile")
+		}
+		navFile = filepath.ToSlash(navFile)
+		navDir := path.Dir(navFile)
+		switch path.Base(navFile) {
+		case "SUMMARY.md":
+			nav = append(nav, summaryNav(content, navDir)...)
+		case "mkdocs.yml", "mkdocs.yaml":
+			entries, err := mkdocsNav(content, navDir)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, navFile)
+			}
+			nav = append(nav, entries...)
+		default:
+			nav = append(nav, docusaurusNav(content, navDir)...)
+		}
+	}
+	return nav, navFiles, nil
+}
+
+// applyNavigation orders pages as they are listed in nav, using the titles given there, and lists
+// the pages nested under another in nav as its subpages. Pages not listed in nav come after those
+// that are, ordered by their own weight. Navigation files which are Markdown (e.g. SUMMARY.md) are
+// not pages themselves, and are removed.
+func applyNavigation(pages []schema.Page, nav []navEntry, navFiles []string) []schema.Page {
+	isNavFile := map[string]bool{}
+	for _, navFile := range navFiles {
+		isNavFile[navFile] = true
+	}
+	var filtered []schema.Page
+	for _, page := range pages {
+		if !isNavFile[filepath.ToSlash(page.Path)] {
+			filtered = append(filtered, page)
+		}
+	}
+	pages = filtered
+
+	byPath := make(map[string]int, len(pages))
+	for i, page := range pages {
+		byPath[filepath.ToSlash(page.Path)] = i
+	}
+	listed := map[int]bool{}
+	weight := 0
+
+	// visit orders the pages of entries depth-first, returning those which are direct children of
+	// the parent entry. The children of entries without a page are treated as direct children.
+	var visit func(entries []navEntry) []int
+	visit = func(entries []navEntry) []int {
+		var direct []int
+		for _, entry := range entries {
+			i, ok := byPath[entry.path]
+			if ok && !listed[i] {
+				listed[i] = true
+				weight++
+				pages[i].Weight = weight
+				if entry.title != "" {
+					pages[i].Title = entry.title
+				}
+			}
+			children := visit(entry.children)
+			if !ok {
+				direct = append(direct, children...)
+				continue
+			}
+			for _, child := range children {
+				pages[i].Subpages = append(pages[i].Subpages, schema.Page{
+					Path:      pages[child].Path,
+					Title:     pages[child].Title,
+					SearchKey: []string{},
+					Location:  pages[child].Location,
+					Sections:  []schema.Section{},
+				})
+			}
+			direct = append(direct, i)
+		}
+		return direct
+	}
+	visit(nav)
+
+	if weight > 0 {
+		for i := range pages {
+			if !listed[i] {
+				pages[i].Weight += weight + 1
+			}
+		}
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		if pages[i].Weight != pages[j].Weight {
+			return pages[i].Weight < pages[j].Weight
+		}
+		return pages[i].Path < pages[j].Path
+	})
+	return pages
+}
+
+// navPath returns the path of the page a navigation file in dir links to, or "" if it links to
+// e.g. a website rather than a Markdown page.
+func navPath(dir, link string) string {
+	link, _, _ = strings.Cut(link, "#")
+	if link == "" || strings.Contains(link, "://") || strings.HasPrefix(link, "/") || path.Ext(link) != ".md" {
+		return ""
+	}
+	return path.Join(dir, link)
+}
+
+// summaryNav returns the chapters listed in an mdBook SUMMARY.md file in dir: links in (nested)
+// lists, and prefix/suffix chapters linked outside of lists. Headings (part titles) are ignored.
+func summaryNav(content []byte, dir string) []navEntry {
+	var entries []navEntry
+	doc := goldmark.DefaultParser().Parse(text.NewReader(content))
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		switch n := n.(type) {
+		case *ast.List:
+			entries = append(entries, summaryList(n, content, dir)...)
+		case *ast.Paragraph:
+			for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+				if link, ok := child.(*ast.Link); ok {
+					entries = append(entries, navEntry{
+						title: string(link.Text(content)),
+						path:  navPath(dir, string(link.Destination)),
+					})
+				}
+			}
+		}
+	}
+	return entries
+}
+
+func summaryList(list *ast.List, content []byte, dir string) []navEntry {
+	var entries []navEntry
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		var entry navEntry
+		for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+			if nested, ok := child.(*ast.List); ok {
+				entry.children = append(entry.children, summaryList(nested, content, dir)...)
+				continue
+			}
+			entry.title = string(child.Text(content))
+			for inline := child.FirstChild(); inline != nil; inline = inline.NextSibling() {
+				if link, ok := inline.(*ast.Link); ok {
+					entry.title = string(link.Text(content))
+					entry.path = navPath(dir, string(link.Destination))
+					break
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// mkdocsNav returns the pages listed in the nav of an MkDocs mkdocs.yml file in dir. Paths in the
+// nav are relative to the docs_dir, "docs" by default.
+func mkdocsNav(content []byte, dir string) ([]navEntry, error) {
+	var config struct {
+		DocsDir string        `yaml:"docs_dir"`
+		Nav     []interface{} `yaml:"nav"`
+	}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, errors.Wrap(err, "Unmarshal")
+	}
+	docsDir := config.DocsDir
+	if docsDir == "" {
+		docsDir = "docs"
+	}
+	return mkdocsEntries(config.Nav, path.Join(dir, docsDir)), nil
+}
+
+// mkdocsEntries returns the entries of an MkDocs nav, whose items are either paths, e.g.
+// "index.md", or single-key maps of a title to a path or a list of items, e.g. "Guide: guide.md".
+func mkdocsEntries(items []interface{}, docsDir string) []navEntry {
+	var entries []navEntry
+	for _, item := range items {
+		switch item := item.(type) {
+		case string:
+			entries = append(entries, navEntry{path: navPath(docsDir, item)})
+		case map[string]interface{}:
+			for title, value := range item {
+				switch value := value.(type) {
+				case string:
+					entries = append(entries, navEntry{title: title, path: navPath(docsDir, value)})
+				case []interface{}:
+					entries = append(entries, navEntry{title: title, children: mkdocsEntries(value, docsDir)})
+				}
+			}
+		}
+	}
+	return entries
+}
+
+// docusaurusNav returns the docs listed in a Docusaurus sidebars.js (or sidebars.json) file in dir.
+// Docs are identified by their path relative to the docs directory, without extension.
+//
+// Only sidebars declared as a static object literal (e.g. `module.exports = { docs: [...] }`) are
+// supported, others are ignored.
+func docusaurusNav(content []byte, dir string) []navEntry {
+	var sidebars map[string]interface{}
+	if err := json.Unmarshal(staticJSON(content), &sidebars); err != nil {
+		return nil
+	}
+	docsDir := path.Join(dir, "docs")
+
+	var names []string
+	for name := range sidebars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var entries []navEntry
+	for _, name := range names {
+		entries = append(entries, docusaurusEntries(sidebars[name], docsDir)...)
+	}
+	return entries
+}
+
+// docusaurusEntries returns the entries of a Docusaurus sidebar, whose items are doc IDs, or
+// objects describing a doc or a category of items (of which there is also a shorthand form,
+// `{"Category": [...items]}`.)
+func docusaurusEntries(items interface{}, docsDir string) []navEntry {
+	docPath := func(id interface{}) string {
+		if id, ok := id.(string); ok {
+			return path.Join(docsDir, id+".md")
+		}
+		return ""
+	}
+	label := func(item map[string]interface{}) string {
+		label, _ := item["label"].(string)
+		return label
+	}
+	categories := func(item map[string]interface{}) []navEntry {
+		var titles []string
+		for title := range item {
+			titles = append(titles, title)
+		}
+		sort.Strings(titles)
+		var entries []navEntry
+		for _, title := range titles {
+			entries = append(entries, navEntry{title: title, children: docusaurusEntries(item[title], docsDir)})
+		}
+		return entries
+	}
+
+	switch items := items.(type) {
+	case map[string]interface{}:
+		return categories(items)
+	case []interface{}:
+		var entries []navEntry
+		for _, item := range items {
+			switch item := item.(type) {
+			case string:
+				entries = append(entries, navEntry{path: docPath(item)})
+			case map[string]interface{}:
+				switch item["type"] {
+				case "doc":
+					entries = append(entries, navEntry{title: label(item), path: docPath(item["id"])})
+				case "category":
+					entry := navEntry{title: label(item), children: docusaurusEntries(item["items"], docsDir)}
+					if link, ok := item["link"].(map[string]interface{}); ok && link["type"] == "doc" {
+						entry.path = docPath(link["id"])
+					}
+					entries = append(entries, entry)
+				case nil:
+					entries = append(entries, categories(item)...)
+				}
+			}
+		}
+		return entries
+	}
+	return nil
+}
+
+var trailingComma = regexp.MustCompile(`,(\s*[}\]])`)
+
+// staticJSON converts the object literal in a JavaScript file, e.g. `module.exports = {...};`, to
+// JSON: comments and trailing commas are removed, and keys and strings are double-quoted. Other
+// code, e.g. variables or function calls, is left as-is, producing invalid JSON.
+func staticJSON(js []byte) []byte {
+	var out bytes.Buffer
+	isIdent := func(c byte) bool {
+		return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+	}
+	for i := 0; i < len(js); i++ {
+		c := js[i]
+		switch {
+		case c == '/' && i+1 < len(js) && js[i+1] == '/':
+			for i < len(js) && js[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(js) && js[i+1] == '*':
+			end := bytes.Index(js[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil
+			}
+			i += 2 + end + 1
+		case c == '"' || c == '\'' || c == '`':
+			var s strings.Builder
+			j := i + 1
+			for ; j < len(js) && js[j] != c; j++ {
+				if js[j] == '\\' && j+1 < len(js) {
+					j++
+				}
+				s.WriteByte(js[j])
+			}
+			quoted, _ := json.Marshal(s.String())
+			out.Write(quoted)
+			i = j
+		case isIdent(c) && (c < '0' || c > '9'):
+			j := i
+			for j < len(js) && isIdent(js[j]) {
+				j++
+			}
+			word := string(js[i:j])
+			if rest := bytes.TrimLeft(js[j:], " \t\r\n"); len(rest) > 0 && rest[0] == ':' {
+				word = strconv.Quote(word) // an unquoted key
+			}
+			out.WriteString(word)
+			i = j - 1
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	result := trailingComma.ReplaceAll(out.Bytes(), []byte("$1"))
+	start, end := bytes.IndexByte(result, '{'), bytes.LastIndexByte(result, '}')
+	if start < 0 || end < start {
+		return nil
+	}
+	return result[start : end+1]
+}
@@ -0,0 +1,167 @@
+package markdown
+
+import (
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+// navLines formats navigation entries as lines of "title: path", indented by their depth.
+func navLines(entries []navEntry, depth int) []string {
+	var lines []string
+	for _, entry := range entries {
+		lines = append(lines, strings.Repeat("  ", depth)+entry.title+": "+entry.path)
+		lines = append(lines, navLines(entry.children, depth+1)...)
+	}
+	return lines
+}
+
+func Test_navigation(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{
+			name: "mdbook",
+			file: "book/src/SUMMARY.md",
+			content: `# Summary
+
+[Introduction](README.md)
+
+# User guide
+
+- [Installation](guide/install.md)
+    - [From source](guide/source.md#building)
+- [Draft chapter]()
+    - [Website](https://example.com)
+
+[Contributors](misc/contributors.md)
+`,
+			want: []string{
+				"Introduction: book/src/README.md",
+				"Installation: book/src/guide/install.md",
+				"  From source: book/src/guide/source.md",
+				"Draft chapter: ",
+				"  Website: ",
+				"Contributors: book/src/misc/contributors.md",
+			},
+		},
+		{
+			name: "mkdocs",
+			file: "mkdocs.yml",
+			content: `site_name: Example
+docs_dir: site
+nav:
+  - index.md
+  - Guide:
+      - Install: guide/install.md
+      - guide/usage.md
+  - Website: https://example.com
+`,
+			want: []string{
+				": site/index.md",
+				"Guide: ",
+				"  Install: site/guide/install.md",
+				"  : site/guide/usage.md",
+				"Website: ",
+			},
+		},
+		{
+			name: "docusaurus",
+			file: "website/sidebars.js",
+			content: `// The sidebars of the docs.
+module.exports = {
+  docs: [
+    'intro',
+    {type: 'doc', id: 'install', label: "Installing"},
+    {
+      type: 'category',
+      label: 'Guides',
+      link: {type: 'doc', id: 'guides/index'},
+      items: ['guides/usage', /* soon: 'guides/faq', */],
+    },
+  ],
+  api: {Reference: ['api/client']},
+};
+`,
+			want: []string{
+				"Reference: ",
+				"  : website/docs/api/client.md",
+				": website/docs/intro.md",
+				"Installing: website/docs/install.md",
+				"Guides: website/docs/guides/index.md",
+				"  : website/docs/guides/usage.md",
+			},
+		},
+		{
+			name:    "docusaurus-dynamic",
+			file:    "sidebars.js",
+			content: "const items = require('./items');\nmodule.exports = { docs: items };\n",
+			want:    nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var entries []navEntry
+			dir := path.Dir(tc.file)
+			switch path.Base(tc.file) {
+			case "SUMMARY.md":
+				entries = summaryNav([]byte(tc.content), dir)
+			case "mkdocs.yml":
+				var err error
+				entries, err = mkdocsNav([]byte(tc.content), dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+			default:
+				entries = docusaurusNav([]byte(tc.content), dir)
+			}
+			autogold.Want(tc.name, tc.want).Equal(t, navLines(entries, 0))
+		})
+	}
+}
+
+func Test_applyNavigation(t *testing.T) {
+	page := func(path, title string, weight int) schema.Page {
+		return schema.Page{Path: path, Title: title, Weight: weight}
+	}
+	pages := []schema.Page{
+		page("README.md", "Readme", 0),
+		page("SUMMARY.md", "Summary", 0),
+		page("guide/install.md", "Install", 0),
+		page("guide/source.md", "Source", 0),
+		page("notes.md", "Notes", 2),
+		page("changelog.md", "Changelog", 1),
+		page("unlisted.md", "Unlisted", 0),
+	}
+	nav := []navEntry{
+		{title: "Installation", path: "guide/install.md", children: []navEntry{
+			{title: "From source", path: "guide/source.md"},
+		}},
+		{title: "Part", children: []navEntry{
+			{path: "README.md"},
+		}},
+	}
+
+	var got []string
+	for _, page := range applyNavigation(pages, nav, []string{"SUMMARY.md"}) {
+		line := page.Path + ": " + page.Title
+		for _, subpage := range page.Subpages {
+			line += ", " + subpage.Path
+		}
+		got = append(got, line)
+	}
+	autogold.Want("pages", []string{
+		"guide/install.md: Installation, guide/source.md",
+		"guide/source.md: From source",
+		"README.md: Readme",
+		"unlisted.md: Unlisted",
+		"changelog.md: Changelog",
+		"notes.md: Notes",
+	}).Equal(t, got)
+}
@@ -0,0 +1,299 @@
+package python
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/doctree/doctree/indexer"
+)
+
+// formatDocstring converts the contents of a docstring to Markdown. Indentation is removed as
+// described in PEP 257, and the parameters, return values and exceptions documented using Google
+// style ("Args:"), NumPy style ("Parameters" underlined with dashes) or reStructuredText fields
+// (":param x:") are converted to tables.
+func formatDocstring(docstring string) string {
+	lines := dedentDocstring(docstring)
+	for _, line := range lines {
+		if restField.MatchString(line) {
+			return formatRestDocstring(lines)
+		}
+	}
+	return formatSectionedDocstring(lines)
+}
+
+// dedentDocstring splits a docstring into lines, removing the indentation common to all but the
+// first line (which directly follows the opening quotes) and leading and trailing blank lines.
+func dedentDocstring(docstring string) []string {
+	lines := strings.Split(strings.ReplaceAll(docstring, "\t", "        "), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := indentation(line); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	lines[0] = strings.TrimSpace(lines[0])
+	for i := 1; i < len(lines); i++ {
+		if len(lines[i]) >= indent && indent > 0 {
+			lines[i] = lines[i][indent:]
+		}
+		lines[i] = strings.TrimRight(lines[i], " \r")
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// docField is a documented parameter, return value or exception.
+type docField struct {
+	name, typ, desc string
+}
+
+// Kinds of docstring sections, by their (lower case) title.
+var docSectionKinds = map[string]string{
+	"args": "params", "arguments": "params", "parameters": "params", "params": "params",
+	"keyword args": "params", "keyword arguments": "params", "other parameters": "params",
+	"attributes": "params", "returns": "returns", "return": "returns", "yields": "returns",
+	"yield": "returns", "raises": "raises", "raise": "raises", "warns": "raises",
+	"example": "text", "examples": "text", "note": "text", "notes": "text", "warning": "text",
+	"warnings": "text", "see also": "text", "references": "text", "todo": "text",
+}
+
+var (
+	numpyUnderline = regexp.MustCompile(`^\s*-{3,}\s*$`)
+
+	// e.g. "x (int): The value." or "x (int, optional): The value."
+	googleTypedField = regexp.MustCompile(`^(\*{0,2}[\w.]+)\s*\(([^)]*)\)\s*:\s*(.*)$`)
+
+	// e.g. "x: The value."
+	googleField = regexp.MustCompile(`^(\*{0,2}[\w.]+)\s*:\s*(.*)$`)
+
+	// e.g. "x : int"
+	numpyField = regexp.MustCompile(`^(\*{0,2}[\w., ]+?)\s+:\s*(.*)$`)
+
+	// e.g. "int: The value." in a Google style Returns section
+	googleReturn = regexp.MustCompile(`^([\w.\[\]|]+(?:,\s*[\w.\[\]|]+)*)\s*:\s+(.*)$`)
+)
+
+// formatSectionedDocstring formats a Google or NumPy style docstring.
+func formatSectionedDocstring(lines []string) string {
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		title := strings.TrimSpace(line)
+		kind, numpy := "", false
+		if k, ok := docSectionKinds[strings.ToLower(title)]; ok && i+1 < len(lines) && numpyUnderline.MatchString(lines[i+1]) {
+			kind, numpy = k, true
+		} else if strings.HasSuffix(title, ":") && indentation(line) == 0 {
+			title = strings.TrimSuffix(title, ":")
+			kind = docSectionKinds[strings.ToLower(title)]
+		}
+		if kind == "" {
+			out = append(out, line)
+			continue
+		}
+
+		// Gather the body of the section: for NumPy style up to the next section, for Google style
+		// the lines indented below the title.
+		var body []string
+		if numpy {
+			i += 2
+			for ; i < len(lines); i++ {
+				next := strings.TrimSpace(lines[i])
+				if _, ok := docSectionKinds[strings.ToLower(next)]; ok && i+1 < len(lines) && numpyUnderline.MatchString(lines[i+1]) {
+					break
+				}
+				body = append(body, lines[i])
+			}
+		} else {
+			for i++; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) != "" && indentation(lines[i]) == 0 {
+					break
+				}
+				body = append(body, lines[i])
+			}
+		}
+		i--
+		body = dedentLines(body)
+
+		switch kind {
+		case "params":
+			out = append(out, fieldsTable(title, docFields(body, numpy), "Name", "Type", "Description"))
+		case "returns":
+			var fields []docField
+			if numpy {
+				for _, field := range docFields(body, true) {
+					if field.typ == "" {
+						field.name, field.typ = "", field.name // only a type, e.g. "int"
+					}
+					fields = append(fields, field)
+				}
+			} else if len(body) > 0 {
+				desc := strings.Join(body, " ")
+				if m := googleReturn.FindStringSubmatch(body[0]); m != nil {
+					fields = append(fields, docField{typ: m[1], desc: strings.Join(append([]string{m[2]}, body[1:]...), " ")})
+				} else {
+					fields = append(fields, docField{desc: desc})
+				}
+			}
+			out = append(out, fieldsTable(title, fields, "Name", "Type", "Description"))
+		case "raises":
+			var fields []docField
+			for _, field := range docFields(body, numpy) {
+				fields = append(fields, docField{typ: field.name, desc: field.desc}) // e.g. "ValueError: If ..."
+			}
+			out = append(out, fieldsTable(title, fields, "", "Exception", "Description"))
+		default:
+			out = append(out, "**"+title+"**", "")
+			if isDoctest(body) {
+				out = append(out, "```python")
+				out = append(out, body...)
+				out = append(out, "```")
+			} else {
+				out = append(out, body...)
+			}
+		}
+		out = append(out, "")
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// dedentLines removes the indentation common to all lines, and leading and trailing blank lines.
+func dedentLines(lines []string) []string {
+	return dedentDocstring("\n" + strings.Join(lines, "\n"))
+}
+
+// isDoctest reports whether lines contain interactive Python examples, e.g. ">>> foo()"
+func isDoctest(lines []string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), ">>>") {
+			return !strings.HasPrefix(strings.TrimSpace(lines[0]), "```")
+		}
+	}
+	return false
+}
+
+// docFields parses the fields of a Google or NumPy style section, e.g. "x (int): The value." or
+// "x : int" followed by an indented description.
+func docFields(body []string, numpy bool) []docField {
+	var fields []docField
+	for _, line := range body {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if indentation(line) > 0 && len(fields) > 0 {
+			// Continuation of the description of the previous field.
+			field := &fields[len(fields)-1]
+			field.desc = strings.TrimSpace(field.desc + " " + strings.TrimSpace(line))
+			continue
+		}
+		var field docField
+		if m := googleTypedField.FindStringSubmatch(line); m != nil && !numpy {
+			field = docField{name: m[1], typ: m[2], desc: m[3]}
+		} else if m := numpyField.FindStringSubmatch(line); m != nil && numpy {
+			field = docField{name: m[1], typ: m[2]}
+		} else if m := googleField.FindStringSubmatch(line); m != nil && !numpy {
+			field = docField{name: m[1], desc: m[2]}
+		} else {
+			field = docField{name: strings.TrimSpace(line)}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// e.g. ":param int x: The value." or ":returns: The value."
+var restField = regexp.MustCompile(`^:(param|parameter|arg|argument|key|keyword|type|returns?|rtype|yields?|ytype|raises?|except|exception|var|ivar|cvar|vartype)\b([^:]*):\s*(.*)$`)
+
+// formatRestDocstring formats a docstring using reStructuredText fields, e.g. ":param x: The value."
+func formatRestDocstring(lines []string) string {
+	var (
+		prose          []string
+		params, raises []docField
+		returns        docField
+		types          = map[string]string{}
+		last           *string // description of the last field, for continuation lines
+	)
+	for _, line := range lines {
+		m := restField.FindStringSubmatch(line)
+		if m == nil {
+			if last != nil && (indentation(line) > 0 || strings.TrimSpace(line) == "") {
+				*last = strings.TrimSpace(*last + " " + strings.TrimSpace(line))
+				continue
+			}
+			last = nil
+			prose = append(prose, line)
+			continue
+		}
+		kind, arg, desc := m[1], strings.Fields(m[2]), m[3]
+		switch kind {
+		case "param", "parameter", "arg", "argument", "key", "keyword", "var", "ivar", "cvar":
+			field := docField{desc: desc}
+			if len(arg) > 0 {
+				field.name = arg[len(arg)-1]
+				field.typ = strings.Join(arg[:len(arg)-1], " ") // e.g. ":param int x:"
+			}
+			params = append(params, field)
+			last = &params[len(params)-1].desc
+		case "type", "vartype":
+			if len(arg) > 0 {
+				types[arg[0]] = desc
+			}
+			last = nil
+		case "returns", "return", "yields", "yield":
+			returns.desc = desc
+			last = &returns.desc
+		case "rtype", "ytype":
+			returns.typ = desc
+			last = nil
+		default: // raises, raise, except, exception
+			raises = append(raises, docField{typ: strings.Join(arg, " "), desc: desc})
+			last = &raises[len(raises)-1].desc
+		}
+	}
+	for i, param := range params {
+		if typ, ok := types[param.name]; ok && param.typ == "" {
+			params[i].typ = typ
+		}
+	}
+
+	out := []string{strings.TrimSpace(strings.Join(prose, "\n"))}
+	if len(params) > 0 {
+		out = append(out, fieldsTable("Parameters", params, "Name", "Type", "Description"))
+	}
+	if returns != (docField{}) {
+		out = append(out, fieldsTable("Returns", []docField{returns}, "Name", "Type", "Description"))
+	}
+	if len(raises) > 0 {
+		out = append(out, fieldsTable("Raises", raises, "", "Exception", "Description"))
+	}
+	return strings.TrimSpace(strings.Join(out, "\n\n"))
+}
+
+// fieldsTable formats fields as a Markdown table with the given title, see indexer.Table. The name
+// column is omitted if nameHeading is empty.
+func fieldsTable(title string, fields []docField, nameHeading, typeHeading, descHeading string) string {
+	headings := []string{typeHeading, descHeading}
+	if nameHeading != "" {
+		headings = append([]string{nameHeading}, headings...)
+	}
+	var rows [][]string
+	for _, field := range fields {
+		row := []string{indexer.Code(field.typ), field.desc}
+		if nameHeading != "" {
+			row = append([]string{indexer.Code(field.name)}, row...)
+		}
+		rows = append(rows, row)
+	}
+	return indexer.Table(title, headings, rows)
+}
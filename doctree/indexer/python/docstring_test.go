@@ -0,0 +1,65 @@
+package python
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func Test_dedentDocstring(t *testing.T) {
+	tests := []struct {
+		name      string
+		docstring string
+		want      []string
+	}{
+		{
+			name:      "first-line",
+			docstring: "Adds.\n\n    Example:\n        >>> add(1, 2)\n    ",
+			want:      []string{"Adds.", "", "Example:", "    >>> add(1, 2)"},
+		},
+		{
+			name:      "blank-first-line",
+			docstring: "\n    Adds.\n\n\tIndented with a tab.\n\n      More indented.\n    ",
+			want:      []string{"Adds.", "", "    Indented with a tab.", "", "  More indented."},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			autogold.Want(tc.name, tc.want).Equal(t, dedentDocstring(tc.docstring))
+		})
+	}
+}
+
+func Test_formatDocstring(t *testing.T) {
+	tests := []struct {
+		name      string
+		docstring string
+		want      string
+	}{
+		{
+			name:      "google",
+			docstring: "Adds two numbers.\n\n    Args:\n        a (int): The first.\n        b: The second, which is\n            long.\n\n    Returns:\n        int: The sum.\n\n    Raises:\n        ValueError: If negative.\n    ",
+			want:      "Adds two numbers.\n\n**Args**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `a` | `int` | The first. |\n| `b` |  | The second, which is long. |\n\n**Returns**\n\n| Type | Description |\n| --- | --- |\n| `int` | The sum. |\n\n**Raises**\n\n| Exception | Description |\n| --- | --- |\n| `ValueError` | If negative. |",
+		},
+		{
+			name:      "numpy",
+			docstring: "Adds two numbers.\n\n    Parameters\n    ----------\n    a : int\n        The first.\n    b : int, optional\n\n    Returns\n    -------\n    int\n        The sum.\n    ",
+			want:      "Adds two numbers.\n\n**Parameters**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `a` | `int` | The first. |\n| `b` | `int, optional` |  |\n\n**Returns**\n\n| Type | Description |\n| --- | --- |\n| `int` | The sum. |",
+		},
+		{
+			name:      "restructuredtext",
+			docstring: "Adds two numbers.\n\n    :param int a: The first.\n    :param b: The second,\n        continued.\n    :type b: int\n    :returns: The sum.\n    :rtype: int\n    :raises ValueError: If negative.\n    ",
+			want:      "Adds two numbers.\n\n**Parameters**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `a` | `int` | The first. |\n| `b` | `int` | The second, continued. |\n\n**Returns**\n\n| Type | Description |\n| --- | --- |\n| `int` | The sum. |\n\n**Raises**\n\n| Exception | Description |\n| --- | --- |\n| `ValueError` | If negative. |",
+		},
+		{
+			name:      "doctest",
+			docstring: "Adds.\n\n    Example:\n        >>> add(1, 2)\n        3\n    ",
+			want:      "Adds.\n\n**Example**\n\n```python\n>>> add(1, 2)\n3\n```",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			autogold.Want(tc.name, tc.want).Equal(t, formatDocstring(tc.docstring))
+		})
+	}
+}
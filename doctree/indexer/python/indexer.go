@@ -3,12 +3,15 @@ package python
 
 import (
 	"context"
-	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/pkg/errors"
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/python"
@@ -25,37 +28,30 @@ type pythonIndexer struct{}
 
 func (i *pythonIndexer) Name() schema.Language { return schema.LanguagePython }
 
-func (i *pythonIndexer) Extensions() []string { return []string{"py", "py3"} }
+func (i *pythonIndexer) Extensions() []string { return []string{"py", "py3", "pyi"} }
 
-func (i *pythonIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index, error) {
+func (i *pythonIndexer) IndexDir(ctx context.Context, dir string, opts indexer.Options) (*schema.Index, error) {
 	// Find Python sources
-	var sources []string
-	if err := fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err // error walking dir
-		}
-		if !d.IsDir() {
-			ext := filepath.Ext(path)
-			if ext == ".py" || ext == ".py3" {
-				sources = append(sources, path)
-			}
-		}
-		return nil
-	}); err != nil {
-		return nil, errors.Wrap(err, "WalkDir")
+	sources, err := indexer.Sources(dir, opts, ".py", ".py3", ".pyi")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sources")
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "Abs")
 	}
+	names := moduleNames(sources, filepath.Base(absDir))
 
+	dirFS := os.DirFS(dir)
 	files := 0
 	bytes := 0
-	mods := map[string]moduleInfo{}
-	functionsByMod := map[string][]schema.Section{}
-	classesByMod := map[string][]schema.Section{}
-
+	mods := map[string]*module{}
+	stubs := map[string]*pythonFile{}
 	for _, path := range sources {
-		if strings.Contains(path, "test_") || strings.Contains(path, "_test") || strings.Contains(path, "tests") {
+		if indexer.IsTestFile(path, testDirs, testFiles...) {
 			continue
 		}
-		dirFS := os.DirFS(dir)
 		content, err := fs.ReadFile(dirFS, path)
 		if err != nil {
 			return nil, errors.Wrap(err, "ReadFile")
@@ -64,152 +60,75 @@ func (i *pythonIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index
 		files += 1
 		bytes += len(content)
 
-		// Parse the file with tree-sitter.
-		parser := sitter.NewParser()
-		defer parser.Close()
-		parser.SetLanguage(python.GetLanguage())
-
-		tree, err := parser.ParseCtx(ctx, nil, content)
-		if err != nil {
-			return nil, errors.Wrap(err, "ParseCtx")
-		}
-		defer tree.Close()
-
-		// Inspect the root node.
-		n := tree.RootNode()
-
-		// Module clauses
-		var modName string
-		{
-			query, err := sitter.NewQuery([]byte(`
-			(
-				module
-				.
-				(comment)*
-				.
-				(expression_statement .
-					(string) @module_docs
-				)?
-			)
-			`), python.GetLanguage())
+		file := &pythonFile{}
+		if !opts.Cache.Get(path, content, file) {
+			file, err = indexFile(ctx, path, content, opts.IncludePrivate)
 			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
+				return nil, errors.Wrap(err, path)
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				// Extract module docs and Strip """ from both sides.
-				modDocs := joinCaptures(content, captures["module_docs"], "\n")
-				modDocs = sanitizeDocs(modDocs)
-				modName = strings.ReplaceAll(strings.TrimSuffix(path, ".py"), "/", ".")
-
-				mods[modName] = moduleInfo{path: path, docs: modDocs}
+			if err := opts.Cache.Put(path, content, file); err != nil {
+				return nil, errors.Wrap(err, "Put")
 			}
 		}
-
-		funcDefQuery := `
-		(
-		function_definition
-			name: (identifier) @func_name
-			parameters: (parameters) @func_params
-			return_type: (type)? @func_result
-			body: (block . (expression_statement (string) @func_docs)?)
-		)
-		`
-
-		// Function definitions
-		{
-			moduleFuncDefQuery := fmt.Sprintf("(module %s)", funcDefQuery)
-			modFunctions, err := getFunctions(n, content, moduleFuncDefQuery, []string{modName})
-			if err != nil {
-				return nil, err
-			}
-
-			functionsByMod[modName] = modFunctions
-		}
-
-		// Classes and their methods
-		{
-			// Find out all the classes
-			query, err := sitter.NewQuery([]byte(`
-			(class_definition
-				name: (identifier) @class_name
-				superclasses: (argument_list)? @superclasses
-				body: (block
-					(expression_statement (string) @class_docs)?
-				) @class_body
-			)
-			`), python.GetLanguage())
-			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
+		// Stubs (.pyi files) are merged into the module they describe, which is documented at the
+		// path of its implementation if there is one.
+		modName := names[path]
+		if isStubFile(path) {
+			stubs[modName] = file
+			if mod, ok := mods[modName]; ok {
+				file = mergeStub(mod.file, file)
+				path = mod.path
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
+		} else if stub, ok := stubs[modName]; ok {
+			file = mergeStub(file, stub)
+		}
+		mods[modName] = &module{
+			name:      modName,
+			path:      path,
+			isPackage: isPackageFile(path),
+			file:      file,
+			constants: file.Constants,
+			functions: file.Functions,
+			classes:   file.Classes,
+		}
+	}
+	followReexports(mods)
 
-			// Iterate over the classes
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				className := firstCaptureContentOr(content, captures["class_name"], "")
-				superClasses := firstCaptureContentOr(content, captures["superclasses"], "")
-				classDocs := joinCaptures(content, captures["class_docs"], "\n")
-				classDocs = sanitizeDocs(classDocs)
-
-				classLabel := schema.Markdown("class " + className + superClasses)
-				classes := classesByMod[modName]
-
-				// Extract class methods:
-				var classMethods []schema.Section
-				classBodyNodes := captures["class_body"]
-				if len(classBodyNodes) > 0 {
-					classMethods, err = getFunctions(
-						classBodyNodes[0], content, funcDefQuery,
-						[]string{modName, ".", className},
-					)
-					if err != nil {
-						return nil, err
-					}
-				}
+	libraries, err := findLibraries(dir, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "findLibraries")
+	}
 
-				classes = append(classes, schema.Section{
-					ID:         className,
-					ShortLabel: className,
-					Label:      classLabel,
-					Detail:     schema.Markdown(classDocs),
-					SearchKey:  []string{modName, ".", className},
-					Children:   classMethods,
-				})
-				classesByMod[modName] = classes
-			}
+	var modNames []string
+	for modName := range mods {
+		if isPrivateModule(modName) && !opts.IncludePrivate {
+			continue
 		}
+		modNames = append(modNames, modName)
 	}
+	sort.Strings(modNames)
 
 	var pages []schema.Page
-	for modName, moduleInfo := range mods {
+	for _, modName := range modNames {
+		mod := mods[modName]
+		modSearchKey := moduleSearchKey(modName)
+
+		constantsSection := schema.Section{
+			ID:         "const",
+			ShortLabel: "const",
+			Label:      "Constants",
+			SearchKey:  []string{},
+			Category:   true,
+			Children:   withModule(modSearchKey, mod.constants),
+		}
+
 		functionsSection := schema.Section{
 			ID:         "func",
 			ShortLabel: "func",
 			Label:      "Functions",
 			SearchKey:  []string{},
 			Category:   true,
-			Children:   functionsByMod[modName],
+			Children:   withModule(modSearchKey, mod.functions),
 		}
 
 		classesSection := schema.Section{
@@ -218,15 +137,31 @@ func (i *pythonIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index
 			Label:      "Classes",
 			SearchKey:  []string{},
 			Category:   true,
-			Children:   classesByMod[modName],
+			Children:   withModule(modSearchKey, mod.classes),
+		}
+
+		// Link to the direct submodules and subpackages of a package.
+		var subpages []schema.Page
+		for _, subName := range modNames {
+			if mod.isPackage && parentModule(subName) == modName && subName != modName {
+				subpages = append(subpages, schema.Page{
+					Path:      subName,
+					Title:     moduleTitle(mods[subName]),
+					SearchKey: []string{},
+					Location:  &schema.Location{Path: mods[subName].path},
+					Sections:  []schema.Section{},
+				})
+			}
 		}
 
 		pages = append(pages, schema.Page{
-			Path:      moduleInfo.path,
-			Title:     "Module " + modName,
-			Detail:    schema.Markdown(moduleInfo.docs),
-			SearchKey: []string{modName},
-			Sections:  []schema.Section{functionsSection, classesSection},
+			Path:      modName,
+			Title:     moduleTitle(mod),
+			Detail:    schema.Markdown(mod.file.ModDocs),
+			SearchKey: modSearchKey,
+			Location:  &schema.Location{Path: mod.path},
+			Sections:  []schema.Section{constantsSection, functionsSection, classesSection},
+			Subpages:  subpages,
 		})
 	}
 
@@ -235,76 +170,613 @@ func (i *pythonIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index
 		Language:      schema.LanguagePython,
 		NumFiles:      files,
 		NumBytes:      bytes,
-		Libraries: []schema.Library{
-			{
-				Name:        "TODO",
-				ID:          "TODO",
-				Version:     "TODO",
-				VersionType: "TODO",
-				Pages:       pages,
-			},
-		},
+		Libraries:     indexer.GroupLibraries(libraries, pages, indexer.DefaultLibrary(dir)),
 	}, nil
 }
 
-func getFunctions(node *sitter.Node, content []byte, q string, searchKeyPrefix []string) ([]schema.Section, error) {
-	var functions []schema.Section
-	query, err := sitter.NewQuery([]byte(q), python.GetLanguage())
+func moduleTitle(mod *module) string {
+	if mod.isPackage {
+		return "Package " + mod.name
+	}
+	return "Module " + mod.name
+}
+
+// moduleSearchKey returns the search key of a module, e.g. ["foo", ".", "bar"] for foo.bar
+func moduleSearchKey(modName string) []string {
+	var key []string
+	for i, part := range strings.Split(modName, ".") {
+		if i > 0 {
+			key = append(key, ".")
+		}
+		key = append(key, part)
+	}
+	return key
+}
+
+// withModule prefixes the search keys of sections (which are relative to their module) and their
+// children with the search key of the module.
+func withModule(modSearchKey []string, sections []schema.Section) []schema.Section {
+	for i := range sections {
+		if len(sections[i].SearchKey) > 0 {
+			key := append(append([]string{}, modSearchKey...), ".")
+			sections[i].SearchKey = append(key, sections[i].SearchKey...)
+		}
+		sections[i].Children = withModule(modSearchKey, sections[i].Children)
+	}
+	return sections
+}
+
+// findLibraries finds Python distributions in dir, i.e. directories containing a pyproject.toml,
+// setup.cfg or setup.py file. Where a directory has several, the first to name the distribution in
+// that order is used.
+func findLibraries(dir string, opts indexer.Options) ([]indexer.LibraryRoot, error) {
+	files, err := indexer.FindFiles(dir, opts, "pyproject.toml", "setup.cfg", "setup.py")
 	if err != nil {
-		return nil, errors.Wrap(err, "NewQuery")
+		return nil, errors.Wrap(err, "FindFiles")
+	}
+	byDir := map[string]map[string][]byte{}
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadFile")
+		}
+		libDir := path.Dir(file)
+		if byDir[libDir] == nil {
+			byDir[libDir] = map[string][]byte{}
+		}
+		byDir[libDir][path.Base(file)] = content
 	}
-	defer query.Close()
 
-	cursor := sitter.NewQueryCursor()
-	defer cursor.Close()
-	cursor.Exec(query, node)
+	var libraries []indexer.LibraryRoot
+	for libDir, contents := range byDir {
+		var name, version string
+		if content, ok := contents["pyproject.toml"]; ok {
+			var pyproject struct {
+				Project struct {
+					Name    string `toml:"name"`
+					Version string `toml:"version"`
+				} `toml:"project"`
+				Tool struct {
+					Poetry struct {
+						Name    string `toml:"name"`
+						Version string `toml:"version"`
+					} `toml:"poetry"`
+				} `toml:"tool"`
+			}
+			if err := toml.Unmarshal(content, &pyproject); err != nil {
+				return nil, errors.Wrap(err, path.Join(libDir, "pyproject.toml"))
+			}
+			name, version = pyproject.Project.Name, pyproject.Project.Version
+			if name == "" {
+				name, version = pyproject.Tool.Poetry.Name, pyproject.Tool.Poetry.Version
+			}
+		}
+		if content, ok := contents["setup.cfg"]; ok && name == "" {
+			name, version = parseSetupCfg(content)
+		}
+		if content, ok := contents["setup.py"]; ok && name == "" {
+			name, version = parseSetupPy(content)
+		}
+		if name == "" {
+			continue
+		}
+		library := schema.Library{Name: name, ID: name, Version: version}
+		if version != "" {
+			library.VersionType = "pep440"
+		}
+		libraries = append(libraries, indexer.LibraryRoot{Dir: libDir, Library: library})
+	}
+	return libraries, nil
+}
 
-	for {
-		match, ok := cursor.NextMatch()
+// parseSetupCfg returns the distribution name and version from the [metadata] section of a
+// setup.cfg file.
+func parseSetupCfg(content []byte) (name, version string) {
+	section := ""
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != "metadata" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
 		if !ok {
-			break
+			continue
 		}
-		captures := getCaptures(query, match)
-		funcDocs := joinCaptures(content, captures["func_docs"], "\n")
-		funcDocs = sanitizeDocs(funcDocs)
-		funcName := firstCaptureContentOr(content, captures["func_name"], "")
-		funcParams := firstCaptureContentOr(content, captures["func_params"], "")
-		funcResult := firstCaptureContentOr(content, captures["func_result"], "")
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "attr:") || strings.HasPrefix(value, "file:") {
+			continue // computed when building the distribution
+		}
+		switch strings.TrimSpace(key) {
+		case "name":
+			name = value
+		case "version":
+			version = value
+		}
+	}
+	return name, version
+}
 
-		if len(funcName) > 0 && funcName[0] == '_' && funcName[len(funcName)-1] != '_' {
-			continue // unexported (private function)
+var (
+	setupPyName    = regexp.MustCompile(`\bname\s*=\s*["']([^"']+)["']`)
+	setupPyVersion = regexp.MustCompile(`\bversion\s*=\s*["']([^"']+)["']`)
+)
+
+// parseSetupPy returns the distribution name and version passed as string literals to setup() in a
+// setup.py file. Values computed at runtime are not supported.
+func parseSetupPy(content []byte) (name, version string) {
+	if m := setupPyName.FindSubmatch(content); m != nil {
+		name = string(m[1])
+	}
+	if m := setupPyVersion.FindSubmatch(content); m != nil {
+		version = string(m[1])
+	}
+	return name, version
+}
+
+// indexFile indexes a single Python source file. Private functions and classes (whose names start
+// with an underscore, or which are not listed in the module's __all__) are only included if
+// includePrivate is true.
+//
+// Search keys are relative to the module, as the module name depends on the package the file is in.
+func indexFile(ctx context.Context, path string, content []byte, includePrivate bool) (*pythonFile, error) {
+	file := &pythonFile{}
+
+	// Parse the file with tree-sitter.
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(python.GetLanguage())
+
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseCtx")
+	}
+	defer tree.Close()
+
+	// Inspect the root node.
+	n := tree.RootNode()
+
+	// Module clauses
+	{
+		query, err := sitter.NewQuery([]byte(`
+		(
+			module
+			.
+			(comment)*
+			.
+			(expression_statement .
+				(string) @module_docs
+			)?
+		)
+		`), python.GetLanguage())
+		if err != nil {
+			return nil, errors.Wrap(err, "NewQuery")
+		}
+		defer query.Close()
+
+		cursor := sitter.NewQueryCursor()
+		defer cursor.Close()
+		cursor.Exec(query, n)
+
+		for {
+			match, ok := cursor.NextMatch()
+			if !ok {
+				break
+			}
+			captures := indexer.GetCaptures(query, match)
+
+			// Extract module docs and Strip """ from both sides.
+			modDocs := joinCaptures(content, captures["module_docs"], "\n")
+			modDocs = sanitizeDocs(modDocs)
+
+			file.ModDocs = modDocs
+		}
+	}
+
+	// The public surface of the module, and names it imports from other modules.
+	if err := moduleExports(n, content, file); err != nil {
+		return nil, errors.Wrap(err, "moduleExports")
+	}
+	public := func(name string) bool {
+		if file.HasAll {
+			return containsString(file.All, name)
 		}
+		return !isPrivate(name)
+	}
+
+	// Module-level constants, functions and classes
+	var functionDefs []definition
+	for _, def := range blockDefinitions(content, n) {
+		if def.node.Type() == "class_definition" {
+			class, ok := classSection(content, path, def, nil, public, includePrivate)
+			if ok {
+				file.Classes = append(file.Classes, class)
+			}
+			continue
+		}
+		functionDefs = append(functionDefs, def)
+	}
+	file.Functions = functions(content, path, functionDefs, nil, public, includePrivate)
+	file.Constants = constants(content, path, n, file, public, includePrivate)
+	return file, nil
+}
+
+// definition is a function or class definition, along with the decorators applied to it.
+type definition struct {
+	node       *sitter.Node
+	decorators []string // e.g. "@dataclass(frozen=True)"
+}
 
-		funcLabel := schema.Markdown("def " + funcName + funcParams)
-		if funcResult != "" {
-			funcLabel = funcLabel + schema.Markdown(" -> "+funcResult)
+// blockDefinitions returns the function and class definitions directly within a module or block.
+func blockDefinitions(content []byte, block *sitter.Node) []definition {
+	var defs []definition
+	for i := 0; i < int(block.NamedChildCount()); i++ {
+		child := block.NamedChild(i)
+		var decorators []string
+		if child.Type() == "decorated_definition" {
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if decorator := child.NamedChild(j); decorator.Type() == "decorator" {
+					decorators = append(decorators, strings.Join(strings.Fields(decorator.Content(content)), " "))
+				}
+			}
+			child = child.ChildByFieldName("definition")
+		}
+		if child != nil && (child.Type() == "function_definition" || child.Type() == "class_definition") {
+			defs = append(defs, definition{node: child, decorators: decorators})
 		}
-		functions = append(functions, schema.Section{
-			ID:         funcName,
+	}
+	return defs
+}
+
+// hasDecorator reports whether any of the decorators is one of the given names, ignoring module
+// qualifiers and arguments, e.g. "@functools.cache" and "@cache(maxsize=None)" both have "cache".
+func hasDecorator(decorators []string, names ...string) bool {
+	for _, decorator := range decorators {
+		name := strings.TrimPrefix(decorator, "@")
+		if i := strings.Index(name, "("); i >= 0 {
+			name = name[:i]
+		}
+		name = name[strings.LastIndex(name, ".")+1:]
+		for _, want := range names {
+			if name == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isAccessor reports whether the decorators make a function the setter or deleter of a property,
+// e.g. "@name.setter", which is documented by the property itself.
+func isAccessor(decorators []string) bool {
+	for _, decorator := range decorators {
+		if strings.HasSuffix(decorator, ".setter") || strings.HasSuffix(decorator, ".deleter") {
+			return true
+		}
+	}
+	return false
+}
+
+// blockDocs returns the docstring of a module, class or function body, i.e. a string literal as
+// its first statement.
+func blockDocs(content []byte, block *sitter.Node) string {
+	if block == nil {
+		return ""
+	}
+	for i := 0; i < int(block.NamedChildCount()); i++ {
+		stmt := block.NamedChild(i)
+		if stmt.Type() == "comment" {
+			continue
+		}
+		if stmt.Type() == "expression_statement" && stmt.NamedChildCount() > 0 && stmt.NamedChild(0).Type() == "string" {
+			return sanitizeDocs(stmt.NamedChild(0).Content(content))
+		}
+		break
+	}
+	return ""
+}
+
+// classSection returns the section documenting a class definition, with its attributes,
+// properties, methods and nested classes as children. searchKeyPrefix is the search key of the
+// class the definition is nested in, if any.
+func classSection(content []byte, path string, def definition, searchKeyPrefix []string, public func(name string) bool, includePrivate bool) (schema.Section, bool) {
+	className := def.node.ChildByFieldName("name").Content(content)
+	if !public(className) && !includePrivate {
+		return schema.Section{}, false
+	}
+	superClasses := ""
+	if superClassesNode := def.node.ChildByFieldName("superclasses"); superClassesNode != nil {
+		superClasses = superClassesNode.Content(content)
+	}
+	body := def.node.ChildByFieldName("body")
+	key := indexer.SearchKey(searchKeyPrefix, className)
+
+	// Members of the class are private by convention only, __all__ does not apply to them.
+	memberPublic := func(name string) bool { return !isPrivate(name) }
+
+	var propertyDefs, methodDefs []definition
+	var nestedClasses []schema.Section
+	for _, member := range blockDefinitions(content, body) {
+		switch {
+		case member.node.Type() == "class_definition":
+			nested, ok := classSection(content, path, member, key, memberPublic, includePrivate)
+			if ok {
+				nestedClasses = append(nestedClasses, nested)
+			}
+		case isAccessor(member.decorators):
+			continue
+		case hasDecorator(member.decorators, "property", "cached_property", "abstractproperty"):
+			propertyDefs = append(propertyDefs, member)
+		default:
+			methodDefs = append(methodDefs, member)
+		}
+	}
+
+	var children []schema.Section
+	for _, stmt := range blockStatements(body) {
+		attr, _, ok := assignmentSection(content, path, stmt, key)
+		if ok && (memberPublic(attr.ShortLabel) || includePrivate) {
+			children = append(children, attr)
+		}
+	}
+	children = append(children, functions(content, path, propertyDefs, key, memberPublic, includePrivate)...)
+	children = append(children, functions(content, path, methodDefs, key, memberPublic, includePrivate)...)
+	children = append(children, nestedClasses...)
+
+	return schema.Section{
+		ID:         strings.Join(key, ""),
+		ShortLabel: className,
+		Visibility: visibility(public(className)),
+		Label:      schema.Markdown(decorated(def.decorators, "class "+className+superClasses)),
+		Detail:     schema.Markdown(blockDocs(content, body)),
+		SearchKey:  key,
+		Location:   indexer.NodeLocation(path, def.node),
+		Children:   children,
+	}, true
+}
+
+// functions returns the sections documenting function definitions. Overloads of a function (those
+// decorated with @overload) are documented by a single section, listing their signatures.
+// searchKeyPrefix is the search key of the class the definitions are methods of, if any.
+func functions(content []byte, path string, defs []definition, searchKeyPrefix []string, public func(name string) bool, includePrivate bool) []schema.Section {
+	var sections []schema.Section
+	byName := map[string]int{}
+	overloads := map[string][]string{}
+	for _, def := range defs {
+		funcName := def.node.ChildByFieldName("name").Content(content)
+		if !public(funcName) && !includePrivate {
+			continue // unexported (private function)
+		}
+		funcParams := def.node.ChildByFieldName("parameters").Content(content)
+		funcLabel := "def " + funcName + funcParams
+		if def.node.ChildCount() > 0 && def.node.Child(0).Type() == "async" {
+			funcLabel = "async " + funcLabel
+		}
+		if result := def.node.ChildByFieldName("return_type"); result != nil {
+			funcLabel += " -> " + result.Content(content)
+		}
+		key := indexer.SearchKey(searchKeyPrefix, funcName)
+		section := schema.Section{
+			ID:         strings.Join(key, ""),
 			ShortLabel: funcName,
-			Label:      funcLabel,
-			Detail:     schema.Markdown(funcDocs),
-			SearchKey:  append(searchKeyPrefix, ".", funcName),
-		})
+			Visibility: visibility(public(funcName)),
+			Label:      schema.Markdown(decorated(def.decorators, funcLabel)),
+			Detail:     schema.Markdown(blockDocs(content, def.node.ChildByFieldName("body"))),
+			SearchKey:  key,
+			Location:   indexer.NodeLocation(path, def.node),
+		}
+
+		isOverload := hasDecorator(def.decorators, "overload")
+		if isOverload {
+			overloads[funcName] = append(overloads[funcName], funcLabel)
+		}
+		i, seen := byName[funcName]
+		if !seen {
+			byName[funcName] = len(sections)
+			sections = append(sections, section)
+			continue
+		}
+		// A redefinition, e.g. the implementation following the overloads of a function, replaces
+		// the earlier definition. Docs are often only on one of them.
+		if section.Detail == "" {
+			section.Detail = sections[i].Detail
+		}
+		if isOverload {
+			sections[i].Detail = section.Detail
+		} else {
+			sections[i] = section
+		}
+	}
+	for name, signatures := range overloads {
+		i := byName[name]
+		code := "```python\n" + strings.Join(signatures, "\n") + "\n```\n\n"
+		sections[i].Detail = schema.Markdown("Overloads:\n\n"+code) + sections[i].Detail
 	}
+	return sections
+}
 
-	return functions, nil
+// decorated prefixes the label of a definition with its decorators, e.g. "@staticmethod def f()".
+func decorated(decorators []string, label string) string {
+	if len(decorators) == 0 {
+		return label
+	}
+	return strings.Join(decorators, " ") + " " + label
 }
 
-func sanitizeDocs(s string) string {
-	return strings.TrimSuffix(strings.TrimPrefix(s, "\"\"\""), "\"\"\"")
+// blockStatements returns the statements directly within a module or block.
+func blockStatements(block *sitter.Node) []*sitter.Node {
+	var stmts []*sitter.Node
+	if block == nil {
+		return nil
+	}
+	for i := 0; i < int(block.NamedChildCount()); i++ {
+		stmts = append(stmts, block.NamedChild(i))
+	}
+	return stmts
 }
 
-type moduleInfo struct {
-	path string
-	docs string
+// maxValueLabel is the length beyond which values are omitted from the labels of assignments, and
+// shown in their detail instead.
+const maxValueLabel = 60
+
+// assignmentSection returns the section documenting a statement assigning to a single name, e.g.
+// "x: int = 1", or a type alias statement. Reports whether the statement had a type annotation.
+//
+// Docs are taken from a string literal following the statement, or "#:" comments preceding it.
+func assignmentSection(content []byte, path string, stmt *sitter.Node, searchKeyPrefix []string) (section schema.Section, annotated, ok bool) {
+	var name, label string
+	switch {
+	case stmt.Type() == "type_alias_statement":
+		name = stmt.ChildByFieldName("left").Content(content)
+		if i := strings.Index(name, "["); i >= 0 {
+			name = name[:i] // generic type alias, e.g. type Pair[T] = tuple[T, T]
+		}
+		label, annotated = stmt.Content(content), true
+	case stmt.Type() == "expression_statement" && stmt.NamedChildCount() > 0 && stmt.NamedChild(0).Type() == "assignment":
+		assignment := stmt.NamedChild(0)
+		left := assignment.ChildByFieldName("left")
+		if left == nil || left.Type() != "identifier" {
+			return schema.Section{}, false, false // e.g. a, b = 1, 2 or self.x = 1
+		}
+		name, label = left.Content(content), left.Content(content)
+		if typ := assignment.ChildByFieldName("type"); typ != nil {
+			label += ": " + typ.Content(content)
+			annotated = true
+		}
+		if right := assignment.ChildByFieldName("right"); right != nil {
+			label += " = " + right.Content(content)
+		}
+	default:
+		return schema.Section{}, false, false
+	}
+
+	var docs string
+	if next := stmt.NextNamedSibling(); next != nil && next.Type() == "expression_statement" &&
+		next.NamedChildCount() > 0 && next.NamedChild(0).Type() == "string" {
+		docs = sanitizeDocs(next.NamedChild(0).Content(content))
+	} else {
+		var comments []string
+		for prev := stmt.PrevNamedSibling(); prev != nil && prev.Type() == "comment"; prev = prev.PrevNamedSibling() {
+			comment := prev.Content(content)
+			if !strings.HasPrefix(comment, "#:") {
+				break
+			}
+			comments = append([]string{strings.TrimSpace(strings.TrimPrefix(comment, "#:"))}, comments...)
+		}
+		docs = strings.Join(comments, "\n")
+	}
+
+	if strings.Contains(label, "\n") || len(label) > len(name)+maxValueLabel {
+		// Show long values in full in the detail instead.
+		docs = "```python\n" + stmt.Content(content) + "\n```\n\n" + docs
+		label = strings.SplitN(label, "\n", 2)[0]
+		if len(label) > len(name)+maxValueLabel {
+			label = label[:len(name)+maxValueLabel]
+		}
+		label += " ..."
+	}
+
+	key := indexer.SearchKey(searchKeyPrefix, name)
+	return schema.Section{
+		ID:         strings.Join(key, ""),
+		ShortLabel: name,
+		Visibility: visibility(!isPrivate(name)),
+		Label:      schema.Markdown(label),
+		Detail:     schema.Markdown(docs),
+		SearchKey:  key,
+		Location:   indexer.NodeLocation(path, stmt),
+	}, annotated, true
 }
 
-func firstCaptureContentOr(content []byte, captures []*sitter.Node, defaultValue string) string {
-	if len(captures) > 0 {
-		return captures[0].Content(content)
+// constants returns the sections documenting module-level constants and type aliases. As Python
+// has no constants, these are assignments to names in __all__ or in UPPER_CASE, or which have a
+// type annotation or docs.
+func constants(content []byte, path string, module *sitter.Node, file *pythonFile, public func(name string) bool, includePrivate bool) []schema.Section {
+	var sections []schema.Section
+	seen := map[string]bool{}
+	for _, stmt := range blockStatements(module) {
+		section, annotated, ok := assignmentSection(content, path, stmt, nil)
+		name := section.ShortLabel
+		if !ok || seen[name] || (strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__")) {
+			continue // e.g. __all__ or __version__, or a name reassigned later
+		}
+		if !public(name) && !includePrivate {
+			continue
+		}
+		if !annotated && section.Detail == "" && !isConstantName(name) && !(file.HasAll && containsString(file.All, name)) {
+			continue // e.g. logger = logging.getLogger(__name__)
+		}
+		seen[name] = true
+		section.Visibility = visibility(public(name))
+		sections = append(sections, section)
 	}
-	return defaultValue
+	return sections
+}
+
+// isConstantName reports whether a name is in UPPER_CASE, which by convention denotes a constant.
+func isConstantName(name string) bool {
+	return strings.ToUpper(name) == name && strings.ToLower(name) != name
+}
+
+// isPrivate reports whether a name is private by convention, i.e. starts with an underscore but is
+// not a "dunder" name such as __init__.
+func isPrivate(name string) bool {
+	return len(name) > 0 && name[0] == '_' && name[len(name)-1] != '_'
+}
+
+func visibility(public bool) schema.Visibility {
+	if public {
+		return schema.VisibilityPublic
+	}
+	return schema.VisibilityPrivate
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeDocs converts a docstring literal, including its quotes, to Markdown.
+func sanitizeDocs(s string) string {
+	s = strings.TrimLeft(s, "rRuU") // string prefixes, e.g. r"""raw docstring"""
+	for _, quote := range []string{`"""`, `'''`, `"`, `'`} {
+		if len(s) >= 2*len(quote) && strings.HasPrefix(s, quote) && strings.HasSuffix(s, quote) {
+			s = s[len(quote) : len(s)-len(quote)]
+			break
+		}
+	}
+	return formatDocstring(s)
+}
+
+// pythonFile is the result of indexing a single Python source file, as stored in the
+// indexer.FileCache.
+type pythonFile struct {
+	ModDocs   string           `json:"modDocs"`
+	Constants []schema.Section `json:"constants"`
+	Functions []schema.Section `json:"functions"`
+	Classes   []schema.Section `json:"classes"`
+
+	// All is the list of public names declared by __all__, if HasAll.
+	All    []string `json:"all,omitempty"`
+	HasAll bool     `json:"hasAll,omitempty"`
+
+	// Imports are the names imported by module-level from-import statements.
+	Imports []pythonImport `json:"imports,omitempty"`
+}
+
+// pythonImport is a name imported by a from-import statement, e.g. "from ..foo import Bar as Baz"
+type pythonImport struct {
+	Level  int    `json:"level,omitempty"` // Number of leading dots, 0 for absolute imports.
+	Module string `json:"module,omitempty"`
+	Name   string `json:"name"` // "*" for wildcard imports.
+	Alias  string `json:"alias,omitempty"`
 }
 
 func joinCaptures(content []byte, captures []*sitter.Node, sep string) string {
@@ -315,11 +787,9 @@ func joinCaptures(content []byte, captures []*sitter.Node, sep string) string {
 	return strings.Join(v, sep)
 }
 
-func getCaptures(q *sitter.Query, m *sitter.QueryMatch) map[string][]*sitter.Node {
-	captures := map[string][]*sitter.Node{}
-	for _, c := range m.Captures {
-		cname := q.CaptureNameForId(c.Index)
-		captures[cname] = append(captures[cname], c.Node)
-	}
-	return captures
-}
+// testDirs and testFiles describe files likely to contain tests rather than library code, e.g.
+// test_foo.py, foo_test.py, conftest.py or tests/foo.py, which are not indexed.
+var (
+	testDirs  = []string{"test", "tests"}
+	testFiles = []string{"test_*", "*_test", "conftest"}
+)
@@ -0,0 +1,191 @@
+package python
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func Test_findLibraries(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"pyproject.toml":         "[project]\nname = \"root\"\nversion = \"1.0.0\"\n",
+		"setup.py":               "setup(name='ignored', version='0.0.1')\n",
+		"poetry/pyproject.toml":  "[tool.poetry]\nname = \"poetry-pkg\"\nversion = \"2.0\"\n",
+		"cfg/setup.cfg":          "[options]\nname = nope\n\n[metadata]\nname = cfg-pkg\nversion = attr: cfg_pkg.__version__\n",
+		"py/setup.py":            "from setuptools import setup\n\nsetup(\n    name=\"py-pkg\",\n    version='3.1',\n)\n",
+		"dynamic/pyproject.toml": "[build-system]\nrequires = [\"setuptools\"]\n",
+		"dynamic/setup.cfg":      "[metadata]\nname = dynamic-pkg\n",
+		"unnamed/setup.py":       "setup(**kwargs)\n",
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	libraries, err := findLibraries(dir, indexer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, library := range libraries {
+		got = append(got, library.Dir+": "+library.Library.Name+" "+library.Library.Version)
+	}
+	sort.Strings(got)
+	autogold.Want("libraries", []string{
+		".: root 1.0.0", "cfg: cfg-pkg ", "dynamic: dynamic-pkg ",
+		"poetry: poetry-pkg 2.0",
+		"py: py-pkg 3.1",
+	}).Equal(t, got)
+}
+
+func Test_indexFile_includePrivate(t *testing.T) {
+	content := []byte(`class Client:
+    def __init__(self):
+        pass
+
+    def send(self):
+        pass
+
+    def _retry(self):
+        pass
+
+class _State:
+    pass
+
+def connect():
+    pass
+
+def _helper():
+    pass
+`)
+	summarize := func(file *pythonFile) []string {
+		var got []string
+		for _, sections := range [][]schema.Section{file.Functions, file.Classes} {
+			for _, s := range sections {
+				got = append(got, s.ID+" "+string(s.Visibility))
+				for _, child := range s.Children {
+					got = append(got, "  "+child.ID+" "+string(child.Visibility))
+				}
+			}
+		}
+		return got
+	}
+
+	file, err := indexFile(context.Background(), "client.py", content, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	autogold.Want("default", []string{
+		"connect public", "Client public", "  Client.__init__ public",
+		"  Client.send public",
+	}).Equal(t, summarize(file))
+
+	file, err = indexFile(context.Background(), "client.py", content, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	autogold.Want("include private", []string{
+		"connect public", "_helper private", "Client public",
+		"  Client.__init__ public",
+		"  Client.send public",
+		"  Client._retry private",
+		"_State private",
+	}).Equal(t, summarize(file))
+}
+
+func Test_indexFile_definitions(t *testing.T) {
+	content := []byte(`"""Clients."""
+import functools
+import logging
+
+MAX_RETRIES = 3
+"""The maximum number of retries."""
+
+#: The default timeout, in seconds.
+timeout: float = 1.5
+
+logger = logging.getLogger(__name__)
+
+_SECRET = "x"
+
+@dataclass(frozen=True)
+class Client:
+    """A client."""
+
+    name: str
+    #: The number of retries.
+    retries = MAX_RETRIES
+    _cache = None
+
+    @property
+    def url(self) -> str:
+        """The URL."""
+        return ""
+
+    @url.setter
+    def url(self, value):
+        pass
+
+    @staticmethod
+    def create() -> "Client":
+        pass
+
+    @overload
+    def get(self, key: int) -> int: ...
+    @overload
+    def get(self, key: str) -> str: ...
+    def get(self, key):
+        """Gets a key."""
+
+    class Options:
+        verbose = False
+
+@functools.cache
+async def connect(url):
+    pass
+`)
+	file, err := indexFile(context.Background(), "client.py", content, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	var summarize func(sections []schema.Section, depth int)
+	summarize = func(sections []schema.Section, depth int) {
+		for _, s := range sections {
+			line := strings.Repeat("  ", depth) + s.ID + ": " + string(s.Label)
+			if s.Detail != "" {
+				line += " -- " + string(s.Detail)
+			}
+			got = append(got, line)
+			summarize(s.Children, depth+1)
+		}
+	}
+	summarize(file.Constants, 0)
+	summarize(file.Functions, 0)
+	summarize(file.Classes, 0)
+	autogold.Want("sections", []string{
+		"MAX_RETRIES: MAX_RETRIES = 3 -- The maximum number of retries.",
+		"timeout: timeout: float = 1.5 -- The default timeout, in seconds.",
+		"connect: @functools.cache async def connect(url)",
+		"Client: @dataclass(frozen=True) class Client -- A client.",
+		"  Client.name: name: str",
+		"  Client.retries: retries = MAX_RETRIES -- The number of retries.",
+		"  Client.url: @property def url(self) -> str -- The URL.",
+		`  Client.create: @staticmethod def create() -> "Client"`,
+		"  Client.get: def get(self, key) -- Overloads:\n\n```python\ndef get(self, key: int) -> int\ndef get(self, key: str) -> str\n```\n\nGets a key.",
+		"  Client.Options: class Options",
+		"    Client.Options.verbose: verbose = False",
+	}).Equal(t, got)
+}
@@ -0,0 +1,317 @@
+package python
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+// module is a Python module (or package, for an __init__.py file) being indexed.
+type module struct {
+	name      string // dotted name, e.g. "foo.bar"
+	path      string
+	isPackage bool
+	file      *pythonFile
+
+	// Constants, functions and classes documented on the module's page, including those
+	// re-exported from other modules.
+	constants, functions, classes []schema.Section
+}
+
+// moduleNames returns the dotted name each Python source file is imported by, keyed by path.
+//
+// A file's module name is relative to the nearest ancestor directory which is not a package (one
+// with no __init__.py file), so e.g. both foo/bar.py and src/foo/bar.py are "foo.bar" given a
+// foo/__init__.py file. __init__.py files are named after their package. If the indexed directory
+// is itself a package, rootName is used as its name.
+//
+// Stub files (e.g. foo.pyi) have the same name as the module they describe, and packages within a
+// stub-only distribution (e.g. foo-stubs/__init__.pyi, see PEP 561) are named without the suffix.
+//
+// Where several modules would have the same name, e.g. scripts/util.py and tools/util.py, their
+// names are derived from their paths instead.
+func moduleNames(sources []string, rootName string) map[string]string {
+	packageDirs := map[string]bool{}
+	for _, p := range sources {
+		p = path.Clean(filepath.ToSlash(p))
+		if isPackageFile(p) {
+			packageDirs[path.Dir(p)] = true
+		}
+	}
+
+	names := make(map[string]string, len(sources))
+	count := map[string]int{}
+	seen := map[string]bool{}
+	for _, source := range sources {
+		p := path.Clean(filepath.ToSlash(source))
+		dir, stem := path.Dir(p), strings.TrimSuffix(path.Base(p), path.Ext(p))
+
+		var parts []string
+		if !isPackageFile(p) {
+			parts = []string{stem}
+		}
+		for dir != "." && packageDirs[dir] {
+			parts = append([]string{strings.TrimSuffix(path.Base(dir), "-stubs")}, parts...)
+			dir = path.Dir(dir)
+		}
+		if dir == "." && packageDirs["."] {
+			parts = append([]string{rootName}, parts...)
+		}
+		name := strings.Join(parts, ".")
+		names[source] = name
+		// A module and its stub, e.g. foo/bar.py and foo/bar.pyi or foo-stubs/bar.pyi, count once.
+		if module := strings.ReplaceAll(strings.TrimSuffix(p, path.Ext(p)), "-stubs/", "/"); !seen[module] {
+			seen[module] = true
+			count[name]++
+		}
+	}
+	for source, name := range names {
+		if count[name] > 1 {
+			p := strings.TrimSuffix(filepath.ToSlash(source), path.Ext(source))
+			p = strings.TrimSuffix(p, "/__init__")
+			names[source] = strings.ReplaceAll(p, "/", ".")
+		}
+	}
+	return names
+}
+
+// isPackageFile reports whether the file at path is the __init__.py file of a package.
+func isPackageFile(p string) bool {
+	name := path.Base(filepath.ToSlash(p))
+	return name == "__init__.py" || name == "__init__.py3" || name == "__init__.pyi"
+}
+
+// isStubFile reports whether the file at path is a stub file, declaring the types of a module.
+func isStubFile(p string) bool {
+	return path.Ext(filepath.ToSlash(p)) == ".pyi"
+}
+
+// parentModule returns the name of the package containing the named module, or "" if it is a
+// top-level module.
+func parentModule(modName string) string {
+	if i := strings.LastIndex(modName, "."); i >= 0 {
+		return modName[:i]
+	}
+	return ""
+}
+
+// isPrivateModule reports whether the named module, or any package containing it, is private by
+// convention, e.g. foo._internal.bar
+func isPrivateModule(modName string) bool {
+	for _, part := range strings.Split(modName, ".") {
+		if isPrivate(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveImport returns the name of the module imported from by a from-import statement in the
+// given module, resolving relative imports such as "from ..foo import Bar". Returns "" if a
+// relative import goes beyond the top-level package.
+func resolveImport(mod *module, imp pythonImport) string {
+	if imp.Level == 0 {
+		return imp.Module
+	}
+	parts := strings.Split(mod.name, ".")
+	if !mod.isPackage {
+		parts = parts[:len(parts)-1] // the package containing the module
+	}
+	if imp.Level-1 > len(parts) {
+		return ""
+	}
+	parts = parts[:len(parts)-(imp.Level-1)]
+	if imp.Module != "" {
+		parts = append(parts, imp.Module)
+	}
+	return strings.Join(parts, ".")
+}
+
+// followReexports moves constants, functions and classes which are re-exported by another module, e.g. a
+// package's __init__.py containing "from ._client import Client", to the module re-exporting them,
+// so they are documented under the name users import them by (e.g. "pkg.Client".)
+//
+// A name is re-exported if it is listed in the importing module's __all__ or, if the module has no
+// __all__, it is imported relatively into a package. Modules are processed deepest first, so names
+// re-exported through several packages end up in the outermost one.
+func followReexports(mods map[string]*module) {
+	var names []string
+	for name := range mods {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := strings.Count(names[i], "."), strings.Count(names[j], ".")
+		if di != dj {
+			return di > dj
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		mod := mods[name]
+		reexported := func(imp pythonImport, as string) bool {
+			if mod.file.HasAll {
+				return containsString(mod.file.All, as)
+			}
+			return mod.isPackage && imp.Level > 0 && !isPrivate(as)
+		}
+		for _, imp := range mod.file.Imports {
+			target, ok := mods[resolveImport(mod, imp)]
+			if !ok || target == mod {
+				continue // not indexed, e.g. from the standard library, or a submodule
+			}
+			if imp.Name == "*" {
+				var sections []schema.Section
+				sections = append(sections, target.constants...)
+				sections = append(sections, target.functions...)
+				sections = append(sections, target.classes...)
+				for _, section := range sections {
+					if section.Visibility == schema.VisibilityPublic && reexported(imp, section.ID) {
+						moveSection(target, mod, section.ID, section.ID)
+					}
+				}
+				continue
+			}
+			as := imp.Name
+			if imp.Alias != "" {
+				as = imp.Alias
+			}
+			if reexported(imp, as) {
+				moveSection(target, mod, imp.Name, as)
+			}
+		}
+	}
+}
+
+// moveSection moves the constant, function or class with the given ID from one module to another, renaming
+// it to as.
+func moveSection(from, to *module, id, as string) {
+	move := func(fromSections, toSections *[]schema.Section) bool {
+		for i, section := range *fromSections {
+			if section.ID != id {
+				continue
+			}
+			*fromSections = append((*fromSections)[:i:i], (*fromSections)[i+1:]...)
+			*toSections = append(*toSections, renameSection(section, id, as))
+			return true
+		}
+		return false
+	}
+	if !move(&from.constants, &to.constants) && !move(&from.functions, &to.functions) {
+		move(&from.classes, &to.classes)
+	}
+}
+
+// renameSection renames a constant, function or class section (and the IDs and search keys of its
+// children, e.g. methods) for a re-export under an alias, e.g. "from .foo import Bar as Baz".
+func renameSection(section schema.Section, name, as string) schema.Section {
+	if name == as {
+		return section
+	}
+	section.ID, section.ShortLabel = as, as
+	section.Detail = schema.Markdown("Alias of `"+name+"`.\n\n") + section.Detail
+	var rename func(sections []schema.Section) []schema.Section
+	rename = func(sections []schema.Section) []schema.Section {
+		renamed := make([]schema.Section, 0, len(sections))
+		for _, s := range sections {
+			if strings.HasPrefix(s.ID, name+".") {
+				s.ID = as + strings.TrimPrefix(s.ID, name)
+			}
+			if len(s.SearchKey) > 0 && s.SearchKey[0] == name {
+				s.SearchKey = append([]string{as}, s.SearchKey[1:]...)
+			}
+			s.Children = rename(s.Children)
+			renamed = append(renamed, s)
+		}
+		return renamed
+	}
+	section.SearchKey = []string{as}
+	section.Children = rename(section.Children)
+	return section
+}
+
+// moduleExports records the names a module declares public in __all__, and the names it imports
+// from other modules with from-import statements, in file.
+func moduleExports(n *sitter.Node, content []byte, file *pythonFile) error {
+	query, err := sitter.NewQuery([]byte(`
+	[
+		(module (expression_statement (assignment left: (identifier) @name right: (_) @value)))
+		(module (expression_statement (augmented_assignment left: (identifier) @name right: (_) @value)))
+		(module (import_from_statement) @import)
+	]
+	`), python.GetLanguage())
+	if err != nil {
+		return errors.Wrap(err, "NewQuery")
+	}
+	defer query.Close()
+
+	cursor := sitter.NewQueryCursor()
+	defer cursor.Close()
+	cursor.Exec(query, n)
+
+	for {
+		match, ok := cursor.NextMatch()
+		if !ok {
+			break
+		}
+		captures := indexer.GetCaptures(query, match)
+
+		if imports := captures["import"]; len(imports) > 0 {
+			file.Imports = append(file.Imports, fromImports(content, imports[0])...)
+			continue
+		}
+		if indexer.FirstCaptureContentOr(content, captures["name"], "") != "__all__" {
+			continue
+		}
+		value := captures["value"][0]
+		if value.Type() != "list" && value.Type() != "tuple" {
+			continue // computed, e.g. __all__ = foo.__all__ + [...]
+		}
+		file.HasAll = true
+		for i := 0; i < int(value.NamedChildCount()); i++ {
+			if item := value.NamedChild(i); item.Type() == "string" {
+				file.All = append(file.All, strings.Trim(item.Content(content), `"'`))
+			}
+		}
+	}
+	return nil
+}
+
+// fromImports returns the names imported by an import_from_statement node.
+func fromImports(content []byte, node *sitter.Node) []pythonImport {
+	moduleName := node.ChildByFieldName("module_name")
+	if moduleName == nil {
+		return nil
+	}
+	module := moduleName.Content(content)
+	level := len(module) - len(strings.TrimLeft(module, "."))
+
+	var imports []pythonImport
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if child.Equal(moduleName) {
+			continue
+		}
+		imp := pythonImport{Level: level, Module: module[level:]}
+		switch child.Type() {
+		case "wildcard_import":
+			imp.Name = "*"
+		case "dotted_name":
+			imp.Name = child.Content(content)
+		case "aliased_import":
+			imp.Name = child.ChildByFieldName("name").Content(content)
+			imp.Alias = child.ChildByFieldName("alias").Content(content)
+		default:
+			continue // e.g. comments
+		}
+		imports = append(imports, imp)
+	}
+	return imports
+}
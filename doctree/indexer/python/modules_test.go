@@ -0,0 +1,220 @@
+package python
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func Test_moduleNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []string
+		want    autogold.Value
+	}{
+		{
+			name:    "flat",
+			sources: []string{"setup.py", "util.py"},
+			want:    autogold.Want("flat", map[string]string{"setup.py": "setup", "util.py": "util"}),
+		},
+		{
+			name:    "src-layout",
+			sources: []string{"src/foo/__init__.py", "src/foo/bar.py", "src/foo/baz/__init__.py", "src/foo/baz/qux.pyi"},
+			want: autogold.Want("src-layout", map[string]string{
+				"src/foo/__init__.py": "foo", "src/foo/bar.py": "foo.bar",
+				"src/foo/baz/__init__.py": "foo.baz", "src/foo/baz/qux.pyi": "foo.baz.qux",
+			}),
+		},
+		{
+			name:    "root-package",
+			sources: []string{"__init__.py", "bar.py"},
+			want:    autogold.Want("root-package", map[string]string{"__init__.py": "root", "bar.py": "root.bar"}),
+		},
+		{
+			name:    "stubs",
+			sources: []string{"foo/__init__.py", "foo/bar.py", "foo-stubs/__init__.pyi", "foo-stubs/bar.pyi", "foo/bar.pyi"},
+			want: autogold.Want("stubs", map[string]string{
+				"foo-stubs/__init__.pyi": "foo", "foo-stubs/bar.pyi": "foo.bar",
+				"foo/__init__.py": "foo", "foo/bar.py": "foo.bar", "foo/bar.pyi": "foo.bar",
+			}),
+		},
+		{
+			name:    "collision",
+			sources: []string{"scripts/util.py", "tools/util.py", "tools/main.py"},
+			want: autogold.Want("collision", map[string]string{
+				"scripts/util.py": "scripts.util", "tools/main.py": "main",
+				"tools/util.py": "tools.util",
+			}),
+		},
+		{
+			name:    "package-collision",
+			sources: []string{"a/pkg/__init__.py", "b/pkg/__init__.py"},
+			want:    autogold.Want("package-collision", map[string]string{"a/pkg/__init__.py": "a.pkg", "b/pkg/__init__.py": "b.pkg"}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.want.Equal(t, moduleNames(tc.sources, "root"))
+		})
+	}
+}
+
+func Test_moduleExports(t *testing.T) {
+	type exports struct {
+		All     []string
+		HasAll  bool
+		Imports []pythonImport
+	}
+	tests := []struct {
+		name, content string
+		want          autogold.Value
+	}{
+		{
+			name:    "all",
+			content: "__all__ = ['Client', \"get\"]\n__all__ += ('post',)\n",
+			want:    autogold.Want("all", exports{All: []string{"Client", "get", "post"}, HasAll: true}),
+		},
+		{
+			name:    "computed-all",
+			content: "from .a import *\n__all__ = a.__all__ + ['b']\n",
+			want:    autogold.Want("computed-all", exports{Imports: []pythonImport{{Level: 1, Module: "a", Name: "*"}}}),
+		},
+		{
+			name:    "from-imports",
+			content: "import os\nfrom os import path\nfrom ..models import (Model, Field as F)\nfrom . import sub\n",
+			want: autogold.Want("from-imports", exports{Imports: []pythonImport{
+				{Module: "os", Name: "path"},
+				{Level: 2, Module: "models", Name: "Model"},
+				{Level: 2, Module: "models", Name: "Field", Alias: "F"},
+				{Level: 1, Name: "sub"},
+			}}),
+		},
+		{
+			name:    "nested",
+			content: "def f():\n    from .a import b\n    __all__ = ['c']\n",
+			want:    autogold.Want("nested", exports{}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := indexFile(context.Background(), "mod.py", []byte(tc.content), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.want.Equal(t, exports{All: file.All, HasAll: file.HasAll, Imports: file.Imports})
+		})
+	}
+}
+
+func Test_followReexports(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  autogold.Value
+	}{
+		{
+			name: "relative-import",
+			files: map[string]string{
+				"pkg/__init__.py": "from ._client import Client\nfrom .util import helper as h\nfrom os import path\n",
+				"pkg/_client.py":  "class Client:\n    def get(self):\n        pass\n",
+				"pkg/util.py":     "def helper():\n    pass\n\ndef other():\n    pass\n",
+			},
+			want: autogold.Want("relative-import", []string{
+				"pkg: h Client Client.get",
+				"pkg.util: other",
+			}),
+		},
+		{
+			name: "all",
+			files: map[string]string{
+				"pkg/__init__.py": "from pkg.a import A, B\nfrom .b import C\n__all__ = ['A']\n",
+				"pkg/a.py":        "class A:\n    pass\n\nclass B:\n    pass\n",
+				"pkg/b.py":        "class C:\n    pass\n",
+			},
+			want: autogold.Want("all", []string{"pkg: A", "pkg.a: B", "pkg.b: C"}),
+		},
+		{
+			name: "wildcard",
+			files: map[string]string{
+				"pkg/__init__.py": "from .a import *\n",
+				"pkg/a.py":        "MAX = 1\n\ndef f():\n    pass\n\ndef _g():\n    pass\n",
+			},
+			want: autogold.Want("wildcard", []string{"pkg: MAX f"}),
+		},
+		{
+			name: "nested",
+			files: map[string]string{
+				"pkg/__init__.py":     "from .sub import Client\n",
+				"pkg/sub/__init__.py": "from ._impl import Client\n",
+				"pkg/sub/_impl.py":    "class Client:\n    pass\n",
+			},
+			want: autogold.Want("nested", []string{"pkg: Client"}),
+		},
+		{
+			name: "module",
+			files: map[string]string{
+				"pkg/mod.py":  "from .util import helper\n",
+				"pkg/util.py": "def helper():\n    pass\n",
+			},
+			want: autogold.Want("module", []string{"util: helper"}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var sources []string
+			for path := range tc.files {
+				sources = append(sources, path)
+			}
+			names := moduleNames(sources, "root")
+			mods := map[string]*module{}
+			for path, content := range tc.files {
+				file, err := indexFile(context.Background(), path, []byte(content), false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				mods[names[path]] = &module{
+					name:      names[path],
+					path:      path,
+					isPackage: isPackageFile(path),
+					file:      file,
+					constants: file.Constants,
+					functions: file.Functions,
+					classes:   file.Classes,
+				}
+			}
+			followReexports(mods)
+			tc.want.Equal(t, summarizeModules(mods))
+		})
+	}
+}
+
+// summarizeModules lists the IDs of the sections documented on each module which has any.
+func summarizeModules(mods map[string]*module) []string {
+	var names []string
+	for name := range mods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var got []string
+	for _, name := range names {
+		mod := mods[name]
+		var ids []string
+		for _, sections := range [][]schema.Section{mod.constants, mod.functions, mod.classes} {
+			for _, section := range sections {
+				ids = append(ids, section.ID)
+				for _, child := range section.Children {
+					ids = append(ids, child.ID)
+				}
+			}
+		}
+		if len(ids) > 0 {
+			got = append(got, name+": "+strings.Join(ids, " "))
+		}
+	}
+	return got
+}
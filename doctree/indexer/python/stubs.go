@@ -0,0 +1,45 @@
+package python
+
+import "github.com/sourcegraph/doctree/doctree/schema"
+
+// mergeStub merges the declarations of a stub file (.pyi) into those of the module it describes,
+// so that labels show the type annotations of the stub. Declarations only found in the stub, e.g.
+// those of an extension module written in C, are added. Docs are taken from the implementation
+// unless it has none, as stubs rarely do.
+func mergeStub(impl, stub *pythonFile) *pythonFile {
+	merged := *impl
+	if merged.ModDocs == "" {
+		merged.ModDocs = stub.ModDocs
+	}
+	merged.Constants = mergeStubSections(impl.Constants, stub.Constants)
+	merged.Functions = mergeStubSections(impl.Functions, stub.Functions)
+	merged.Classes = mergeStubSections(impl.Classes, stub.Classes)
+	if !merged.HasAll {
+		merged.All, merged.HasAll = stub.All, stub.HasAll
+	}
+	merged.Imports = append(append([]pythonImport{}, impl.Imports...), stub.Imports...)
+	return &merged
+}
+
+// mergeStubSections merges sections from a stub file into those of the implementation with the
+// same ID, recursively, e.g. so methods get the labels of the stub too.
+func mergeStubSections(impl, stub []schema.Section) []schema.Section {
+	merged := append([]schema.Section{}, impl...)
+	byID := make(map[string]int, len(merged))
+	for i, section := range merged {
+		byID[section.ID] = i
+	}
+	for _, section := range stub {
+		i, ok := byID[section.ID]
+		if !ok {
+			merged = append(merged, section)
+			continue
+		}
+		merged[i].Label = section.Label
+		if merged[i].Detail == "" {
+			merged[i].Detail = section.Detail
+		}
+		merged[i].Children = mergeStubSections(merged[i].Children, section.Children)
+	}
+	return merged
+}
@@ -0,0 +1,88 @@
+package python
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+)
+
+func Test_mergeStub(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  autogold.Value
+	}{
+		{
+			// foo/bar.py is indexed before foo/bar.pyi
+			name: "stub-after",
+			files: map[string]string{
+				"foo/__init__.py": "",
+				"foo/bar.py":      "\"\"\"Bars.\"\"\"\n\ndef get(x):\n    \"\"\"Gets x.\"\"\"\n",
+				"foo/bar.pyi":     "def get(x: int) -> str: ...\ndef native() -> None: ...\n",
+			},
+			want: autogold.Want("stub-after", []string{
+				"foo.bar: Bars.", "  def get(x: int) -> str (foo/bar.py): Gets x.",
+				"  def native() -> None (foo/bar.pyi): ",
+			}),
+		},
+		{
+			// foo-stubs/bar.pyi is indexed before foo/bar.py
+			name: "stub-before",
+			files: map[string]string{
+				"foo/__init__.py":        "",
+				"foo/bar.py":             "def get(x):\n    \"\"\"Gets x.\"\"\"\n",
+				"foo-stubs/__init__.pyi": "",
+				"foo-stubs/bar.pyi":      "\"\"\"Bars.\"\"\"\n\ndef get(x: int) -> str: ...\n",
+			},
+			want: autogold.Want("stub-before", []string{"foo.bar: Bars.", "  def get(x: int) -> str (foo/bar.py): Gets x."}),
+		},
+		{
+			name: "stub-only",
+			files: map[string]string{
+				"_native.pyi": "def f() -> int: ...\n",
+			},
+			want: autogold.Want("stub-only", []string{"_native: ", "  def f() -> int (_native.pyi): "}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for path, content := range tc.files {
+				path = filepath.Join(dir, filepath.FromSlash(path))
+				if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			index, err := (&pythonIndexer{}).IndexDir(context.Background(), dir, indexer.Options{IncludePrivate: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Summarize the functions of each module with any, with their labels, docs and locations.
+			var got []string
+			for _, library := range index.Libraries {
+				for _, page := range library.Pages {
+					for _, category := range page.Sections {
+						if category.ID != "func" || len(category.Children) == 0 {
+							continue
+						}
+						got = append(got, page.Path+": "+strings.TrimSpace(string(page.Detail)))
+						for _, function := range category.Children {
+							docs := strings.TrimSpace(string(function.Detail))
+							got = append(got, "  "+string(function.Label)+" ("+function.Location.Path+"): "+docs)
+						}
+					}
+				}
+			}
+			tc.want.Equal(t, got)
+		})
+	}
+}
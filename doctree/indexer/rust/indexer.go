@@ -0,0 +1,937 @@
+// Package rust provides a doctree indexer implementation for Rust.
+package rust
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func init() {
+	indexer.Register(&rustIndexer{})
+}
+
+// Implements the indexer.Language interface.
+type rustIndexer struct{}
+
+func (i *rustIndexer) Name() schema.Language { return schema.LanguageRust }
+
+func (i *rustIndexer) Extensions() []string { return []string{"rs"} }
+
+// Categories of items, in the order they are shown on a page.
+var categories = []struct{ id, label string }{
+	{"macro", "Macros"},
+	{"struct", "Structs"},
+	{"enum", "Enums"},
+	{"union", "Unions"},
+	{"trait", "Traits"},
+	{"fn", "Functions"},
+	{"type", "Type aliases"},
+	{"const", "Constants"},
+	{"static", "Statics"},
+}
+
+// typeCategories are the categories of items which impl blocks may be attached to.
+var typeCategories = []string{"struct", "enum", "union", "type"}
+
+func (i *rustIndexer) IndexDir(ctx context.Context, dir string, opts indexer.Options) (*schema.Index, error) {
+	// Find Rust sources
+	sources, err := indexer.Sources(dir, opts, ".rs")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sources")
+	}
+	dirFS := os.DirFS(dir)
+
+	files := 0
+	bytes := 0
+	parsed := map[string]*rustModule{}
+	var paths []string
+	for _, path := range sources {
+		if indexer.IsTestFile(path, testDirs) {
+			continue
+		}
+		content, err := fs.ReadFile(dirFS, path)
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadFile")
+		}
+		files += 1
+		bytes += len(content)
+
+		file := &rustModule{}
+		if !opts.Cache.Get(path, content, file) {
+			file, err = indexFile(ctx, path, content, opts.IncludePrivate)
+			if err != nil {
+				return nil, errors.Wrap(err, path)
+			}
+			if err := opts.Cache.Put(path, content, file); err != nil {
+				return nil, errors.Wrap(err, "Put")
+			}
+		}
+		parsed[filepath.ToSlash(path)] = file
+		paths = append(paths, filepath.ToSlash(path))
+	}
+
+	crates, err := findCrates(dir, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "findCrates")
+	}
+
+	// Resolve the module tree of each crate from its root file, e.g. src/lib.rs, following `mod`
+	// declarations. Files which are not part of any crate found this way, e.g. src/bin/*.rs or
+	// build.rs, are crate roots themselves.
+	tree := &moduleTree{files: parsed, claimed: map[string]bool{}, crateNames: map[string]bool{}}
+	for _, crate := range crates {
+		for _, root := range crate.roots {
+			if parsed[root] != nil && !tree.claimed[root] {
+				tree.addCrate(crate.name, root)
+			}
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool { return isRootFile(paths[i]) && !isRootFile(paths[j]) })
+	for _, path := range paths {
+		if !tree.claimed[path] {
+			tree.addCrate(crateName(path), path)
+		}
+	}
+
+	var pages []schema.Page
+	for _, modules := range tree.crates {
+		attachImpls(modules)
+
+		hasPage := map[string]bool{}
+		for _, mod := range modules {
+			if mod.public || opts.IncludePrivate {
+				hasPage[strings.Join(mod.path, "::")] = true
+			}
+		}
+		for _, mod := range modules {
+			modName := strings.Join(mod.path, "::")
+			if !hasPage[modName] {
+				continue
+			}
+			modSearchKey := moduleSearchKey(mod.path)
+
+			var sections []schema.Section
+			for _, category := range categories {
+				children := mod.Sections[category.id]
+				if len(children) == 0 {
+					continue
+				}
+				sections = append(sections, schema.Section{
+					ID:         category.id,
+					ShortLabel: category.id,
+					Label:      schema.Markdown(category.label),
+					SearchKey:  []string{},
+					Category:   true,
+					Children:   withModule(modSearchKey, children),
+				})
+			}
+
+			// Link to the submodules of the module.
+			var subpages []schema.Page
+			for _, sub := range modules {
+				subName := strings.Join(sub.path, "::")
+				if hasPage[subName] && len(sub.path) == len(mod.path)+1 && strings.HasPrefix(subName, modName+"::") {
+					subpages = append(subpages, schema.Page{
+						Path:      subName,
+						Title:     moduleTitle(sub.path),
+						SearchKey: []string{},
+						Location:  sub.location,
+						Sections:  []schema.Section{},
+					})
+				}
+			}
+			if len(sections) == 0 && len(subpages) == 0 && mod.Docs == "" {
+				continue
+			}
+
+			pages = append(pages, schema.Page{
+				Path:      modName,
+				Title:     moduleTitle(mod.path),
+				Detail:    schema.Markdown(docsToMarkdown(mod.Docs)),
+				SearchKey: modSearchKey,
+				Location:  mod.location,
+				Sections:  sections,
+				Subpages:  subpages,
+			})
+		}
+	}
+
+	var libraries []indexer.LibraryRoot
+	for _, crate := range crates {
+		libraries = append(libraries, crate.LibraryRoot)
+	}
+	return &schema.Index{
+		SchemaVersion: schema.LatestVersion,
+		Language:      schema.LanguageRust,
+		NumFiles:      files,
+		NumBytes:      bytes,
+		Libraries:     indexer.GroupLibraries(libraries, pages, indexer.DefaultLibrary(dir)),
+	}, nil
+}
+
+func moduleTitle(modPath []string) string {
+	if len(modPath) == 1 {
+		return "Crate " + modPath[0]
+	}
+	return "Module " + strings.Join(modPath, "::")
+}
+
+// moduleSearchKey returns the search key of a module, e.g. ["foo", "::", "bar"] for foo::bar
+func moduleSearchKey(modPath []string) []string {
+	var key []string
+	for i, part := range modPath {
+		if i > 0 {
+			key = append(key, "::")
+		}
+		key = append(key, part)
+	}
+	return key
+}
+
+// withModule prefixes the search keys of sections (which are relative to their module) and their
+// children with the search key of the module.
+func withModule(modSearchKey []string, sections []schema.Section) []schema.Section {
+	for i := range sections {
+		if len(sections[i].SearchKey) > 0 {
+			key := append(append([]string{}, modSearchKey...), "::")
+			sections[i].SearchKey = append(key, sections[i].SearchKey...)
+		}
+		sections[i].Children = withModule(modSearchKey, sections[i].Children)
+	}
+	return sections
+}
+
+// crate is a Rust package described by a Cargo.toml file.
+type crate struct {
+	indexer.LibraryRoot
+
+	// name of the crate as used in paths, e.g. "serde_json" for the serde-json package.
+	name string
+
+	// roots are the slash-separated paths of the crate's root files, e.g. src/lib.rs
+	roots []string
+}
+
+// findCrates finds Rust packages in dir, i.e. directories containing a Cargo.toml file with a
+// [package] table. Workspace roots without one are ignored.
+func findCrates(dir string, opts indexer.Options) ([]crate, error) {
+	manifests, err := indexer.FindFiles(dir, opts, "Cargo.toml")
+	if err != nil {
+		return nil, errors.Wrap(err, "FindFiles")
+	}
+	sort.Strings(manifests)
+
+	var crates []crate
+	for _, manifest := range manifests {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(manifest)))
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadFile")
+		}
+		var cargo struct {
+			Package struct {
+				Name string `toml:"name"`
+
+				// A string, or a table when inherited from the workspace, e.g.
+				// `version.workspace = true`
+				Version interface{} `toml:"version"`
+			} `toml:"package"`
+			Lib struct {
+				Name string `toml:"name"`
+				Path string `toml:"path"`
+			} `toml:"lib"`
+		}
+		if err := toml.Unmarshal(content, &cargo); err != nil {
+			return nil, errors.Wrap(err, manifest)
+		}
+		if cargo.Package.Name == "" {
+			continue
+		}
+		crateDir := path.Dir(filepath.ToSlash(manifest))
+		library := schema.Library{Name: cargo.Package.Name, ID: cargo.Package.Name}
+		if version, ok := cargo.Package.Version.(string); ok {
+			library.Version = version
+			library.VersionType = "semver"
+		}
+
+		name := cargo.Lib.Name
+		if name == "" {
+			name = strings.ReplaceAll(cargo.Package.Name, "-", "_")
+		}
+		libPath := cargo.Lib.Path
+		if libPath == "" {
+			libPath = "src/lib.rs"
+		}
+		crates = append(crates, crate{
+			LibraryRoot: indexer.LibraryRoot{Dir: crateDir, Library: library},
+			name:        name,
+			roots:       []string{path.Join(crateDir, libPath), path.Join(crateDir, "src/main.rs")},
+		})
+	}
+	return crates, nil
+}
+
+// isRootFile reports whether the file at the given slash-separated path is conventionally the root
+// of a crate, i.e. a lib.rs or main.rs file.
+func isRootFile(p string) bool {
+	return path.Base(p) == "lib.rs" || path.Base(p) == "main.rs"
+}
+
+// crateName returns the name of a crate whose root file is at the given slash-separated path but
+// which is not described by a Cargo.toml file: the name of the file, e.g. "foo" for
+// src/bin/foo.rs, or of its directory for lib.rs, main.rs and mod.rs files.
+func crateName(p string) string {
+	name := strings.TrimSuffix(path.Base(p), ".rs")
+	if isRootFile(p) || name == "mod" {
+		dir := path.Dir(p)
+		if path.Base(dir) == "src" {
+			dir = path.Dir(dir)
+		}
+		name = "crate"
+		if dir != "." {
+			name = path.Base(dir)
+		}
+	}
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// module is a module within the module tree of a crate.
+type module struct {
+	*rustModule
+
+	// path of the module, e.g. ["serde", "de", "value"]
+	path []string
+
+	// public reports whether the module is reachable from outside the crate, i.e. it and all of
+	// its parent modules are pub.
+	public bool
+
+	location *schema.Location
+}
+
+// moduleTree resolves the modules of crates from their root files.
+type moduleTree struct {
+	// files are the parsed Rust source files, by slash-separated path.
+	files map[string]*rustModule
+
+	// claimed are the files which have been found to be a module of a crate.
+	claimed map[string]bool
+
+	crateNames map[string]bool
+
+	// crates are the modules of each crate, in the order they were found.
+	crates [][]*module
+}
+
+// addCrate adds the crate whose root file is at the given path. If a crate with the same name was
+// already added (e.g. a binary crate named after its package, next to the library) the crate is
+// named by the path of its root file instead.
+func (t *moduleTree) addCrate(name, root string) {
+	if t.crateNames[name] {
+		name = strings.TrimSuffix(root, ".rs")
+	}
+	t.crateNames[name] = true
+	t.claimed[root] = true
+
+	var modules []*module
+	t.add(&modules, []string{name}, t.files[root], root, path.Dir(root), false, true, &schema.Location{Path: root})
+	t.crates = append(t.crates, modules)
+}
+
+// add adds a module and, recursively, its submodules. Submodules declared by `mod foo;` are found in
+// modDir, as foo.rs or foo/mod.rs. inline reports whether the module is declared inline within file.
+func (t *moduleTree) add(modules *[]*module, modPath []string, mod *rustModule, file, modDir string, inline, public bool, location *schema.Location) {
+	*modules = append(*modules, &module{rustModule: mod, path: modPath, public: public, location: location})
+	for _, decl := range mod.Mods {
+		subPath := append(append([]string{}, modPath...), decl.Name)
+		subDir := path.Join(modDir, decl.Name)
+		if decl.Inline != nil {
+			t.add(modules, subPath, decl.Inline, file, subDir, true, public && decl.Pub, decl.Location)
+			continue
+		}
+
+		candidates := []string{subDir + ".rs", path.Join(subDir, "mod.rs")}
+		if decl.PathAttr != "" {
+			// Relative to the directory of the file, or to that of the inline module it is in.
+			candidates = []string{path.Join(path.Dir(file), decl.PathAttr)}
+			if inline {
+				candidates = []string{path.Join(modDir, decl.PathAttr)}
+			}
+		}
+		for _, subFile := range candidates {
+			if t.files[subFile] == nil || t.claimed[subFile] {
+				continue
+			}
+			t.claimed[subFile] = true
+			if decl.Test {
+				break
+			}
+			subModDir := subDir
+			if path.Base(subFile) == "mod.rs" || decl.PathAttr != "" {
+				subModDir = path.Dir(subFile)
+			}
+			t.add(modules, subPath, t.files[subFile], subFile, subModDir, false, public && decl.Pub, &schema.Location{Path: subFile})
+			break
+		}
+	}
+}
+
+// attachImpls attaches the impl blocks of the modules of a crate to the types they implement, e.g.
+// the methods of `impl Point { ... }` and `impl Display for Point { ... }` to the Point struct.
+// Implementations of a trait of the crate for other types, e.g. `impl<T> Shape for Vec<T>`, are
+// attached to the trait instead.
+func attachImpls(modules []*module) {
+	// find returns the section of the named item in the given categories, preferring the module
+	// of the impl block and falling back to any other module of the crate.
+	find := func(from *module, name string, categoryIDs ...string) *schema.Section {
+		candidates := append([]*module{from}, modules...)
+		for _, mod := range candidates {
+			for _, category := range categoryIDs {
+				sections := mod.Sections[category]
+				for i := range sections {
+					if sections[i].ShortLabel == name {
+						return &sections[i]
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, mod := range modules {
+		for _, impl := range mod.Impls {
+			var target *schema.Section
+			if !impl.Blanket {
+				target = find(mod, impl.Type, typeCategories...)
+			}
+			if target == nil && impl.Trait != "" {
+				if trait := find(mod, impl.Trait, "trait"); trait != nil {
+					trait.Children = append(trait.Children, implSection(impl, implID(*trait, impl.Type)))
+				}
+				continue
+			}
+			if target == nil {
+				continue // e.g. a type which is private, or declared by a macro
+			}
+			if impl.Trait == "" {
+				// Inherent methods and constants are documented as members of the type.
+				for _, item := range impl.Section.Children {
+					item.ID = target.ID + "." + item.ID
+					item.SearchKey = append(append(append([]string{}, target.SearchKey...), "::"), item.SearchKey...)
+					target.Children = append(target.Children, item)
+				}
+				continue
+			}
+			target.Children = append(target.Children, implSection(impl, implID(*target, impl.Trait)))
+		}
+	}
+}
+
+// implID returns the ID of the section documenting an impl block attached to parent, named after
+// the other side of the impl, e.g. "Point.impl-Display" for `impl<T> fmt::Display for Point<T>`
+// attached to Point, or "Shape.impl-Vec" for `impl<T> Shape for Vec<T>` attached to Shape. Impls
+// which would share an ID, e.g. of From<i32> and From<String>, are numbered: "Point.impl-From-2".
+func implID(parent schema.Section, name string) string {
+	id := parent.ID + ".impl-" + name
+	taken := func(id string) bool {
+		for _, child := range parent.Children {
+			if child.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	for n := 2; taken(id); n++ {
+		id = fmt.Sprintf("%s.impl-%s-%d", parent.ID, name, n)
+	}
+	return id
+}
+
+// implSection returns the section documenting a trait impl block with the given ID. Its items are
+// not searchable, as they are documented by the trait.
+func implSection(impl implBlock, id string) schema.Section {
+	section := impl.Section
+	section.ID = id
+	section.Children = nil
+	for _, item := range impl.Section.Children {
+		item.ID = id + "." + item.ID
+		item.SearchKey = []string{}
+		section.Children = append(section.Children, item)
+	}
+	return section
+}
+
+// rustModule is the result of indexing a Rust module: a source file, as stored in the
+// indexer.FileCache, or an inline module within one. Search keys are relative to the module.
+type rustModule struct {
+	// Docs of the module, from inner doc comments (//! or /*! */)
+	Docs string `json:"docs"`
+
+	Mods []modDecl `json:"mods"`
+
+	// Sections documenting items, by category ID, e.g. "fn".
+	Sections map[string][]schema.Section `json:"sections"`
+
+	Impls []implBlock `json:"impls"`
+}
+
+// modDecl is a module declaration, e.g. `pub mod foo;` or `mod foo { ... }`
+type modDecl struct {
+	Name string `json:"name"`
+	Pub  bool   `json:"pub"`
+
+	// Test reports whether the module is only compiled for tests, i.e. has a #[cfg(test)]
+	// attribute. Its file is not documented.
+	Test bool `json:"test,omitempty"`
+
+	// PathAttr is the path of the module's file given by a #[path = "..."] attribute, if any.
+	PathAttr string `json:"pathAttr,omitempty"`
+
+	// Inline is the module declared inline, e.g. `mod foo { ... }`, or nil if it is in a file.
+	Inline *rustModule `json:"inline,omitempty"`
+
+	Location *schema.Location `json:"location"`
+}
+
+// implBlock is an impl block, e.g. `impl<T> Display for Point<T> { ... }`
+type implBlock struct {
+	// Type is the name of the implementing type, e.g. "Point".
+	Type string `json:"type"`
+
+	// Trait is the name of the implemented trait, e.g. "Display", or "" for inherent impls.
+	Trait string `json:"trait,omitempty"`
+
+	// Blanket reports whether the implementing type is a type parameter, e.g. `impl<T> Foo for T`.
+	Blanket bool `json:"blanket,omitempty"`
+
+	// Section documenting the impl block, labeled by its header, with its items as children.
+	// Their IDs and search keys are relative to the impl block.
+	Section schema.Section `json:"section"`
+}
+
+// indexFile indexes a single Rust source file. Items which are not pub are only included if
+// includePrivate is true.
+func indexFile(ctx context.Context, path string, content []byte, includePrivate bool) (*rustModule, error) {
+	// Parse the file with tree-sitter.
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(rust.GetLanguage())
+
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseCtx")
+	}
+	defer tree.Close()
+
+	f := &fileIndexer{content: content, path: filepath.ToSlash(path), includePrivate: includePrivate}
+	return f.module(tree.RootNode()), nil
+}
+
+// fileIndexer indexes the items within a single Rust file.
+type fileIndexer struct {
+	content        []byte
+	path           string
+	includePrivate bool
+}
+
+// module indexes the items of a module: the root node of a file, or the body of an inline module.
+func (f *fileIndexer) module(body *sitter.Node) *rustModule {
+	mod := &rustModule{Sections: map[string][]schema.Section{}}
+	add := func(category string, section schema.Section, pub bool) {
+		if pub || f.includePrivate {
+			mod.Sections[category] = append(mod.Sections[category], section)
+		}
+	}
+
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		item := body.NamedChild(i)
+		switch item.Type() {
+		case "line_comment", "block_comment":
+			comment := item.Content(f.content)
+			if strings.HasPrefix(comment, "//!") || strings.HasPrefix(comment, "/*!") {
+				mod.Docs += comment + "\n"
+			}
+
+		case "mod_item":
+			attrs := f.attributes(item)
+			name := item.ChildByFieldName("name")
+			if name == nil {
+				continue
+			}
+			decl := modDecl{
+				Name:     name.Content(f.content),
+				Pub:      isPub(f.content, item),
+				Test:     attrs.cfgTest,
+				PathAttr: attrs.path,
+				Location: indexer.NodeLocation(f.path, item),
+			}
+			body := item.ChildByFieldName("body")
+			if body != nil && decl.Test {
+				continue
+			}
+			if body != nil {
+				decl.Inline = f.module(body)
+				decl.Inline.Docs = strings.TrimSpace(attrs.docs + "\n" + decl.Inline.Docs)
+			}
+			mod.Mods = append(mod.Mods, decl)
+
+		case "function_item", "function_signature_item":
+			section, pub := f.item(item)
+			section.Label = schema.Markdown(f.header(item))
+			add("fn", section, pub)
+
+		case "struct_item", "union_item", "enum_item":
+			section, pub := f.item(item)
+			section.Label = schema.Markdown(f.header(item))
+			section.Children = f.fields(item.ChildByFieldName("body"), section.ID)
+			add(strings.TrimSuffix(item.Type(), "_item"), section, pub)
+
+		case "trait_item":
+			section, pub := f.item(item)
+			section.Label = schema.Markdown(f.header(item))
+			if body := item.ChildByFieldName("body"); body != nil {
+				section.Children = f.items(body, section.ID, section.SearchKey, true)
+			}
+			add("trait", section, pub)
+
+		case "type_item", "const_item", "static_item":
+			section, pub := f.item(item)
+			section.Label = schema.Markdown(indexer.Truncate(f.header(item)))
+			add(strings.TrimSuffix(item.Type(), "_item"), section, pub)
+
+		case "macro_definition":
+			section, _ := f.item(item)
+			section.Label = schema.Markdown("macro_rules! " + section.ShortLabel)
+			var rules []string
+			for j := 0; j < int(item.NamedChildCount()); j++ {
+				if rule := item.NamedChild(j); rule.Type() == "macro_rule" {
+					if left := rule.ChildByFieldName("left"); left != nil {
+						rules = append(rules, "    "+indexer.SingleLine(left.Content(f.content))+" => { ... };")
+					}
+				}
+			}
+			if len(rules) > 0 {
+				code := "```rust\nmacro_rules! " + section.ShortLabel + " {\n" + strings.Join(rules, "\n") + "\n}\n```"
+				section.Detail = schema.Markdown(strings.TrimSpace(code + "\n\n" + string(section.Detail)))
+			}
+			// Macros are only usable outside of the crate if exported.
+			pub := f.attributes(item).macroExport
+			if pub {
+				section.Visibility = schema.VisibilityPublic
+			}
+			add("macro", section, pub)
+
+		case "foreign_mod_item":
+			// e.g. extern "C" { pub fn foo(); }
+			body := item.ChildByFieldName("body")
+			if body == nil {
+				continue
+			}
+			for j := 0; j < int(body.NamedChildCount()); j++ {
+				switch foreign := body.NamedChild(j); foreign.Type() {
+				case "function_signature_item":
+					section, pub := f.item(foreign)
+					section.Label = schema.Markdown(f.header(foreign))
+					add("fn", section, pub)
+				case "static_item":
+					section, pub := f.item(foreign)
+					section.Label = schema.Markdown(indexer.Truncate(f.header(foreign)))
+					add("static", section, pub)
+				}
+			}
+
+		case "impl_item":
+			if impl, ok := f.impl(item); ok {
+				mod.Impls = append(mod.Impls, impl)
+			}
+		}
+	}
+	return mod
+}
+
+// item returns a section documenting a named item, without a label, and whether it is pub.
+func (f *fileIndexer) item(item *sitter.Node) (schema.Section, bool) {
+	name := ""
+	if nameNode := item.ChildByFieldName("name"); nameNode != nil {
+		name = nameNode.Content(f.content)
+	}
+	attrs := f.attributes(item)
+	pub := isPub(f.content, item)
+	visibility := schema.VisibilityPublic
+	if !pub {
+		visibility = schema.VisibilityPrivate
+	}
+	return schema.Section{
+		ID:         name,
+		ShortLabel: name,
+		Visibility: visibility,
+		Detail:     schema.Markdown(docsToMarkdown(attrs.docs)),
+		SearchKey:  []string{name},
+		Location:   indexer.NodeLocation(f.path, item),
+		Deprecated: attrs.deprecated,
+	}, pub
+}
+
+// items returns the sections documenting the items of a trait or impl block: functions, associated
+// types and constants. Items of traits and trait impls are all public, as the trait is.
+func (f *fileIndexer) items(body *sitter.Node, idPrefix string, searchKeyPrefix []string, allPub bool) []schema.Section {
+	var sections []schema.Section
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		item := body.NamedChild(i)
+		var label string
+		switch item.Type() {
+		case "function_item", "function_signature_item":
+			label = f.header(item)
+		case "associated_type", "const_item", "type_item":
+			label = indexer.Truncate(f.header(item))
+		default:
+			continue
+		}
+		section, pub := f.item(item)
+		if allPub {
+			section.Visibility = schema.VisibilityPublic
+		} else if !pub && !f.includePrivate {
+			continue
+		}
+		section.Label = schema.Markdown(label)
+		if idPrefix != "" {
+			section.ID = idPrefix + "." + section.ID
+		}
+		if len(searchKeyPrefix) > 0 {
+			section.SearchKey = append(append(append([]string{}, searchKeyPrefix...), "::"), section.SearchKey...)
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// fields returns the sections documenting the named fields of a struct or union, or the variants
+// of an enum. Tuple struct fields are documented by the label of the struct.
+func (f *fileIndexer) fields(body *sitter.Node, parentID string) []schema.Section {
+	if body == nil {
+		return nil
+	}
+	var sections []schema.Section
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		field := body.NamedChild(i)
+		if field.Type() != "field_declaration" && field.Type() != "enum_variant" {
+			continue
+		}
+		section, pub := f.item(field)
+		if field.Type() == "enum_variant" {
+			section.Visibility = schema.VisibilityPublic
+		} else if !pub && !f.includePrivate {
+			continue
+		}
+		section.ID = parentID + "." + section.ID
+		section.SearchKey = []string{parentID, "::", section.ShortLabel}
+		section.Label = schema.Markdown(indexer.Truncate(indexer.SingleLine(field.Content(f.content))))
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// impl returns the impl block documenting the given impl item, or false if it implements e.g. a
+// type which cannot be named.
+func (f *fileIndexer) impl(item *sitter.Node) (implBlock, bool) {
+	typeName := baseName(f.content, item.ChildByFieldName("type"))
+	if typeName == "" {
+		return implBlock{}, false
+	}
+	impl := implBlock{Type: typeName}
+	trait := item.ChildByFieldName("trait")
+	if trait != nil {
+		impl.Trait = baseName(f.content, trait)
+	}
+	if typeParams := item.ChildByFieldName("type_parameters"); typeParams != nil {
+		for i := 0; i < int(typeParams.NamedChildCount()); i++ {
+			param := typeParams.NamedChild(i)
+			if param.Type() == "constrained_type_parameter" {
+				param = param.ChildByFieldName("left")
+			}
+			if param != nil && param.Content(f.content) == typeName {
+				impl.Blanket = true
+			}
+		}
+	}
+
+	attrs := f.attributes(item)
+	impl.Section = schema.Section{
+		ShortLabel: "impl",
+		Label:      schema.Markdown(f.header(item)),
+		Detail:     schema.Markdown(docsToMarkdown(attrs.docs)),
+		SearchKey:  []string{},
+		Location:   indexer.NodeLocation(f.path, item),
+	}
+	if trait != nil {
+		impl.Section.ShortLabel = "impl " + impl.Trait
+	}
+	if body := item.ChildByFieldName("body"); body != nil {
+		impl.Section.Children = f.items(body, "", nil, trait != nil)
+	}
+	return impl, true
+}
+
+// baseName returns the name of a type without its path or type arguments, e.g. "Point" for
+// `crate::geom::Point<T>` or `&mut Point<T>`
+func baseName(content []byte, typ *sitter.Node) string {
+	for typ != nil {
+		switch typ.Type() {
+		case "type_identifier", "identifier":
+			return typ.Content(content)
+		case "generic_type", "reference_type", "pointer_type":
+			typ = typ.ChildByFieldName("type")
+		case "scoped_type_identifier", "scoped_identifier":
+			typ = typ.ChildByFieldName("name")
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// header returns the declaration of an item without its body, e.g. "pub fn new(x: T) -> Self" or
+// "pub struct Point<T>", on a single line.
+func (f *fileIndexer) header(item *sitter.Node) string {
+	header := item.Content(f.content)
+	if body := item.ChildByFieldName("body"); body != nil && body.Type() != "ordered_field_declaration_list" {
+		header = string(f.content[item.StartByte():body.StartByte()])
+	}
+	return strings.TrimSuffix(indexer.SingleLine(header), ";")
+}
+
+// attributes are the outer doc comments and attributes preceding an item.
+type attributes struct {
+	docs        string
+	path        string // #[path = "..."]
+	cfgTest     bool   // #[cfg(test)]
+	macroExport bool   // #[macro_export]
+	deprecated  bool   // #[deprecated]
+}
+
+var (
+	pathAttr       = regexp.MustCompile(`^#\[\s*path\s*=\s*"([^"]*)"\s*\]$`)
+	deprecatedNote = regexp.MustCompile(`^#\[\s*deprecated\s*(?:=\s*"([^"]*)"|\(.*note\s*=\s*"([^"]*)")?`)
+)
+
+// attributes returns the outer doc comments (/// or /** */) and attributes preceding an item, in
+// any order.
+func (f *fileIndexer) attributes(item *sitter.Node) attributes {
+	var (
+		attrs    attributes
+		docs     []string
+		deprNote string
+	)
+	for prev := item.PrevNamedSibling(); prev != nil; prev = prev.PrevNamedSibling() {
+		text := prev.Content(f.content)
+		if prev.Type() == "attribute_item" {
+			text = indexer.SingleLine(text)
+			if m := pathAttr.FindStringSubmatch(text); m != nil {
+				attrs.path = m[1]
+			}
+			if m := deprecatedNote.FindStringSubmatch(text); m != nil {
+				attrs.deprecated = true
+				deprNote = m[1] + m[2]
+			}
+			attrs.cfgTest = attrs.cfgTest || strings.ReplaceAll(text, " ", "") == "#[cfg(test)]"
+			attrs.macroExport = attrs.macroExport || strings.HasPrefix(text, "#[macro_export")
+			continue
+		}
+		isLineDoc := prev.Type() == "line_comment" && strings.HasPrefix(text, "///") && !strings.HasPrefix(text, "////")
+		isBlockDoc := prev.Type() == "block_comment" && strings.HasPrefix(text, "/**") && !strings.HasPrefix(text, "/***") && text != "/**/"
+		if !isLineDoc && !isBlockDoc {
+			break
+		}
+		docs = append([]string{text}, docs...)
+	}
+	if attrs.deprecated {
+		if len(docs) > 0 {
+			docs = append(docs, "///")
+		}
+		docs = append(docs, "/// "+indexer.Deprecated(deprNote))
+	}
+	attrs.docs = strings.Join(docs, "\n")
+	return attrs
+}
+
+// isPub reports whether an item is visible outside of its crate, i.e. is `pub` rather than e.g.
+// `pub(crate)`.
+func isPub(content []byte, item *sitter.Node) bool {
+	for i := 0; i < int(item.NamedChildCount()); i++ {
+		if child := item.NamedChild(i); child.Type() == "visibility_modifier" {
+			return child.Content(content) == "pub"
+		}
+	}
+	return false
+}
+
+// rustdocCodeAttrs are the attributes of code blocks in doc comments which rustdoc treats as Rust
+// code, e.g. "```no_run".
+var rustdocCodeAttrs = map[string]bool{
+	"rust": true, "ignore": true, "no_run": true, "should_panic": true, "compile_fail": true,
+	"test_harness": true, "edition2015": true, "edition2018": true, "edition2021": true,
+}
+
+// docsToMarkdown converts doc comments (/// and //! lines, or /** */ and /*! */ blocks) to
+// Markdown. As in rustdoc, code blocks are Rust code unless another language is given, and lines
+// of Rust code starting with "# " are hidden.
+func docsToMarkdown(docs string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(docs), "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"///", "//!", "/**", "/*!", "*/", "*"} {
+			if strings.HasPrefix(line, prefix) {
+				line = strings.TrimPrefix(line, prefix)
+				break
+			}
+		}
+		line = strings.TrimSuffix(line, "*/")
+		lines = append(lines, strings.TrimPrefix(line, " "))
+	}
+
+	var out []string
+	fence, isRust := "", false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+			info := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			isRust = true
+			for _, attr := range strings.FieldsFunc(info, func(r rune) bool { return r == ',' || r == ' ' }) {
+				isRust = isRust && rustdocCodeAttrs[attr]
+			}
+			if isRust {
+				line = fence + "rust"
+			}
+			out = append(out, line)
+			continue
+		}
+		if fence != "" && strings.HasPrefix(trimmed, fence) {
+			fence = ""
+			out = append(out, line)
+			continue
+		}
+		if fence != "" && isRust && (trimmed == "#" || strings.HasPrefix(trimmed, "# ")) {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// testDirs are the directories containing tests and benchmarks rather than library code, e.g.
+// tests/foo.rs or benches/foo.rs, which are not indexed.
+var testDirs = []string{"tests", "benches"}
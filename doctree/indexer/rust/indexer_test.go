@@ -0,0 +1,110 @@
+package rust
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func Test_docsToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		docs string
+		want string
+	}{
+		{
+			name: "hidden-lines",
+			docs: "/// Adds.\n///\n/// ```\n/// # use geom::add;\n/// assert_eq!(add(1, 2), 3);\n/// ```",
+			want: "Adds.\n\n```rust\nassert_eq!(add(1, 2), 3);\n```",
+		},
+		{
+			name: "code-attributes",
+			docs: "/// ```no_run,should_panic\n/// #\n/// panic!();\n/// ```",
+			want: "```rust\npanic!();\n```",
+		},
+		{
+			name: "other-language",
+			docs: "/// ```text\n/// # not hidden\n/// ```",
+			want: "```text\n# not hidden\n```",
+		},
+		{
+			name: "inner",
+			docs: "//! Crate docs.\n//! ~~~python\n//! # comment\n//! ~~~",
+			want: "Crate docs.\n~~~python\n# comment\n~~~",
+		},
+		{
+			name: "block",
+			docs: "/**\n * Adds.\n */",
+			want: "Adds.",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			autogold.Want(tc.name, tc.want).Equal(t, docsToMarkdown(tc.docs))
+		})
+	}
+}
+
+func Test_attributes(t *testing.T) {
+	tests := []struct {
+		name           string
+		source         string
+		want           string
+		wantDeprecated bool
+	}{
+		{
+			name:           "deprecated",
+			source:         "/// Adds.\n#[deprecated]\npub fn add() {}",
+			want:           "Adds.\n\nDeprecated.",
+			wantDeprecated: true,
+		},
+		{
+			name:           "deprecated-note",
+			source:         "#[deprecated = \"use plus\"]\n/// Adds.\npub fn add() {}",
+			want:           "Adds.\n\nDeprecated: use plus",
+			wantDeprecated: true,
+		},
+		{
+			name:           "deprecated-since-note",
+			source:         "/// Adds.\n#[deprecated(since = \"1.0\", note = \"use plus\")]\n#[inline]\npub fn add() {}",
+			want:           "Adds.\n\nDeprecated: use plus",
+			wantDeprecated: true,
+		},
+		{
+			name:   "not-docs",
+			source: "/// Adds.\n// not docs\npub fn add() {}",
+			want:   "",
+		},
+		{
+			name:   "not-doc-comments",
+			source: "/**/\n//// not docs\npub fn add() {}",
+			want:   "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mod, err := indexFile(context.Background(), "lib.rs", []byte(tc.source), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			section := mod.Sections["fn"][0]
+			autogold.Want(tc.name, tc.want).Equal(t, string(section.Detail))
+			if section.Deprecated != tc.wantDeprecated {
+				t.Errorf("deprecated = %v, want %v", section.Deprecated, tc.wantDeprecated)
+			}
+		})
+	}
+}
+
+func Test_implID(t *testing.T) {
+	point := schema.Section{ID: "Point", Children: []schema.Section{{ID: "Point.new"}}}
+	var got []string
+	for _, trait := range []string{"Display", "From", "From", "From"} {
+		id := implID(point, trait)
+		point.Children = append(point.Children, schema.Section{ID: id})
+		got = append(got, id)
+	}
+	autogold.Want("ids", []string{"Point.impl-Display", "Point.impl-From", "Point.impl-From-2", "Point.impl-From-3"}).Equal(t, got)
+}
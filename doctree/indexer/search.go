@@ -32,14 +32,18 @@ func IndexForSearch(projectName, indexDataDir string, indexes map[string]*schema
 	}
 	defer filter.Deinit()
 
-	walkPage := func(p schema.Page, keys [][]string, ids []string) ([][]string, []string) {
-		keys = append(keys, p.SearchKey)
-		ids = append(ids, "")
+	walkPage := func(p schema.Page, keys [][]string, ids []string, deprecated []bool) ([][]string, []string, []bool) {
+		if len(p.SearchKey) > 0 {
+			keys = append(keys, p.SearchKey)
+			ids = append(ids, "")
+			deprecated = append(deprecated, false)
+		}
 
 		var walkSection func(s schema.Section)
 		walkSection = func(s schema.Section) {
 			keys = append(keys, s.SearchKey)
 			ids = append(ids, s.ID)
+			deprecated = append(deprecated, s.Deprecated)
 
 			for _, child := range s.Children {
 				walkSection(child)
@@ -48,12 +52,12 @@ func IndexForSearch(projectName, indexDataDir string, indexes map[string]*schema
 		for _, section := range p.Sections {
 			walkSection(section)
 		}
-		return keys, ids
+		return keys, ids, deprecated
 	}
 
 	totalNumKeys := 0
 	totalNumSearchKeys := 0
-	insert := func(language, projectName, pagePath string, searchKeys [][]string, ids []string) error {
+	insert := func(language, projectName, pagePath string, searchKeys [][]string, ids []string, deprecated []bool) error {
 		absoluteKeys := make([][]string, 0, len(searchKeys))
 		for _, searchKey := range searchKeys {
 			absoluteKeys = append(absoluteKeys, append([]string{language, projectName}, searchKey...))
@@ -73,6 +77,7 @@ func IndexForSearch(projectName, indexDataDir string, indexes map[string]*schema
 			ProjectName: projectName,
 			SearchKeys:  searchKeys,
 			IDs:         ids,
+			Deprecated:  deprecated,
 			Path:        pagePath,
 		}); err != nil {
 			return errors.Wrap(err, "Encode")
@@ -87,13 +92,13 @@ func IndexForSearch(projectName, indexDataDir string, indexes map[string]*schema
 	for language, index := range indexes {
 		for _, lib := range index.Libraries {
 			for _, page := range lib.Pages {
-				searchKeys, ids := walkPage(page, nil, nil)
-				if err := insert(language, projectName, page.Path, searchKeys, ids); err != nil {
+				searchKeys, ids, deprecated := walkPage(page, nil, nil, nil)
+				if err := insert(language, projectName, page.Path, searchKeys, ids, deprecated); err != nil {
 					return err
 				}
 				for _, subPage := range page.Subpages {
-					searchKeys, ids := walkPage(subPage, nil, nil)
-					if err := insert(language, projectName, page.Path, searchKeys, ids); err != nil {
+					searchKeys, ids, deprecated := walkPage(subPage, nil, nil, nil)
+					if err := insert(language, projectName, page.Path, searchKeys, ids, deprecated); err != nil {
 						return err
 					}
 				}
@@ -201,6 +206,8 @@ var languageSearchTerms = map[string]schema.Language{
 	"objc":       schema.LanguageObjC,
 	"python":     schema.LanguagePython,
 	"py":         schema.LanguagePython,
+	"rust":       schema.LanguageRust,
+	"rs":         schema.LanguageRust,
 	"typescript": schema.LanguageTypeScript,
 	"ts":         schema.LanguageTypeScript,
 	"zig":        schema.LanguageZig,
@@ -243,9 +250,14 @@ type sinterResult struct {
 	ProjectName string     `json:"projectName"`
 	SearchKeys  [][]string `json:"searchKeys"`
 	IDs         []string   `json:"ids"`
+	Deprecated  []bool     `json:"deprecated"`
 	Path        string     `json:"path"`
 }
 
+// deprecatedPenalty is the factor by which the score of deprecated results is reduced, so that they
+// rank below their replacements.
+const deprecatedPenalty = 0.5
+
 func decodeResults(results sinter.FilterResults, queryKey []string, language *schema.Language, limit int) apischema.SearchResults {
 	var out apischema.SearchResults
 decoding:
@@ -263,6 +275,11 @@ decoding:
 			absoluteKey := append([]string{result.Language, result.ProjectName}, searchKey...)
 			score := match(queryKey, absoluteKey)
 			if score > 0.5 {
+				// Search indexes written by older versions do not record deprecation.
+				deprecated := index < len(result.Deprecated) && result.Deprecated[index]
+				if deprecated {
+					score *= deprecatedPenalty
+				}
 				out = append(out, apischema.SearchResult{
 					Language:    result.Language,
 					ProjectName: result.ProjectName,
@@ -270,6 +287,7 @@ decoding:
 					Path:        result.Path,
 					ID:          result.IDs[index],
 					Score:       score,
+					Deprecated:  deprecated,
 				})
 				if len(out) >= limit {
 					break decoding
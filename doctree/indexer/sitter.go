@@ -0,0 +1,37 @@
+package indexer
+
+import (
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+// NodeLocation returns the location of a tree-sitter node in the file at the given path.
+func NodeLocation(path string, node *sitter.Node) *schema.Location {
+	start, end := node.StartPoint(), node.EndPoint()
+	return &schema.Location{
+		Path:        path,
+		StartLine:   int(start.Row) + 1,
+		StartColumn: int(start.Column) + 1,
+		EndLine:     int(end.Row) + 1,
+		EndColumn:   int(end.Column) + 1,
+	}
+}
+
+// GetCaptures returns the nodes captured by a query match, by capture name.
+func GetCaptures(q *sitter.Query, m *sitter.QueryMatch) map[string][]*sitter.Node {
+	captures := map[string][]*sitter.Node{}
+	for _, c := range m.Captures {
+		cname := q.CaptureNameForId(c.Index)
+		captures[cname] = append(captures[cname], c.Node)
+	}
+	return captures
+}
+
+// FirstCaptureContentOr returns the content of the first of the captured nodes, or defaultValue if
+// there are none, e.g. because the capture is optional.
+func FirstCaptureContentOr(content []byte, captures []*sitter.Node, defaultValue string) string {
+	if len(captures) > 0 {
+		return captures[0].Content(content)
+	}
+	return defaultValue
+}
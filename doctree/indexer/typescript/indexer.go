@@ -0,0 +1,641 @@
+// Package typescript provides a doctree indexer implementation for TypeScript.
+package typescript
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func init() {
+	indexer.Register(&typescriptIndexer{})
+}
+
+// Implements the indexer.Language interface.
+type typescriptIndexer struct{}
+
+func (i *typescriptIndexer) Name() schema.Language { return schema.LanguageTypeScript }
+
+func (i *typescriptIndexer) Extensions() []string { return []string{"ts", "tsx", "mts", "cts"} }
+
+// Categories of declarations, in the order they are shown on a page.
+var categories = []struct{ id, label string }{
+	{"namespace", "Namespaces"},
+	{"var", "Variables"},
+	{"func", "Functions"},
+	{"class", "Classes"},
+	{"interface", "Interfaces"},
+	{"type", "Types"},
+	{"enum", "Enums"},
+}
+
+func (i *typescriptIndexer) IndexDir(ctx context.Context, dir string, opts indexer.Options) (*schema.Index, error) {
+	// Find TypeScript sources
+	sources, err := indexer.Sources(dir, opts, ".ts", ".tsx", ".mts", ".cts")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sources")
+	}
+	isSource := make(map[string]bool, len(sources))
+	for _, path := range sources {
+		isSource[filepath.ToSlash(path)] = true
+	}
+
+	dirFS := os.DirFS(dir)
+	files := 0
+	bytes := 0
+	var pages []schema.Page
+	pagePaths := map[string]bool{}
+	for _, path := range sources {
+		if indexer.IsTestFile(path, testDirs, testFiles...) {
+			continue
+		}
+		slashPath := filepath.ToSlash(path)
+		if isGenerated(slashPath, isSource) {
+			continue // declarations generated from the source file next to it
+		}
+
+		content, err := fs.ReadFile(dirFS, path)
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadFile")
+		}
+
+		files += 1
+		bytes += len(content)
+
+		file := &typescriptFile{}
+		if !opts.Cache.Get(path, content, file) {
+			file, err = indexFile(ctx, path, content, opts.IncludePrivate)
+			if err != nil {
+				return nil, errors.Wrap(err, path)
+			}
+			if err := opts.Cache.Put(path, content, file); err != nil {
+				return nil, errors.Wrap(err, "Put")
+			}
+		}
+
+		// Modules are named by their path without extension, so that e.g. foo/index.ts is "foo" as
+		// it is imported, or by their full path if that would be ambiguous (e.g. with foo.ts).
+		modName := moduleName(slashPath)
+		if pagePaths[modName] {
+			modName = slashPath
+		}
+		pagePaths[modName] = true
+		modSearchKey := moduleSearchKey(modName)
+
+		var sections []schema.Section
+		for _, category := range categories {
+			children := file.Sections[category.id]
+			if len(children) == 0 {
+				continue
+			}
+			sections = append(sections, schema.Section{
+				ID:         category.id,
+				ShortLabel: category.id,
+				Label:      schema.Markdown(category.label),
+				SearchKey:  []string{},
+				Category:   true,
+				Children:   withModule(modSearchKey, children),
+			})
+		}
+		if len(sections) == 0 && file.ModDocs == "" {
+			continue
+		}
+		pages = append(pages, schema.Page{
+			Path:      modName,
+			Title:     "Module " + modName,
+			Detail:    schema.Markdown(file.ModDocs),
+			SearchKey: modSearchKey,
+			Location:  &schema.Location{Path: slashPath},
+			Sections:  sections,
+		})
+	}
+
+	libraries, err := findLibraries(dir, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "findLibraries")
+	}
+
+	return &schema.Index{
+		SchemaVersion: schema.LatestVersion,
+		Language:      schema.LanguageTypeScript,
+		NumFiles:      files,
+		NumBytes:      bytes,
+		Libraries:     indexer.GroupLibraries(libraries, pages, indexer.DefaultLibrary(dir)),
+	}, nil
+}
+
+// moduleExtensions are the extensions of TypeScript modules, declaration files first (since e.g.
+// ".d.ts" also ends in ".ts"), with the extensions of the source files declaration files may be
+// generated from.
+var moduleExtensions = []struct {
+	ext     string
+	sources []string
+}{
+	{".d.ts", []string{".ts", ".tsx"}},
+	{".d.mts", []string{".mts"}},
+	{".d.cts", []string{".cts"}},
+	{".ts", nil},
+	{".tsx", nil},
+	{".mts", nil},
+	{".cts", nil},
+}
+
+// splitModuleExt splits the slash-separated path of a module into its path without extension, and
+// the extensions of the source files it may be generated from if it is a declaration file.
+func splitModuleExt(p string) (stem string, sources []string) {
+	for _, ext := range moduleExtensions {
+		if strings.HasSuffix(p, ext.ext) {
+			return strings.TrimSuffix(p, ext.ext), ext.sources
+		}
+	}
+	return p, nil
+}
+
+// isGenerated reports whether the file at the given slash-separated path is a declaration file
+// generated from a source file next to it, e.g. foo.d.ts from foo.ts or foo.d.mts from foo.mts.
+func isGenerated(p string, isSource map[string]bool) bool {
+	stem, sources := splitModuleExt(p)
+	for _, ext := range sources {
+		if isSource[stem+ext] {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleName returns the name of the module at the given slash-separated path, i.e. the path it is
+// imported by: without extension, or the directory for index files.
+func moduleName(p string) string {
+	p, _ = splitModuleExt(p)
+	if path.Base(p) == "index" && path.Dir(p) != "." {
+		p = path.Dir(p)
+	}
+	return p
+}
+
+// moduleSearchKey returns the search key of a module, e.g. ["src", "/", "foo"] for src/foo
+func moduleSearchKey(modName string) []string {
+	var key []string
+	for i, part := range strings.Split(modName, "/") {
+		if i > 0 {
+			key = append(key, "/")
+		}
+		key = append(key, part)
+	}
+	return key
+}
+
+// withModule prefixes the search keys of sections (which are relative to their module) and their
+// children with the search key of the module.
+func withModule(modSearchKey []string, sections []schema.Section) []schema.Section {
+	for i := range sections {
+		if len(sections[i].SearchKey) > 0 {
+			key := append(append([]string{}, modSearchKey...), ".")
+			sections[i].SearchKey = append(key, sections[i].SearchKey...)
+		}
+		sections[i].Children = withModule(modSearchKey, sections[i].Children)
+	}
+	return sections
+}
+
+// findLibraries finds npm packages in dir, i.e. directories containing a package.json file.
+func findLibraries(dir string, opts indexer.Options) ([]indexer.LibraryRoot, error) {
+	packageFiles, err := indexer.FindFiles(dir, opts, "package.json")
+	if err != nil {
+		return nil, errors.Wrap(err, "FindFiles")
+	}
+	var libraries []indexer.LibraryRoot
+	for _, packageFile := range packageFiles {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(packageFile)))
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadFile")
+		}
+		var pkg struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(content, &pkg); err != nil {
+			return nil, errors.Wrap(err, packageFile)
+		}
+		if pkg.Name == "" {
+			continue // e.g. a private workspace root
+		}
+		library := schema.Library{Name: pkg.Name, ID: pkg.Name, Version: pkg.Version}
+		if pkg.Version != "" {
+			library.VersionType = "semver"
+		}
+		libraries = append(libraries, indexer.LibraryRoot{Dir: path.Dir(packageFile), Library: library})
+	}
+	return libraries, nil
+}
+
+// typescriptFile is the result of indexing a single TypeScript source file, as stored in the
+// indexer.FileCache. Search keys are relative to the module.
+type typescriptFile struct {
+	ModDocs string `json:"modDocs"`
+
+	// Sections documenting declarations, by category ID, e.g. "func".
+	Sections map[string][]schema.Section `json:"sections"`
+}
+
+// indexFile indexes a single TypeScript source file. Only exported declarations are documented,
+// unless the file is not a module (has no imports or exports, e.g. a .d.ts file declaring
+// globals), or includePrivate is true.
+func indexFile(ctx context.Context, path string, content []byte, includePrivate bool) (*typescriptFile, error) {
+	// Parse the file with tree-sitter.
+	parser := sitter.NewParser()
+	defer parser.Close()
+	if strings.HasSuffix(path, ".tsx") {
+		parser.SetLanguage(tsx.GetLanguage())
+	} else {
+		parser.SetLanguage(typescript.GetLanguage())
+	}
+
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseCtx")
+	}
+	defer tree.Close()
+
+	// Inspect the root node.
+	n := tree.RootNode()
+
+	f := &fileIndexer{
+		content:        content,
+		path:           filepath.ToSlash(path),
+		includePrivate: includePrivate,
+	}
+	return &typescriptFile{
+		ModDocs:  f.moduleDocs(n),
+		Sections: f.block(n, nil, !isModule(n)),
+	}, nil
+}
+
+// isModule reports whether a program or namespace body has any import or export statements. If it
+// does not, its declarations are all visible outside of it, e.g. a .d.ts file declaring globals.
+func isModule(block *sitter.Node) bool {
+	for i := 0; i < int(block.NamedChildCount()); i++ {
+		switch block.NamedChild(i).Type() {
+		case "import_statement", "export_statement":
+			return true
+		}
+	}
+	return false
+}
+
+// fileIndexer indexes the declarations within a single TypeScript file.
+type fileIndexer struct {
+	content        []byte
+	path           string
+	includePrivate bool
+}
+
+// moduleDocs returns the docs of the module: the first doc comment of the file if it has a
+// @packageDocumentation, @module or @fileoverview tag, or is not attached to the declaration
+// following it (i.e. is followed by a blank line.)
+func (f *fileIndexer) moduleDocs(n *sitter.Node) string {
+	if n.NamedChildCount() == 0 {
+		return ""
+	}
+	first := n.NamedChild(0)
+	comment := first.Content(f.content)
+	if first.Type() != "comment" || !strings.HasPrefix(comment, "/**") {
+		return ""
+	}
+	next := first.NextNamedSibling()
+	tagged := strings.Contains(comment, "@packageDocumentation") || strings.Contains(comment, "@module") ||
+		strings.Contains(comment, "@fileoverview")
+	if !tagged && next != nil && next.StartPoint().Row <= first.EndPoint().Row+1 {
+		return "" // docs of the following declaration
+	}
+	docs, _ := tsdoc(comment)
+	return docs
+}
+
+// block returns the sections documenting the declarations in a program or namespace body, by
+// category. searchKeyPrefix is the search key of the enclosing namespace, if any. If allExported
+// is true, declarations are documented whether or not they are exported.
+func (f *fileIndexer) block(block *sitter.Node, searchKeyPrefix []string, allExported bool) map[string][]schema.Section {
+	// Names exported separately from their declaration, e.g. "export { foo, bar as baz }"
+	exportedNames := map[string]bool{}
+	for i := 0; i < int(block.NamedChildCount()); i++ {
+		stmt := block.NamedChild(i)
+		if stmt.Type() != "export_statement" {
+			continue
+		}
+		for j := 0; j < int(stmt.NamedChildCount()); j++ {
+			clause := stmt.NamedChild(j)
+			if clause.Type() != "export_clause" {
+				continue
+			}
+			for k := 0; k < int(clause.NamedChildCount()); k++ {
+				if name := clause.NamedChild(k).ChildByFieldName("name"); name != nil {
+					exportedNames[name.Content(f.content)] = true
+				}
+			}
+		}
+	}
+
+	lists := map[string]*sectionList{}
+	for i := 0; i < int(block.NamedChildCount()); i++ {
+		stmt := block.NamedChild(i)
+		decl, exported := stmt, allExported
+		if stmt.Type() == "export_statement" {
+			decl, exported = stmt.ChildByFieldName("declaration"), true
+			if decl == nil {
+				continue // e.g. export { foo } or export * from "./foo"
+			}
+		}
+		for _, d := range f.declaration(decl, stmt, searchKeyPrefix, exported, exportedNames) {
+			if lists[d.category] == nil {
+				lists[d.category] = newSectionList()
+			}
+			lists[d.category].add(d.section, d.signature)
+		}
+	}
+
+	sections := map[string][]schema.Section{}
+	for category, list := range lists {
+		sections[category] = list.list()
+	}
+	return sections
+}
+
+// tsDeclaration is a documented declaration.
+type tsDeclaration struct {
+	category string
+	section  schema.Section
+
+	// signature indicates a function or method declaration without a body, e.g. an overload.
+	signature bool
+}
+
+// declaration returns the sections documenting a declaration. docNode is the statement the doc
+// comment of the declaration precedes, e.g. an export statement containing it.
+func (f *fileIndexer) declaration(decl, docNode *sitter.Node, searchKeyPrefix []string, exported bool, exportedNames map[string]bool) []tsDeclaration {
+	switch decl.Type() {
+	case "ambient_declaration", "expression_statement":
+		// e.g. "declare function foo(): void", or a namespace which is parsed as an expression
+		if decl.NamedChildCount() == 0 {
+			return nil
+		}
+		return f.declaration(decl.NamedChild(0), docNode, searchKeyPrefix, exported, exportedNames)
+	case "lexical_declaration", "variable_declaration":
+		var decls []tsDeclaration
+		kind := strings.Fields(decl.Content(f.content))[0] // const, let or var
+		for i := 0; i < int(decl.NamedChildCount()); i++ {
+			declarator := decl.NamedChild(i)
+			name := declarator.ChildByFieldName("name")
+			if declarator.Type() != "variable_declarator" || name == nil || name.Type() != "identifier" {
+				continue // e.g. a destructuring pattern
+			}
+			section, ok := f.section(declarator, docNode, searchKeyPrefix, name.Content(f.content), exported, exportedNames)
+			if !ok {
+				continue
+			}
+			category, label := "var", kind+" "+indexer.Truncate(f.signature(declarator))
+			if value := declarator.ChildByFieldName("value"); value != nil {
+				switch value.Type() {
+				case "arrow_function", "function", "function_expression":
+					category = "func"
+					label = kind + " " + name.Content(f.content)
+					if typ := declarator.ChildByFieldName("type"); typ != nil {
+						label += typ.Content(f.content)
+					}
+					label += " = " + f.signature(value)
+				}
+			}
+			section.Label = schema.Markdown(label)
+			decls = append(decls, tsDeclaration{category: category, section: section})
+		}
+		return decls
+	}
+
+	category, ok := map[string]string{
+		"function_declaration":           "func",
+		"generator_function_declaration": "func",
+		"function_signature":             "func",
+		"class_declaration":              "class",
+		"abstract_class_declaration":     "class",
+		"interface_declaration":          "interface",
+		"type_alias_declaration":         "type",
+		"enum_declaration":               "enum",
+		"internal_module":                "namespace",
+		"module":                         "namespace",
+	}[decl.Type()]
+	nameNode := decl.ChildByFieldName("name")
+	if !ok || nameNode == nil {
+		return nil
+	}
+	name := strings.Trim(nameNode.Content(f.content), `"'`) // e.g. declare module "foo"
+	section, ok := f.section(decl, docNode, searchKeyPrefix, name, exported, exportedNames)
+	if !ok {
+		return nil
+	}
+	body := decl.ChildByFieldName("body")
+	switch category {
+	case "func":
+		section.Label = schema.Markdown(f.signature(decl))
+	case "type":
+		section.Label = schema.Markdown(indexer.Truncate(f.signature(decl)))
+		if string(section.Label) != f.signature(decl) {
+			section.Detail = schema.Markdown("```ts\n"+decl.Content(f.content)+"\n```\n\n") + section.Detail
+		}
+	case "class":
+		section.Label = schema.Markdown(f.signature(decl))
+		section.Children = f.classMembers(body, section.SearchKey)
+	case "interface":
+		section.Label = schema.Markdown(f.signature(decl))
+		section.Children = f.objectTypeMembers(body, section.SearchKey)
+	case "enum":
+		section.Label = schema.Markdown(f.signature(decl))
+		section.Children = f.enumMembers(body, section.SearchKey)
+	case "namespace":
+		section.Label = schema.Markdown(f.signature(decl))
+		if body != nil {
+			// Members of ambient namespaces (declare namespace Foo {}) are exported implicitly.
+			ambient := decl.Parent() != nil && decl.Parent().Type() == "ambient_declaration"
+			members := f.block(body, section.SearchKey, ambient && !isModule(body))
+			for _, c := range categories {
+				section.Children = append(section.Children, members[c.id]...)
+			}
+		}
+	}
+	return []tsDeclaration{{category: category, section: section, signature: category == "func" && body == nil}}
+}
+
+// section returns a section documenting the named declaration, with its docs but no label, or
+// false if it is not exported and private declarations are not included.
+func (f *fileIndexer) section(decl, docNode *sitter.Node, searchKeyPrefix []string, name string, exported bool, exportedNames map[string]bool) (schema.Section, bool) {
+	exported = exported || exportedNames[name]
+	if !exported && !f.includePrivate {
+		return schema.Section{}, false
+	}
+	docs, deprecated := f.docs(docNode)
+	key := indexer.SearchKey(searchKeyPrefix, name)
+	section := schema.Section{
+		ID:         strings.Join(key, ""),
+		ShortLabel: name,
+		Visibility: schema.VisibilityPublic,
+		Detail:     schema.Markdown(docs),
+		SearchKey:  key,
+		Location:   indexer.NodeLocation(f.path, decl),
+		Deprecated: deprecated,
+	}
+	if !exported {
+		section.Visibility = schema.VisibilityPrivate
+	}
+	return section, true
+}
+
+// classMembers returns the sections documenting the fields and methods of a class.
+func (f *fileIndexer) classMembers(body *sitter.Node, classKey []string) []schema.Section {
+	list := newSectionList()
+	for i := 0; body != nil && i < int(body.NamedChildCount()); i++ {
+		member := body.NamedChild(i)
+		switch member.Type() {
+		case "method_definition", "method_signature", "abstract_method_signature", "public_field_definition":
+		default:
+			continue // e.g. comments, index signatures or static blocks
+		}
+		name := member.ChildByFieldName("name")
+		if name == nil {
+			continue
+		}
+		private := strings.HasPrefix(name.Content(f.content), "#")
+		for j := 0; j < int(member.NamedChildCount()); j++ {
+			if modifier := member.NamedChild(j); modifier.Type() == "accessibility_modifier" {
+				private = private || modifier.Content(f.content) == "private"
+			}
+		}
+		if private && !f.includePrivate {
+			continue
+		}
+		section, _ := f.section(member, member, classKey, name.Content(f.content), true, nil)
+		if private {
+			section.Visibility = schema.VisibilityPrivate
+		}
+		section.Label = schema.Markdown(indexer.Truncate(f.signature(member)))
+		list.add(section, member.Type() != "method_definition" && member.Type() != "public_field_definition")
+	}
+	return list.list()
+}
+
+// objectTypeMembers returns the sections documenting the properties and methods of an interface.
+func (f *fileIndexer) objectTypeMembers(body *sitter.Node, interfaceKey []string) []schema.Section {
+	list := newSectionList()
+	for i := 0; body != nil && i < int(body.NamedChildCount()); i++ {
+		member := body.NamedChild(i)
+		name := member.ChildByFieldName("name")
+		if name == nil || (member.Type() != "property_signature" && member.Type() != "method_signature") {
+			continue // e.g. comments, or call and index signatures
+		}
+		section, _ := f.section(member, member, interfaceKey, name.Content(f.content), true, nil)
+		section.Label = schema.Markdown(indexer.Truncate(f.signature(member)))
+		list.add(section, member.Type() == "method_signature")
+	}
+	return list.list()
+}
+
+// enumMembers returns the sections documenting the members of an enum.
+func (f *fileIndexer) enumMembers(body *sitter.Node, enumKey []string) []schema.Section {
+	var sections []schema.Section
+	for i := 0; body != nil && i < int(body.NamedChildCount()); i++ {
+		member := body.NamedChild(i)
+		name := member
+		if member.Type() == "enum_assignment" {
+			name = member.ChildByFieldName("name")
+		} else if member.Type() != "property_identifier" && member.Type() != "string" {
+			continue // e.g. comments
+		}
+		section, _ := f.section(member, member, enumKey, strings.Trim(name.Content(f.content), `"'`), true, nil)
+		section.Label = schema.Markdown(indexer.Truncate(f.signature(member)))
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// signature returns the source of a declaration up to its body, if any, on a single line. e.g.
+// "function foo<T>(x: T): T" or "class Foo<T> extends Bar<T>"
+func (f *fileIndexer) signature(decl *sitter.Node) string {
+	signature := decl.Content(f.content)
+	if body := decl.ChildByFieldName("body"); body != nil {
+		signature = string(f.content[decl.StartByte():body.StartByte()])
+	}
+	signature = strings.Join(strings.Fields(signature), " ")
+	return strings.TrimRight(signature, ";, ")
+}
+
+// docs returns the Markdown docs of a declaration from the TSDoc comment preceding it, and whether
+// it is marked @deprecated.
+func (f *fileIndexer) docs(node *sitter.Node) (string, bool) {
+	prev := node.PrevNamedSibling()
+	if prev == nil || prev.Type() != "comment" {
+		return "", false
+	}
+	comment := prev.Content(f.content)
+	if !strings.HasPrefix(comment, "/**") || prev.EndPoint().Row+1 < node.StartPoint().Row {
+		return "", false
+	}
+	return tsdoc(comment)
+}
+
+// sectionList collects the sections documenting declarations, merging overloads of a function or
+// method (several declarations with the same name) into a single section listing their signatures.
+type sectionList struct {
+	sections   []schema.Section
+	byID       map[string]int
+	signatures map[string][]string
+}
+
+func newSectionList() *sectionList {
+	return &sectionList{byID: map[string]int{}, signatures: map[string][]string{}}
+}
+
+// add adds a section. signature indicates a declaration without a body, e.g. an overload.
+func (l *sectionList) add(section schema.Section, signature bool) {
+	if signature {
+		l.signatures[section.ID] = append(l.signatures[section.ID], string(section.Label))
+	}
+	i, ok := l.byID[section.ID]
+	if !ok {
+		l.byID[section.ID] = len(l.sections)
+		l.sections = append(l.sections, section)
+		return
+	}
+	// Docs are usually only on the first overload.
+	if l.sections[i].Detail == "" {
+		l.sections[i].Detail = section.Detail
+	}
+	l.sections[i].Deprecated = l.sections[i].Deprecated || section.Deprecated
+}
+
+func (l *sectionList) list() []schema.Section {
+	for id, signatures := range l.signatures {
+		if len(signatures) < 2 {
+			continue
+		}
+		i := l.byID[id]
+		code := "```ts\n" + strings.Join(signatures, "\n") + "\n```\n\n"
+		l.sections[i].Detail = schema.Markdown("Overloads:\n\n"+code) + l.sections[i].Detail
+	}
+	return l.sections
+}
+
+// testDirs and testFiles describe files likely to contain tests rather than library code, e.g.
+// foo.test.ts, foo.spec.tsx or __tests__/foo.ts, which are not indexed.
+var (
+	testDirs  = []string{"test", "tests", "__tests__"}
+	testFiles = []string{"*.test", "*.spec"}
+)
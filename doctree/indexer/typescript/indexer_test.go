@@ -0,0 +1,78 @@
+package typescript
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+)
+
+func Test_isGenerated(t *testing.T) {
+	isSource := map[string]bool{}
+	for _, p := range []string{"a.ts", "a.d.ts", "b.d.ts", "c.tsx", "c.d.ts", "d.mts", "d.d.mts", "e.cts", "e.d.cts", "f.ts", "f.d.mts"} {
+		isSource[p] = true
+	}
+	tests := []struct {
+		path       string
+		want       bool
+		moduleName string
+	}{
+		{path: "a.ts", moduleName: "a"},
+		{path: "a.d.ts", want: true, moduleName: "a"},
+		{path: "b.d.ts", moduleName: "b"},
+		{path: "c.d.ts", want: true, moduleName: "c"},
+		{path: "d.d.mts", want: true, moduleName: "d"},
+		{path: "e.d.cts", want: true, moduleName: "e"},
+		{path: "f.d.mts", moduleName: "f"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			if got := isGenerated(tc.path, isSource); got != tc.want {
+				t.Fatalf("isGenerated got %v, want %v", got, tc.want)
+			}
+			if got := moduleName(tc.path); got != tc.moduleName {
+				t.Fatalf("moduleName got %q, want %q", got, tc.moduleName)
+			}
+		})
+	}
+}
+
+func TestIndexDir_moduleNames(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"foo.ts":       "export function foo(): void {}\n",
+		"foo/index.ts": "export function fooIndex(): void {}\n",
+		"bar.ts":       "export function bar(): void {}\n",
+		"bar.tsx":      "export function Bar(): void {}\n",
+		"baz/index.ts": "export function baz(): void {}\n",
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index, err := (&typescriptIndexer{}).IndexDir(context.Background(), dir, indexer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, library := range index.Libraries {
+		for _, page := range library.Pages {
+			got = append(got, page.Path+": "+page.Location.Path)
+		}
+	}
+	sort.Strings(got)
+	autogold.Want("pages", []string{
+		"bar.tsx: bar.tsx", "bar: bar.ts", "baz: baz/index.ts",
+		"foo.ts: foo.ts",
+		"foo: foo/index.ts",
+	}).Equal(t, got)
+}
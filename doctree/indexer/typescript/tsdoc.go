@@ -0,0 +1,68 @@
+package typescript
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/doctree/doctree/indexer"
+)
+
+// tsdocParam matches the argument of a @param or @throws tag, e.g. "x - The value." or (in JSDoc
+// style) "{number} x The value."
+var tsdocParam = regexp.MustCompile(`(?s)^(?:\{([^}]*)\}\s*)?(\[[^\]]*\]|[\w$.]+)?\s*(?:-\s*)?(.*)$`)
+
+// tsdocBlockTags are the block tags which are documented, and so may follow other text on a line.
+var tsdocBlockTags = []string{
+	"@param", "@arg", "@argument", "@typeParam", "@template", "@returns", "@return", "@throws",
+	"@exception", "@deprecated", "@remarks", "@example", "@see",
+}
+
+// tsdoc converts a TSDoc (or JSDoc) comment to Markdown: its description followed by tables of
+// the parameters, return value and exceptions documented by block tags such as @param. Reports
+// whether the comment has a @deprecated tag.
+func tsdoc(comment string) (docs string, deprecated bool) {
+	description, tags := indexer.BlockTags(comment, tsdocBlockTags...)
+
+	out := []string{strings.TrimSpace(strings.Join(description, "\n"))}
+	var params, typeParams, returns, throws [][]string
+	for _, t := range tags {
+		text := strings.TrimSpace(t.Text)
+		switch t.Name {
+		case "@param", "@arg", "@argument":
+			m := tsdocParam.FindStringSubmatch(text)
+			params = append(params, []string{indexer.Code(m[2]), indexer.Code(m[1]), m[3]})
+		case "@typeParam", "@template":
+			m := tsdocParam.FindStringSubmatch(text)
+			typeParams = append(typeParams, []string{indexer.Code(m[2]), m[3]})
+		case "@returns", "@return":
+			m := tsdocParam.FindStringSubmatch(text)
+			returns = append(returns, []string{indexer.Code(m[1]), strings.TrimSpace(m[2] + " " + m[3])})
+		case "@throws", "@exception":
+			m := tsdocParam.FindStringSubmatch(text)
+			throws = append(throws, []string{indexer.Code(m[1]), strings.TrimSpace(m[2] + " " + m[3])})
+		case "@deprecated":
+			deprecated = true
+			out = append(out, indexer.Deprecated(text))
+		case "@remarks":
+			out = append(out, text)
+		case "@example":
+			out = append(out, indexer.Example(text, "ts"))
+		case "@see":
+			out = append(out, "See "+text)
+		}
+	}
+	out = append(out,
+		indexer.Table("Parameters", []string{"Name", "Type", "Description"}, params),
+		indexer.Table("Type parameters", []string{"Name", "Description"}, typeParams),
+		indexer.Table("Returns", []string{"Type", "Description"}, returns),
+		indexer.Table("Throws", []string{"Type", "Description"}, throws),
+	)
+
+	var nonEmpty []string
+	for _, s := range out {
+		if s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n"), deprecated
+}
@@ -0,0 +1,68 @@
+package typescript
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func Test_tsdoc(t *testing.T) {
+	tests := []struct {
+		name           string
+		comment        string
+		want           string
+		wantDeprecated bool
+	}{
+		{
+			name:    "tags",
+			comment: "/**\n * Adds two numbers.\n *\n * @param a - The first.\n * @param {number} b The second.\n * @typeParam T - The type.\n * @returns The sum.\n * @throws {RangeError} If too large.\n */",
+			want:    "Adds two numbers.\n\n**Parameters**\n\n| Name | Type | Description |\n| --- | --- | --- |\n| `a` |  | The first. |\n| `b` | `number` | The second. |\n\n**Type parameters**\n\n| Name | Description |\n| --- | --- |\n| `T` | The type. |\n\n**Returns**\n\n| Description |\n| --- |\n| The sum. |\n\n**Throws**\n\n| Type | Description |\n| --- | --- |\n| `RangeError` | If too large. |",
+		},
+		{
+			name:           "deprecated",
+			comment:        "/** @deprecated */",
+			want:           "Deprecated.",
+			wantDeprecated: true,
+		},
+		{
+			name:           "deprecated-on-description-line",
+			comment:        "/** Adds. @deprecated use plus */",
+			want:           "Adds.\n\nDeprecated: use plus",
+			wantDeprecated: true,
+		},
+		{
+			name:    "tags-on-description-line",
+			comment: "/** Adds. @param a - The first. @returns The sum. */",
+			want:    "Adds.\n\n**Parameters**\n\n| Name | Description |\n| --- | --- |\n| `a` | The first. |\n\n**Returns**\n\n| Description |\n| --- |\n| The sum. |",
+		},
+		{
+			name:    "not-tags",
+			comment: "/** Mail me@example.com or see `@param`. */",
+			want:    "Mail me@example.com or see `@param`.",
+		},
+		{
+			name:    "example",
+			comment: "/**\n * Example:\n * @example\n * ```ts\n * // @ts-ignore @deprecated\n * add(1, 2)\n * ```\n */",
+			want:    "Example:\n\n**Example**\n\n```ts\n// @ts-ignore @deprecated\nadd(1, 2)\n```",
+		},
+		{
+			name:    "example-without-fence",
+			comment: "/**\n * @example\n * add(1, 2) // @see plus\n */",
+			want:    "**Example**\n\n```ts\nadd(1, 2) // @see plus\n```",
+		},
+		{
+			name:    "remarks",
+			comment: "/**\n * Adds.\n * @remarks Fast. {@link plus}\n * @see plus\n */",
+			want:    "Adds.\n\nFast. {@link plus}\n\nSee plus",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, deprecated := tsdoc(tc.comment)
+			autogold.Want(tc.name, tc.want).Equal(t, got)
+			if deprecated != tc.wantDeprecated {
+				t.Errorf("deprecated = %v, want %v", deprecated, tc.wantDeprecated)
+			}
+		})
+	}
+}
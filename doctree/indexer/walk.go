@@ -0,0 +1,304 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultExcludes are gitignore-style patterns for paths which are never indexed unless
+// re-included (e.g. `!vendor/`) by a .gitignore or .doctreeignore file.
+var DefaultExcludes = []string{
+	".git/",
+	".hg/",
+	".svn/",
+	"node_modules/",
+	"bower_components/",
+	"vendor/",
+	"testdata/",
+	"__pycache__/",
+}
+
+// IgnoreFiles are the names of files containing gitignore-style patterns of paths which should not
+// be indexed. Like .gitignore, patterns in these files apply to the directory the file is in and
+// all of its subdirectories.
+var IgnoreFiles = []string{".gitignore", ".doctreeignore"}
+
+// Walk walks the directory tree rooted at dir like fs.WalkDir, calling fn for each file or
+// directory that should be indexed. Paths given to fn are slash-separated and relative to dir.
+//
+// The following are skipped:
+//
+//	DefaultExcludes, e.g. node_modules/ and vendor/
+//	git submodules and other nested repositories
+//	paths ignored by IgnoreFiles, i.e. .gitignore and .doctreeignore
+//	paths matching opts.Exclude
+//	files not matching opts.Include, if specified
+func Walk(dir string, opts Options, fn fs.WalkDirFunc) error {
+	w := newWalker(dir, opts)
+	return fs.WalkDir(os.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(p, d, err)
+		}
+		if p != "." {
+			skip, err := w.skip(p, d.IsDir())
+			if err != nil {
+				return err
+			}
+			if skip {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+		if d.IsDir() {
+			if err := w.readIgnoreFiles(p); err != nil {
+				return err
+			}
+		}
+		return fn(p, d, err)
+	})
+}
+
+// Sources returns the paths of all files in dir that should be indexed (see Walk) which have one
+// of the given file extensions, e.g. ".go".
+func Sources(dir string, opts Options, extensions ...string) ([]string, error) {
+	var sources []string
+	err := Walk(dir, opts, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err // error walking dir
+		}
+		if !d.IsDir() {
+			ext := filepath.Ext(path)
+			for _, want := range extensions {
+				if ext == want {
+					sources = append(sources, path)
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Walk")
+	}
+	return sources, nil
+}
+
+// Indexed reports whether the file at the slash-separated path p, relative to dir, would be walked
+// by Walk with the given options. The file need not exist, e.g. if it was just deleted.
+func Indexed(dir string, opts Options, p string) (bool, error) {
+	w := newWalker(dir, opts)
+	for _, ancestor := range ancestors(p) {
+		if ancestor != "." {
+			skip, err := w.skip(ancestor, true)
+			if err != nil || skip {
+				return false, err
+			}
+		}
+		if err := w.readIgnoreFiles(ancestor); err != nil {
+			return false, err
+		}
+	}
+	skip, err := w.skip(p, false)
+	return !skip, err
+}
+
+// IsTestFile reports whether the file at the given path is likely to contain tests rather than
+// library code: whether it is within a directory with one of the given names, e.g. "tests", or its
+// name without extension matches one of the given patterns, e.g. "*.test" for foo.test.js.
+func IsTestFile(path string, dirs []string, patterns ...string) bool {
+	name := filepath.Base(path)
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, stem); ok {
+			return true
+		}
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		for _, want := range dirs {
+			if dir == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newWalker(dir string, opts Options) *walker {
+	w := &walker{
+		dir:     dir,
+		rules:   map[string][]ignoreRule{},
+		exclude: parseIgnoreRules(opts.Exclude),
+		include: parseIgnoreRules(opts.Include),
+	}
+	w.rules["."] = parseIgnoreRules(DefaultExcludes)
+	return w
+}
+
+type walker struct {
+	dir string
+
+	// Ignore rules by the directory they were found in.
+	rules map[string][]ignoreRule
+
+	exclude, include []ignoreRule
+}
+
+func (w *walker) skip(p string, isDir bool) (bool, error) {
+	if isDir {
+		// Skip submodules and other nested repositories.
+		if _, err := os.Lstat(filepath.Join(w.dir, filepath.FromSlash(p), ".git")); err == nil {
+			return true, nil
+		} else if !os.IsNotExist(err) {
+			return false, errors.Wrap(err, "Lstat")
+		}
+	}
+
+	// As with .gitignore, the last matching rule wins, and rules in subdirectories take precedence
+	// over those in parent directories.
+	ignored := false
+	for _, ancestor := range ancestors(p) {
+		rel := p
+		if ancestor != "." {
+			rel = strings.TrimPrefix(p, ancestor+"/")
+		}
+		ignored = matchIgnoreRules(w.rules[ancestor], rel, isDir, ignored)
+	}
+	ignored = matchIgnoreRules(w.exclude, p, isDir, ignored)
+	if ignored {
+		return true, nil
+	}
+
+	if !isDir && len(w.include) > 0 {
+		// A file is included if it, or any of its parent directories, match an include pattern.
+		parts := strings.Split(p, "/")
+		for i := range parts {
+			if matchIgnoreRules(w.include, strings.Join(parts[:i+1], "/"), i+1 < len(parts), false) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// ancestors returns the directories containing the slash-separated path p, outermost (".") first.
+func ancestors(p string) []string {
+	var ancestors []string
+	for parent := path.Dir(p); ; parent = path.Dir(parent) {
+		ancestors = append([]string{parent}, ancestors...)
+		if parent == "." {
+			break
+		}
+	}
+	return ancestors
+}
+
+func (w *walker) readIgnoreFiles(dir string) error {
+	for _, name := range IgnoreFiles {
+		data, err := os.ReadFile(filepath.Join(w.dir, filepath.FromSlash(dir), name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "ReadFile")
+		}
+		var lines []string
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		w.rules[dir] = append(w.rules[dir], parseIgnoreRules(lines)...)
+	}
+	return nil
+}
+
+// ignoreRule is a single gitignore-style pattern, see https://git-scm.com/docs/gitignore
+type ignoreRule struct {
+	negate   bool     // pattern began with "!", matching paths are re-included
+	dirOnly  bool     // pattern ended with "/", only matches directories
+	anchored bool     // pattern contains "/", matches relative to the directory it was defined in
+	segments []string // pattern split by "/"
+}
+
+func parseIgnoreRules(lines []string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`) // e.g. `\#file` or `\!file`
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.segments = strings.Split(line, "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// matchIgnoreRules reports whether the slash-separated path (relative to the directory the rules
+// were defined in) is ignored after applying the rules in order, given whether it was ignored
+// before.
+func matchIgnoreRules(rules []ignoreRule, p string, isDir bool, ignored bool) bool {
+	for _, rule := range rules {
+		if rule.match(p, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) match(p string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	parts := strings.Split(p, "/")
+	if !r.anchored {
+		// Patterns without a slash match the name of a file or directory at any depth.
+		ok, _ := path.Match(r.segments[0], parts[len(parts)-1])
+		return ok
+	}
+	return matchSegments(r.segments, parts)
+}
+
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], parts[0])
+	return ok && matchSegments(pattern[1:], parts[1:])
+}
@@ -0,0 +1,123 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexops/autogold"
+)
+
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".gitignore":                  "/build/\n*.gen.go\n",
+		".doctreeignore":              "internal/**/*.go\n!internal/keep/*.go\n",
+		"main.go":                     "",
+		"main.gen.go":                 "",
+		"build/out.go":                "",
+		"vendor/dep/dep.go":           "",
+		"node_modules/pkg/index.js":   "",
+		"lib/testdata/fixture.go":     "",
+		"lib/lib.go":                  "",
+		"lib/.gitignore":              "skip.go\n",
+		"lib/skip.go":                 "",
+		"internal/foo/foo.go":         "",
+		"internal/keep/keep.go":       "",
+		"submodule/.git":              "gitdir: ../.git/modules/submodule\n",
+		"submodule/sub.go":            "",
+		"docs/README.md":              "",
+		"third_party/vendor.go":       "",
+		"third_party/nested/other.go": "",
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{
+				"internal/keep/keep.go",
+				"lib/lib.go",
+				"main.go",
+				"third_party/nested/other.go",
+				"third_party/vendor.go",
+			},
+		},
+		{
+			name: "exclude",
+			opts: Options{Exclude: []string{"third_party/", "!third_party/vendor.go"}},
+			want: []string{"internal/keep/keep.go", "lib/lib.go", "main.go"},
+		},
+		{
+			name: "include",
+			opts: Options{Include: []string{"lib", "third_party/*.go"}, Exclude: []string{"vendor.go"}},
+			want: []string{"lib/lib.go"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Sources(dir, tc.opts, ".go")
+			if err != nil {
+				t.Fatal(err)
+			}
+			autogold.Want(tc.name, tc.want).Equal(t, got)
+
+			// Indexed must agree with Sources.
+			sources := map[string]bool{}
+			for _, path := range got {
+				sources[path] = true
+			}
+			for path := range files {
+				if filepath.Ext(path) != ".go" {
+					continue
+				}
+				indexed, err := Indexed(dir, tc.opts, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if indexed != sources[path] {
+					t.Errorf("Indexed(%q) = %v, want %v", path, indexed, sources[path])
+				}
+			}
+		})
+	}
+}
+
+func TestIndexed_deleted(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "lib"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lib", ".gitignore"), []byte("*.gen.go\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	// None of these files exist, e.g. because they were just deleted.
+	got := map[string]bool{}
+	for _, path := range []string{"main.go", "lib/lib.go", "lib/lib.gen.go", "vendor/dep/dep.go", "docs/README.md"} {
+		indexed, err := Indexed(dir, Options{Exclude: []string{"docs/"}}, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[path] = indexed
+	}
+	autogold.Want("deleted", map[string]bool{
+		"docs/README.md":    false,
+		"lib/lib.gen.go":    false,
+		"lib/lib.go":        true,
+		"main.go":           true,
+		"vendor/dep/dep.go": false,
+	}).Equal(t, got)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -27,294 +28,384 @@ func (i *zigIndexer) Name() schema.Language { return schema.LanguageZig }
 
 func (i *zigIndexer) Extensions() []string { return []string{"zig"} }
 
-func (i *zigIndexer) IndexDir(ctx context.Context, dir string) (*schema.Index, error) {
+func (i *zigIndexer) IndexDir(ctx context.Context, dir string, opts indexer.Options) (*schema.Index, error) {
 	// Find Zig sources
-	var sources []string
-	dirFS := os.DirFS(dir)
-	if err := fs.WalkDir(dirFS, ".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err // error walking dir
-		}
-		if !d.IsDir() {
-			ext := filepath.Ext(path)
-			if ext == ".zig" {
-				sources = append(sources, path)
-			}
-		}
-		return nil
-	}); err != nil {
-		return nil, errors.Wrap(err, "WalkDir")
+	sources, err := indexer.Sources(dir, opts, ".zig")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sources")
 	}
+	dirFS := os.DirFS(dir)
 
+	files := 0
+	bytes := 0
+	var parsed []*zigFile
 	deps := depGraph{}
 	for _, path := range sources {
 		content, err := fs.ReadFile(dirFS, path)
 		if err != nil {
 			return nil, errors.Wrap(err, "ReadFile")
 		}
+		files += 1
+		bytes += len(content)
 
-		// Parse the file with tree-sitter.
-		parser := sitter.NewParser()
-		defer parser.Close()
-		parser.SetLanguage(zig.GetLanguage())
-
-		tree, err := parser.ParseCtx(ctx, nil, content)
-		if err != nil {
-			return nil, errors.Wrap(err, "ParseCtx")
-		}
-		defer tree.Close()
-
-		// Inspect the root node.
-		n := tree.RootNode()
-
-		// Variable declarations
-		{
-			query, err := sitter.NewQuery([]byte(`
-				(
-					"pub"? @pub
-					.
-					(TopLevelDecl
-						(VarDecl
-							variable_type_function:
-							(IDENTIFIER) @var_name
-							(ErrorUnionExpr
-								(SuffixExpr
-									(BUILTINIDENTIFIER)
-									(FnCallArguments
-										(ErrorUnionExpr
-											(SuffixExpr
-												(STRINGLITERALSINGLE)
-											)
-										)
-									)
-								)
-							) @var_expr
-						)
-					)
-				)
-			`), zig.GetLanguage())
+		file := &zigFile{}
+		if !opts.Cache.Get(path, content, file) {
+			file, err = indexFile(ctx, path, content, opts.IncludePrivate)
 			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
+				return nil, errors.Wrap(err, path)
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				pub := firstCaptureContentOr(content, captures["pub"], "") == "pub"
-				varName := firstCaptureContentOr(content, captures["var_name"], "")
-				varExpr := firstCaptureContentOr(content, captures["var_expr"], "")
-
-				if strings.HasPrefix(varExpr, "@import(") {
-					importPath := strings.TrimSuffix(strings.TrimPrefix(varExpr, `@import("`), `")`)
-					deps.insert(path, pub, varName, importPath)
-				}
+			if err := opts.Cache.Put(path, content, file); err != nil {
+				return nil, errors.Wrap(err, "Put")
 			}
 		}
+		for _, record := range file.Imports {
+			deps.insert(record.Path, record.Pub, record.Name, record.ImportPath)
+		}
+		parsed = append(parsed, file)
 	}
 	deps.build()
 
-	files := 0
-	bytes := 0
-	functionsByFile := map[string][]schema.Section{}
-	for _, path := range sources {
-		content, err := fs.ReadFile(dirFS, path)
+	libraries, err := findLibraries(dir, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "findLibraries")
+	}
+
+	var pages []schema.Page
+	for _, file := range parsed {
+		var sections []schema.Section
+		accessiblePath := deps.fileToAccessiblePath[file.Path]
+		for _, category := range []struct {
+			id, label string
+			children  []schema.Section
+		}{
+			{"const", "Constants", file.Constants},
+			{"var", "Variables", file.Variables},
+			{"type", "Types", file.Types},
+			{"fn", "Functions", file.Functions},
+		} {
+			if len(category.children) == 0 {
+				continue
+			}
+			sections = append(sections, schema.Section{
+				ID:         category.id,
+				ShortLabel: category.id,
+				Label:      schema.Markdown(category.label),
+				Category:   true,
+				SearchKey:  []string{},
+				Children:   withAccessiblePath(accessiblePath, category.children),
+			})
+		}
+		if len(sections) == 0 {
+			continue
+		}
+
+		pages = append(pages, schema.Page{
+			Path:      file.Path,
+			Title:     file.Path,
+			Detail:    schema.Markdown(docsToMarkdown(file.Docs)),
+			SearchKey: []string{file.Path},
+			Location:  &schema.Location{Path: file.Path},
+			Sections:  sections,
+		})
+	}
+
+	return &schema.Index{
+		SchemaVersion: schema.LatestVersion,
+		Language:      schema.LanguageZig,
+		NumFiles:      files,
+		NumBytes:      bytes,
+		Libraries:     indexer.GroupLibraries(libraries, pages, indexer.DefaultLibrary(dir)),
+	}, nil
+}
+
+// withAccessiblePath prefixes the search keys of sections (which are relative to their file) and
+// their children with the path the file is accessible by, e.g. "std.mem", if any.
+func withAccessiblePath(accessiblePath string, sections []schema.Section) []schema.Section {
+	for i := range sections {
+		if accessiblePath != "" {
+			sections[i].SearchKey = append([]string{accessiblePath, "."}, sections[i].SearchKey...)
+		}
+		sections[i].Children = withAccessiblePath(accessiblePath, sections[i].Children)
+	}
+	return sections
+}
+
+var (
+	zonName    = regexp.MustCompile(`\.name\s*=\s*(?:"([^"]*)"|\.@?"?([A-Za-z0-9_]+)"?)`)
+	zonVersion = regexp.MustCompile(`\.version\s*=\s*"([^"]*)"`)
+)
+
+// findLibraries finds Zig packages in dir, i.e. directories containing a build.zig.zon file.
+func findLibraries(dir string, opts indexer.Options) ([]indexer.LibraryRoot, error) {
+	zonFiles, err := indexer.FindFiles(dir, opts, "build.zig.zon")
+	if err != nil {
+		return nil, errors.Wrap(err, "FindFiles")
+	}
+	var libraries []indexer.LibraryRoot
+	for _, zonFile := range zonFiles {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(zonFile)))
 		if err != nil {
 			return nil, errors.Wrap(err, "ReadFile")
 		}
-		files += 1
-		bytes += len(content)
+		// The package name and version are the first .name and .version fields, those of
+		// dependencies come after.
+		m := zonName.FindSubmatch(content)
+		if m == nil {
+			continue
+		}
+		name := string(m[1]) + string(m[2])
+		library := schema.Library{Name: name, ID: name}
+		if m := zonVersion.FindSubmatch(content); m != nil {
+			library.Version = string(m[1])
+			library.VersionType = "semver"
+		}
+		libraries = append(libraries, indexer.LibraryRoot{Dir: path.Dir(zonFile), Library: library})
+	}
+	return libraries, nil
+}
 
-		// Parse the file with tree-sitter.
-		parser := sitter.NewParser()
-		defer parser.Close()
-		parser.SetLanguage(zig.GetLanguage())
+// indexFile indexes a single Zig source file. The search keys of declarations are relative to
+// the file, as the path it is accessible by depends on which other files import this one.
+// Declarations which are not pub are only included if includePrivate is true.
+func indexFile(ctx context.Context, path string, content []byte, includePrivate bool) (*zigFile, error) {
+	file := &zigFile{Path: path}
 
-		tree, err := parser.ParseCtx(ctx, nil, content)
-		if err != nil {
-			return nil, errors.Wrap(err, "ParseCtx")
+	// Parse the file with tree-sitter.
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(zig.GetLanguage())
+
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseCtx")
+	}
+	defer tree.Close()
+
+	// Inspect the root node.
+	n := tree.RootNode()
+
+	// Top-level doc comments (//!) document the file.
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		if child := n.NamedChild(i); child.Type() == "container_doc_comment" {
+			file.Docs += child.Content(content)
 		}
-		defer tree.Close()
-
-		// Inspect the root node.
-		n := tree.RootNode()
-
-		// Variable declarations
-		{
-			query, err := sitter.NewQuery([]byte(`
-				(
-					(container_doc_comment)* @container_docs
-					.
-					"pub"? @pub
-					.
-					(TopLevelDecl
-						(VarDecl
-							variable_type_function:
-							(IDENTIFIER) @var_name
-							(ErrorUnionExpr
-								(SuffixExpr
-									(BUILTINIDENTIFIER)
-									(FnCallArguments
-										(ErrorUnionExpr
-											(SuffixExpr
-												(STRINGLITERALSINGLE)
-											)
-										)
-									)
-								)
-							) @var_expr
-						)
-					)
-				)
-			`), zig.GetLanguage())
-			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
+	}
+
+	// Imports, e.g. `pub const mem = @import("mem.zig");`
+	for _, decl := range declarations(n, content) {
+		varDecl := childOfType(decl.node, "VarDecl")
+		if varDecl == nil {
+			continue
+		}
+		value := childAfter(varDecl, "=")
+		if value == nil || !strings.HasPrefix(value.Content(content), `@import("`) {
+			continue
+		}
+		importPath := strings.TrimSuffix(strings.TrimPrefix(value.Content(content), `@import("`), `")`)
+		file.Imports = append(file.Imports, importRecord{
+			Path:       path,
+			Pub:        decl.pub,
+			Name:       varDecl.ChildByFieldName("variable_type_function").Content(content),
+			ImportPath: importPath,
+		})
+	}
+
+	f := &fileIndexer{content: content, path: path, includePrivate: includePrivate}
+	members := f.members(n, nil)
+	file.Constants = members.constants
+	file.Variables = members.variables
+	file.Types = members.types
+	file.Functions = members.functions
+	return file, nil
+}
+
+// declaration is a declaration or field within a container (a struct, enum, union or opaque type,
+// or a file), along with the doc comment and pub keyword preceding it.
+type declaration struct {
+	node *sitter.Node // TopLevelDecl or ContainerField
+	docs string
+	pub  bool
+}
+
+// declarations returns the declarations and fields of a container.
+func declarations(container *sitter.Node, content []byte) []declaration {
+	var (
+		decls []declaration
+		docs  *sitter.Node
+		pub   bool
+	)
+	for i := 0; i < int(container.ChildCount()); i++ {
+		child := container.Child(i)
+		switch child.Type() {
+		case "doc_comment":
+			docs = child
+		case "pub":
+			pub = true
+		case "TopLevelDecl", "ContainerField":
+			if child.Type() == "TopLevelDecl" && childOfType(child, "VarDecl") == nil && childOfType(child, "FnProto") == nil {
+				continue // e.g. the extern of "pub extern fn", which is parsed as a separate declaration
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				containerDocs := firstCaptureContentOr(content, captures["container_docs"], "")
-				pub := firstCaptureContentOr(content, captures["pub"], "") == "pub"
-				varName := firstCaptureContentOr(content, captures["var_name"], "")
-				varExpr := firstCaptureContentOr(content, captures["var_expr"], "")
-
-				_ = containerDocs
-				_ = pub
-				_ = varName
-				_ = varExpr
-				// TODO: emit variables/constants section
+			decl := declaration{node: child, pub: pub}
+			if docs != nil {
+				decl.docs = docs.Content(content)
 			}
+			decls = append(decls, decl)
+			docs, pub = nil, false
+		default:
+			docs, pub = nil, false
 		}
+	}
+	return decls
+}
 
-		// Function definitions
-		{
-			// TODO: This query is incorrectly pulling out methods from nested struct definitions.
-			// So we end up with a flat hierarchy of types - that's very bad. It also means we don't
-			// accurately pick up when a method is part of a parent type.
-			query, err := sitter.NewQuery([]byte(`
-				(
-					(doc_comment)* @func_docs
-					.
-					"pub"? @pub
-					.
-					(TopLevelDecl
-						(FnProto
-							function:
-							(IDENTIFIER) @func_name
-							(ParamDeclList) @func_params
-							(ErrorUnionExpr
-								(SuffixExpr
-									(BuildinTypeExpr)
-								)
-							) @func_result
-						)
-					)
-				)
-			`), zig.GetLanguage())
-			if err != nil {
-				return nil, errors.Wrap(err, "NewQuery")
+// fileIndexer indexes the declarations within a single Zig file.
+type fileIndexer struct {
+	content        []byte
+	path           string
+	includePrivate bool
+}
+
+// containerMembers are the sections documenting the members of a container.
+type containerMembers struct {
+	fields, constants, variables, types, functions []schema.Section
+}
+
+// members returns the sections documenting the members of a container, e.g. the file (the root
+// node) or a struct. Nested containers, e.g. `pub const Point = struct { ... };`, are documented
+// as types, with their own members as children. Imports are omitted.
+func (f *fileIndexer) members(container *sitter.Node, searchKeyPrefix []string) containerMembers {
+	var members containerMembers
+	for _, decl := range declarations(container, f.content) {
+		if decl.node.Type() == "ContainerField" {
+			name := decl.node.ChildByFieldName("field_member")
+			if name == nil || name.Content(f.content) == "_" {
+				continue // e.g. the "_" of a non-exhaustive enum
 			}
-			defer query.Close()
-
-			cursor := sitter.NewQueryCursor()
-			defer cursor.Close()
-			cursor.Exec(query, n)
-
-			for {
-				match, ok := cursor.NextMatch()
-				if !ok {
-					break
-				}
-				captures := getCaptures(query, match)
-
-				pub := firstCaptureContentOr(content, captures["pub"], "")
-				if pub != "pub" {
-					continue
-				}
-
-				funcDocs := firstCaptureContentOr(content, captures["func_docs"], "")
-				funcName := firstCaptureContentOr(content, captures["func_name"], "")
-				funcParams := firstCaptureContentOr(content, captures["func_params"], "")
-				funcResult := firstCaptureContentOr(content, captures["func_result"], "")
-
-				accessiblePath := deps.fileToAccessiblePath[path]
-				var searchKey []string
-				if accessiblePath == "" {
-					searchKey = []string{funcName}
-				} else {
-					searchKey = []string{accessiblePath, ".", funcName}
-				}
-				functionsByFile[path] = append(functionsByFile[path], schema.Section{
-					ID:         funcName,
-					ShortLabel: funcName,
-					Label:      schema.Markdown(funcName + funcParams + " " + funcResult),
-					Detail:     schema.Markdown(docsToMarkdown(funcDocs)),
-					SearchKey:  searchKey,
-				})
+			docs := decl.docs
+			if docComment := childOfType(decl.node, "doc_comment"); docComment != nil {
+				docs = docComment.Content(f.content)
+			}
+			section := f.section(decl.node, searchKeyPrefix, name.Content(f.content), docs, true)
+			section.Label = schema.Markdown(indexer.Truncate(indexer.SingleLine(string(f.content[name.StartByte():decl.node.EndByte()]))))
+			members.fields = append(members.fields, section)
+			continue
+		}
+		if !decl.pub && !f.includePrivate {
+			continue
+		}
+
+		if fnProto := childOfType(decl.node, "FnProto"); fnProto != nil {
+			name := fnProto.ChildByFieldName("function")
+			if name == nil {
+				continue
 			}
+			section := f.section(decl.node, searchKeyPrefix, name.Content(f.content), decl.docs, decl.pub)
+			section.Label = schema.Markdown(strings.TrimPrefix(indexer.SingleLine(fnProto.Content(f.content)), "fn "))
+			members.functions = append(members.functions, section)
+			continue
+		}
+
+		varDecl := childOfType(decl.node, "VarDecl")
+		name := varDecl.ChildByFieldName("variable_type_function")
+		value := childAfter(varDecl, "=")
+		if name == nil || (value != nil && strings.HasPrefix(value.Content(f.content), "@import(")) {
+			continue
+		}
+		section := f.section(decl.node, searchKeyPrefix, name.Content(f.content), decl.docs, decl.pub)
+		label := strings.TrimSuffix(varDecl.Content(f.content), ";")
+		if nested := containerDecl(value); nested != nil {
+			// e.g. "const Point = struct", without the body.
+			if brace := childOfType(nested, "{"); brace != nil {
+				label = string(f.content[varDecl.StartByte():brace.StartByte()])
+			}
+			section.Label = schema.Markdown(indexer.SingleLine(label))
+			nestedMembers := f.members(nested, section.SearchKey)
+			section.Children = append(section.Children, nestedMembers.fields...)
+			section.Children = append(section.Children, nestedMembers.constants...)
+			section.Children = append(section.Children, nestedMembers.variables...)
+			section.Children = append(section.Children, nestedMembers.types...)
+			section.Children = append(section.Children, nestedMembers.functions...)
+			members.types = append(members.types, section)
+			continue
+		}
+		section.Label = schema.Markdown(indexer.Truncate(indexer.SingleLine(label)))
+		if varDecl.Child(0).Type() == "var" {
+			members.variables = append(members.variables, section)
+		} else {
+			members.constants = append(members.constants, section)
 		}
 	}
+	return members
+}
 
-	var pages []schema.Page
-	for path, functions := range functionsByFile {
-		functionsSection := schema.Section{
-			ID:         "fn",
-			ShortLabel: "fn",
-			Label:      "Functions",
-			Category:   true,
-			SearchKey:  []string{},
-			Children:   functions,
+// section returns a section documenting the named declaration, without a label.
+func (f *fileIndexer) section(decl *sitter.Node, searchKeyPrefix []string, name, docs string, pub bool) schema.Section {
+	searchKey := []string{name}
+	if len(searchKeyPrefix) > 0 {
+		searchKey = append(append(append([]string{}, searchKeyPrefix...), "."), name)
+	}
+	visibility := schema.VisibilityPublic
+	if !pub {
+		visibility = schema.VisibilityPrivate
+	}
+	return schema.Section{
+		ID:         strings.Join(searchKey, ""),
+		ShortLabel: name,
+		Visibility: visibility,
+		Detail:     schema.Markdown(docsToMarkdown(docs)),
+		SearchKey:  searchKey,
+		Location:   indexer.NodeLocation(f.path, decl),
+	}
+}
+
+// containerDecl returns the container (struct, enum, union or opaque type) declared by an
+// expression, e.g. `struct { ... }`, or nil if it is not a container declaration.
+func containerDecl(expr *sitter.Node) *sitter.Node {
+	for expr != nil && (expr.Type() == "ErrorUnionExpr" || expr.Type() == "SuffixExpr") && expr.NamedChildCount() == 1 {
+		expr = expr.NamedChild(0)
+	}
+	if expr != nil && expr.Type() == "ContainerDecl" {
+		return expr
+	}
+	return nil
+}
+
+// childOfType returns the first child of node with the given type, or nil.
+func childOfType(node *sitter.Node, typ string) *sitter.Node {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if child := node.Child(i); child.Type() == typ {
+			return child
 		}
+	}
+	return nil
+}
 
-		pages = append(pages, schema.Page{
-			Path:      path,
-			Title:     path,
-			Detail:    schema.Markdown("TODO"),
-			SearchKey: []string{path},
-			Sections:  []schema.Section{functionsSection},
-		})
+// childAfter returns the child of node following the given token, e.g. the value after "=", or nil.
+func childAfter(node *sitter.Node, token string) *sitter.Node {
+	for i := 0; i+1 < int(node.ChildCount()); i++ {
+		if node.Child(i).Type() == token {
+			return node.Child(i + 1)
+		}
 	}
+	return nil
+}
 
-	return &schema.Index{
-		SchemaVersion: schema.LatestVersion,
-		Language:      schema.LanguageZig,
-		NumFiles:      files,
-		NumBytes:      bytes,
-		Libraries: []schema.Library{
-			{
-				Name:        "TODO",
-				ID:          "TODO",
-				Version:     "TODO",
-				VersionType: "TODO",
-				Pages:       pages,
-			},
-		},
-	}, nil
+// zigFile is the result of indexing a single Zig source file, as stored in the indexer.FileCache.
+type zigFile struct {
+	Path      string           `json:"path"`
+	Docs      string           `json:"docs"`
+	Imports   []importRecord   `json:"imports"`
+	Constants []schema.Section `json:"constants"`
+	Variables []schema.Section `json:"variables"`
+	Types     []schema.Section `json:"types"`
+	Functions []schema.Section `json:"functions"`
 }
 
 type importRecord struct {
-	path       string
-	pub        bool
-	name       string
-	importPath string
+	Path       string `json:"path"`
+	Pub        bool   `json:"pub"`
+	Name       string `json:"name"`
+	ImportPath string `json:"importPath"`
 }
 
 type depGraph struct {
@@ -323,7 +414,7 @@ type depGraph struct {
 }
 
 func (d *depGraph) insert(path string, pub bool, name, importPath string) {
-	d.records = append(d.records, importRecord{path, pub, name, importPath})
+	d.records = append(d.records, importRecord{Path: path, Pub: pub, Name: name, ImportPath: importPath})
 	if d.fileToAccessiblePath == nil {
 		d.fileToAccessiblePath = map[string]string{}
 	}
@@ -340,18 +431,18 @@ func (d *depGraph) build() {
 
 func (d *depGraph) collect(targetPath string, result []string, cyclic map[string]struct{}) []string {
 	for _, record := range d.records {
-		if !record.pub {
+		if !record.Pub {
 			continue
 		}
-		if strings.HasSuffix(record.importPath, ".zig") {
-			record.importPath = path.Join(path.Dir(record.path), record.importPath)
+		if strings.HasSuffix(record.ImportPath, ".zig") {
+			record.ImportPath = path.Join(path.Dir(record.Path), record.ImportPath)
 		}
-		if record.importPath == targetPath {
-			if _, ok := cyclic[record.path]; ok {
+		if record.ImportPath == targetPath {
+			if _, ok := cyclic[record.Path]; ok {
 				return result
 			}
-			cyclic[record.path] = struct{}{}
-			return d.collect(record.path, append([]string{record.name}, result...), cyclic)
+			cyclic[record.Path] = struct{}{}
+			return d.collect(record.Path, append([]string{record.Name}, result...), cyclic)
 		}
 	}
 	return result
@@ -359,31 +450,16 @@ func (d *depGraph) collect(targetPath string, result []string, cyclic map[string
 
 func docsToMarkdown(docs string) string {
 	var out []string
-	for _, s := range strings.Split(docs, "\n") {
-		if strings.HasPrefix(s, "/// ") {
-			out = append(out, strings.TrimPrefix(s, "/// "))
+	for _, s := range strings.Split(strings.TrimSpace(docs), "\n") {
+		s = strings.TrimSpace(s)
+		if strings.HasPrefix(s, "///") {
+			out = append(out, strings.TrimPrefix(strings.TrimPrefix(s, "///"), " "))
 			continue
-		} else if strings.HasPrefix(s, "//! ") {
-			out = append(out, strings.TrimPrefix(s, "//! "))
+		} else if strings.HasPrefix(s, "//!") {
+			out = append(out, strings.TrimPrefix(strings.TrimPrefix(s, "//!"), " "))
 			continue
 		}
 		out = append(out, strings.TrimPrefix(s, "// "))
 	}
 	return strings.Join(out, "\n")
 }
-
-func firstCaptureContentOr(content []byte, captures []*sitter.Node, defaultValue string) string {
-	if len(captures) > 0 {
-		return captures[0].Content(content)
-	}
-	return defaultValue
-}
-
-func getCaptures(q *sitter.Query, m *sitter.QueryMatch) map[string][]*sitter.Node {
-	captures := map[string][]*sitter.Node{}
-	for _, c := range m.Captures {
-		cname := q.CaptureNameForId(c.Index)
-		captures[cname] = append(captures[cname], c.Node)
-	}
-	return captures
-}
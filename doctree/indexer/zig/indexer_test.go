@@ -0,0 +1,141 @@
+package zig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/doctree/doctree/indexer"
+	"github.com/sourcegraph/doctree/doctree/schema"
+)
+
+func Test_docsToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		docs string
+		want string
+	}{
+		{
+			name: "doc-comment",
+			docs: "/// Adds.\n///\n///    indented\n///no space",
+			want: "Adds.\n\n   indented\nno space",
+		},
+		{
+			name: "container-doc-comment",
+			docs: "//! File docs.\n//! More.",
+			want: "File docs.\nMore.",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			autogold.Want(tc.name, tc.want).Equal(t, docsToMarkdown(tc.docs))
+		})
+	}
+}
+
+func Test_indexFile(t *testing.T) {
+	file, err := indexFile(context.Background(), "geom.zig", []byte(`//! Geometry.
+const std = @import("std");
+pub const mem = @import("mem.zig");
+
+/// The origin.
+pub const origin = Point{ .x = 0, .y = 0 };
+
+/// A counter.
+pub var count: usize = 0;
+
+const hidden = 1;
+
+/// A point.
+pub const Point = struct {
+    /// The x coordinate.
+    x: i32,
+    y: i32 = 0,
+
+    /// Adds.
+    pub fn add(a: Point, b: Point) Point {
+        return .{ .x = a.x + b.x, .y = a.y + b.y };
+    }
+};
+
+pub const Color = enum { red, green, _ };
+
+/// Frees.
+pub extern fn free(ptr: *anyopaque) void;
+`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each section as "ID: label: detail"
+	var got []string
+	var add func(sections []schema.Section)
+	add = func(sections []schema.Section) {
+		for _, section := range sections {
+			got = append(got, section.ID+": "+string(section.Label)+": "+string(section.Detail))
+			add(section.Children)
+		}
+	}
+	add(file.Constants)
+	add(file.Variables)
+	add(file.Types)
+	add(file.Functions)
+
+	autogold.Want("docs", "//! Geometry.\n").Equal(t, file.Docs)
+	autogold.Want("imports", []importRecord{
+		{Path: "geom.zig", Name: "std", ImportPath: "std"},
+		{Path: "geom.zig", Pub: true, Name: "mem", ImportPath: "mem.zig"},
+	}).Equal(t, file.Imports)
+	autogold.Want("sections", []string{
+		"origin: const origin = Point{ .x = 0, .y = 0 }: The origin.",
+		"count: var count: usize = 0: A counter.",
+		"Point: const Point = struct: A point.",
+		"Point.x: x: i32: The x coordinate.",
+		"Point.y: y: i32 = 0: ",
+		"Point.add: add(a: Point, b: Point) Point: Adds.",
+		"Color: const Color = enum: ",
+		"Color.red: red: ",
+		"Color.green: green: ",
+		"free: free(ptr: *anyopaque) void: Frees.",
+	}).Equal(t, got)
+}
+
+func Test_findLibraries(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"build.zig.zon": `.{
+    .name = "root",
+    .version = "0.1.0",
+    .dependencies = .{
+        .dep = .{ .name = "dep", .version = "9.9.9" },
+    },
+}
+`,
+		"libs/enum/build.zig.zon":    ".{ .name = .enum_name, .version = \"1.0.0\" }\n",
+		"libs/quoted/build.zig.zon":  ".{ .name = .@\"quoted\" }\n",
+		"libs/unnamed/build.zig.zon": ".{ .version = \"1.0.0\" }\n",
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	libraries, err := findLibraries(dir, indexer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, library := range libraries {
+		got = append(got, library.Dir+": "+library.Library.Name+" "+library.Library.Version)
+	}
+	autogold.Want("libraries", []string{
+		".: root 0.1.0", "libs/enum: enum_name 1.0.0",
+		"libs/quoted: quoted ",
+	}).Equal(t, got)
+}
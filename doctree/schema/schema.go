@@ -36,6 +36,13 @@ type Index struct {
 	// Empty string if the indexed directory was not a Git repository.
 	GitRefName string `json:"gitRefName"`
 
+	// GitDirectory is the path of the indexed directory relative to the root of the Git repository,
+	// as reported by `git rev-parse --show-prefix`, e.g. "src/" - or an empty string if the root
+	// of the repository was indexed.
+	//
+	// Empty string if the indexed directory was not a Git repository.
+	GitDirectory string `json:"gitDirectory"`
+
 	// CreatedAt time of the index (RFC3339)
 	CreatedAt string `json:"createdAt"`
 
@@ -73,6 +80,7 @@ var (
 	LanguageJavaScript = Language{Title: "JavaScript", ID: "javascript"}
 	LanguageObjC       = Language{Title: "Objective-C", ID: "objc"}
 	LanguagePython     = Language{Title: "Python", ID: "python"}
+	LanguageRust       = Language{Title: "Rust", ID: "rust"}
 	LanguageTypeScript = Language{Title: "TypeScript", ID: "typescript"}
 	LanguageZig        = Language{Title: "Zig", ID: "zig"}
 	LanguageMarkdown   = Language{Title: "Markdown", ID: "markdown"}
@@ -112,6 +120,16 @@ type Page struct {
 	// The detail
 	Detail Markdown `json:"detail"`
 
+	// Description is a short summary of the page, if any, e.g. from Markdown frontmatter.
+	Description string `json:"description,omitempty"`
+
+	// Tags of the page, if any, e.g. from Markdown frontmatter.
+	Tags []string `json:"tags,omitempty"`
+
+	// Weight orders the page relative to the other pages of its library: pages with a lower
+	// weight come first, and those with the same weight are ordered by path.
+	Weight int `json:"weight,omitempty"`
+
 	// SearchKey describes a single string a user would type in to a search bar to find this
 	// page. For example, in Go this might be "net/http"
 	//
@@ -124,6 +142,10 @@ type Page struct {
 	// name for you.)
 	SearchKey []string `json:"searchKey"`
 
+	// Location of the page in the source code, if any. For pages that span many files (e.g. a Go
+	// package) this describes the primary file, and the line/column range is left empty.
+	Location *Location `json:"location,omitempty"`
+
 	// Sections on the page.
 	Sections []Section `json:"sections"`
 
@@ -162,6 +184,11 @@ type Section struct {
 	// The label of this section.
 	Label Markdown `json:"label"`
 
+	// LabelLinks are links for identifiers appearing in the Label which refer to other pages or
+	// sections in the same project, in the order they appear in the label. For example, the label
+	// `func NewClient() *Client` may link "Client" to the section describing that type.
+	LabelLinks []Link `json:"labelLinks,omitempty"`
+
 	// The detail
 	Detail Markdown `json:"detail"`
 
@@ -178,11 +205,70 @@ type Section struct {
 	// name for you.)
 	SearchKey []string `json:"searchKey"`
 
+	// Location of this section in the source code, e.g. where the function or class it documents
+	// was declared.
+	Location *Location `json:"location,omitempty"`
+
+	// Visibility of the symbol this section documents. Private sections are only produced when
+	// indexing with private symbols included, e.g. for internal documentation.
+	Visibility Visibility `json:"visibility,omitempty"`
+
+	// Deprecated indicates the symbol this section documents should no longer be used, e.g. because
+	// its documentation has a "Deprecated:" paragraph. Deprecated sections rank lower in search.
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// Platforms this section exists on, e.g. ["linux/amd64", "darwin/amd64"], for code which is
+	// only built for some of the platforms that were indexed. Empty if it exists on all of them.
+	Platforms []string `json:"platforms,omitempty"`
+
 	// Any children sections. For example, if this section represents a class the children could be
 	// the methods of the class and they would be rendered immediately below this section and
 	// indicated as being children of the parent section.
 	Children []Section `json:"children"`
 }
 
+// Visibility describes whether a symbol is part of the public API of a library.
+type Visibility string
+
+const (
+	// VisibilityPublic is the visibility of exported symbols, e.g. Go identifiers starting with an
+	// upper-case letter. An empty Visibility is equivalent.
+	VisibilityPublic Visibility = "public"
+
+	// VisibilityPrivate is the visibility of symbols which are not exported, e.g. Go identifiers
+	// starting with a lower-case letter or Python names starting with an underscore.
+	VisibilityPrivate Visibility = "private"
+)
+
+// Link is a hyperlink from a piece of text to a page or section within the same project.
+type Link struct {
+	// Text that is linked, e.g. "Client"
+	Text string `json:"text"`
+
+	// URL of the page or section being linked to, e.g. "/github.com/foo/bar/-/go/-/baz?id=Client"
+	URL string `json:"url"`
+}
+
+// Location describes a range of source code in the directory that was indexed.
+type Location struct {
+	// Path of the file relative to the indexed directory, e.g. "net/http/client.go"
+	Path string `json:"path"`
+
+	// StartLine and StartColumn of the range (1-based.) Zero if the location refers to an entire
+	// file.
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+
+	// EndLine and EndColumn of the range (1-based.) The end column is exclusive. Zero if the
+	// location refers to an entire file.
+	EndLine   int `json:"endLine"`
+	EndColumn int `json:"endColumn"`
+
+	// URL is a permalink to this location on the code host, at the commit that was indexed. It is
+	// not stored in indexes, but populated by the doctree server based on Index.GitRepository and
+	// Index.GitCommitID when serving pages.
+	URL string `json:"url,omitempty"`
+}
+
 // Markdown text.
 type Markdown string
@@ -67,6 +67,7 @@ searchResultDecoder =
         |> Pipeline.required "path" Decode.string
         |> Pipeline.required "id" Decode.string
         |> Pipeline.required "score" Decode.float
+        |> Pipeline.optional "deprecated" Decode.bool False
 
 
 type alias SearchResult =
@@ -76,4 +77,5 @@ type alias SearchResult =
     , path : String
     , id : String
     , score : Float
+    , deprecated : Bool
     }
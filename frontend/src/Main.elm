@@ -371,6 +371,9 @@ mapProjectMsg msg =
         Project.ReplaceUrlSilently newUrl ->
             ReplaceUrlSilently newUrl
 
+        Project.TogglePrivate ->
+            ProjectPageUpdate Project.UpdateTogglePrivate
+
 
 {-| Whether or not the two ProjectLanguagePage routes are equal
 except for the sectionID query parameter
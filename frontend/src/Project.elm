@@ -32,6 +32,7 @@ type alias Model =
     { pageID : Maybe API.PageID
     , page : Maybe (Result Http.Error APISchema.Page)
     , inViewSection : String
+    , showPrivate : Bool
     }
 
 
@@ -62,13 +63,13 @@ init route =
     case route of
         -- TODO: use searchQuery parameter
         Project projectName _ ->
-            ( { pageID = Nothing, page = Nothing, inViewSection = "" }
+            ( { pageID = Nothing, page = Nothing, inViewSection = "", showPrivate = False }
             , Task.succeed () |> Task.perform (\_ -> GetProject projectName)
             )
 
         -- TODO: use searchQuery parameter
         ProjectLanguage projectName _ _ ->
-            ( { pageID = Nothing, page = Nothing, inViewSection = "" }
+            ( { pageID = Nothing, page = Nothing, inViewSection = "", showPrivate = False }
             , Task.succeed () |> Task.perform (\_ -> GetProject projectName)
             )
 
@@ -81,7 +82,7 @@ init route =
                     , pagePath = pagePath
                     }
             in
-            ( { pageID = Just pageID, page = Nothing, inViewSection = "" }
+            ( { pageID = Just pageID, page = Nothing, inViewSection = "", showPrivate = False }
             , Cmd.batch
                 [ API.fetchPage GotPage pageID
                 , case sectionID of
@@ -150,6 +151,7 @@ type Msg
     | OnObserved (Result Json.Decode.Error (List Ports.ObserveEvent))
     | ScrollIntoViewLater String
     | ReplaceUrlSilently String
+    | TogglePrivate
 
 
 type UpdateMsg
@@ -159,6 +161,7 @@ type UpdateMsg
     | UpdateOnObserved (Result Json.Decode.Error (List Ports.ObserveEvent))
     | UpdateScrollIntoViewLater String
     | NavigateToSectionID (Maybe SectionID)
+    | UpdateTogglePrivate
 
 
 update : UpdateMsg -> Model -> ( Model, Cmd Msg )
@@ -279,6 +282,9 @@ update msg model =
                 Nothing ->
                     ( model, Cmd.none )
 
+        UpdateTogglePrivate ->
+            ( { model | showPrivate = not model.showPrivate }, Cmd.none )
+
 
 subscriptions : Model -> Sub Msg
 subscriptions _ =
@@ -388,48 +394,19 @@ viewProjectLanguage _ projectIndexesRequest projectName language _ cloudMode _ =
                         in
                         case indexLookup of
                             Just index ->
-                                let
-                                    listHead =
-                                        List.head index.libraries
-                                in
-                                case listHead of
-                                    Just library ->
-                                        E.layout (List.concat [ Style.layout, [ E.width E.fill ] ])
-                                            (E.column [ E.centerX, E.paddingXY 0 32 ]
-                                                [ E.row []
-                                                    [ E.link [] { url = "/", label = logo }
-                                                    , E.el [ Region.heading 1, Font.size 24 ] (E.text (String.concat [ " / ", projectName ]))
-                                                    ]
-                                                , E.row [ E.width E.fill, E.paddingXY 0 64 ]
-                                                    -- TODO: Should UI sort pages, or indexers themselves decide order? Probably the latter?
-                                                    [ E.column [ E.width (E.fillPortion 1) ]
-                                                        (List.map
-                                                            (\docPage ->
-                                                                E.link [ E.width (E.fillPortion 1) ]
-                                                                    { url =
-                                                                        Url.Builder.absolute [ projectName, "-", selectedLanguage, "-", docPage.path ] []
-                                                                    , label = E.el [ Font.underline ] (E.text docPage.path)
-                                                                    }
-                                                            )
-                                                            (List.sortBy .path library.pages)
-                                                        )
-                                                    , E.column [ E.width (E.fillPortion 1) ]
-                                                        (List.map
-                                                            (\docPage ->
-                                                                E.link [ E.width (E.fillPortion 1) ]
-                                                                    { url =
-                                                                        Url.Builder.absolute [ projectName, "-", selectedLanguage, "-", docPage.path ] []
-                                                                    , label = E.el [ Font.underline ] (E.text docPage.title)
-                                                                    }
-                                                            )
-                                                            (List.sortBy .path library.pages)
-                                                        )
-                                                    ]
+                                if List.isEmpty index.libraries then
+                                    E.layout Style.layout (E.text "error: invalid index: must have at least on library")
+
+                                else
+                                    E.layout (List.concat [ Style.layout, [ E.width E.fill ] ])
+                                        (E.column [ E.centerX, E.paddingXY 0 32 ]
+                                            (E.row []
+                                                [ E.link [] { url = "/", label = logo }
+                                                , E.el [ Region.heading 1, Font.size 24 ] (E.text (String.concat [ " / ", projectName ]))
                                                 ]
+                                                :: List.map (viewLibrary projectName selectedLanguage (List.length index.libraries > 1)) index.libraries
                                             )
-
-                                    Nothing ->
-                                        E.layout Style.layout (E.text "error: invalid index: must have at least on library")
+                                        )
 
                             Nothing ->
                                 E.layout Style.layout (E.text "language not found")
@@ -447,6 +424,41 @@ viewProjectLanguage _ projectIndexesRequest projectName language _ cloudMode _ =
     }
 
 
+viewLibrary : ProjectName -> Language -> Bool -> Schema.Library -> E.Element Msg
+viewLibrary projectName language showHeading library =
+    let
+        pageLinks label =
+            List.map
+                (\docPage ->
+                    E.link [ E.width (E.fillPortion 1) ]
+                        { url =
+                            Url.Builder.absolute [ projectName, "-", language, "-", docPage.path ] []
+                        , label = E.el [ Font.underline ] (E.text (label docPage))
+                        }
+                )
+                (List.sortBy (\docPage -> ( docPage.weight, docPage.path )) library.pages)
+    in
+    E.column [ E.width E.fill, E.paddingXY 0 32, E.spacing 16 ]
+        [ if showHeading then
+            E.el [ Region.heading 2, Font.size 20 ]
+                (E.text
+                    (if library.version == "" || library.versionType == "commit" then
+                        library.name
+
+                     else
+                        String.concat [ library.name, " ", library.version ]
+                    )
+                )
+
+          else
+            E.none
+        , E.row [ E.width E.fill ]
+            [ E.column [ E.width (E.fillPortion 1) ] (pageLinks .path)
+            , E.column [ E.width (E.fillPortion 1) ] (pageLinks .title)
+            ]
+        ]
+
+
 viewProjectLanguagePage :
     Search.Model
     -> ProjectName
@@ -468,6 +480,9 @@ viewProjectLanguagePage _ projectName language pagePath _ searchQuery model =
                                 case docPage.subpages of
                                     Schema.Pages v ->
                                         v
+
+                            sections =
+                                visibleSections model.showPrivate docPage.sections
                         in
                         E.layout (List.concat [ Style.layout, [ E.width E.fill, E.height E.fill ] ])
                             (E.row
@@ -489,7 +504,7 @@ viewProjectLanguagePage _ projectName language pagePath _ searchQuery model =
                                         , pagePath = pagePath
                                         , searchQuery = searchQuery
                                         , inViewSection = model.inViewSection
-                                        , docPage = docPage
+                                        , docPage = { docPage | sections = sections }
                                         }
                                     )
                                 , E.row
@@ -509,6 +524,8 @@ viewProjectLanguagePage _ projectName language pagePath _ searchQuery model =
                                             , E.el [ Region.heading 1, Font.size 20 ] (E.text (String.concat [ " : ", String.toLower docPage.title ]))
                                             ]
                                         , Style.h1 [] (E.text docPage.title)
+                                        , viewSourceLink docPage.location
+                                        , viewPageMetadata docPage
                                         , E.el [ E.paddingXY 0 16 ] (Markdown.render docPage.detail)
                                         , if List.length subpages > 0 then
                                             E.column []
@@ -518,11 +535,16 @@ viewProjectLanguagePage _ projectName language pagePath _ searchQuery model =
                                                     ]
                                                 )
 
+                                          else
+                                            E.none
+                                        , if hasPrivateSections docPage.sections then
+                                            viewPrivateToggle model.showPrivate
+
                                           else
                                             E.none
                                         , Element.Lazy.lazy
                                             (\v1 -> renderSections v1)
-                                            docPage.sections
+                                            sections
                                         ]
                                     ]
                                 ]
@@ -560,6 +582,53 @@ sectionIDs sections =
         list
 
 
+-- visibleSections removes private sections (and their children) unless showPrivate is true.
+
+
+visibleSections : Bool -> Schema.Sections -> Schema.Sections
+visibleSections showPrivate sections =
+    case sections of
+        Schema.Sections list ->
+            Schema.Sections
+                (List.filterMap
+                    (\section ->
+                        if section.visibility == "private" && not showPrivate then
+                            Nothing
+
+                        else
+                            Just { section | children = visibleSections showPrivate section.children }
+                    )
+                    list
+                )
+
+
+hasPrivateSections : Schema.Sections -> Bool
+hasPrivateSections sections =
+    case sections of
+        Schema.Sections list ->
+            List.any (\section -> section.visibility == "private" || hasPrivateSections section.children) list
+
+
+viewPrivateToggle : Bool -> E.Element Msg
+viewPrivateToggle showPrivate =
+    Element.Input.button
+        [ E.paddingEach { top = 0, right = 0, bottom = 16, left = 0 }
+        , Font.size 14
+        , Font.underline
+        , Font.color (E.rgb255 100 100 100)
+        ]
+        { onPress = Just TogglePrivate
+        , label =
+            E.text
+                (if showPrivate then
+                    "hide private symbols"
+
+                 else
+                    "show private symbols"
+                )
+        }
+
+
 renderSections : Schema.Sections -> E.Element Msg
 renderSections sections =
     let
@@ -582,9 +651,11 @@ renderSection section =
 
               else
                 Style.h3 [ E.paddingXY 0 8, E.htmlAttribute (Html.Attributes.id section.id) ]
-                    (E.text
-                        (String.concat [ "# ", section.label ])
-                    )
+                    (E.paragraph [] (E.text "# " :: labelWithLinks section.label section.labelLinks))
+            , viewSourceLink section.location
+            , viewPrivate section.visibility
+            , viewDeprecated section.deprecated
+            , viewPlatforms section.platforms
             , if section.detail == "" then
                 E.none
 
@@ -602,6 +673,105 @@ renderSection section =
         ]
 
 
+-- labelWithLinks renders a section label, turning the text of each link (in the order they appear in
+-- the label) into a hyperlink.
+
+
+labelWithLinks : String -> List Schema.Link -> List (E.Element msg)
+labelWithLinks label links =
+    case links of
+        [] ->
+            [ E.text label ]
+
+        link :: rest ->
+            case List.head (String.indexes link.text label) of
+                Just index ->
+                    E.text (String.left index label)
+                        :: E.link [ Font.underline ] { url = link.url, label = E.text link.text }
+                        :: labelWithLinks (String.dropLeft (index + String.length link.text) label) rest
+
+                Nothing ->
+                    labelWithLinks label rest
+
+
+viewSourceLink : Maybe Schema.Location -> E.Element msg
+viewSourceLink location =
+    case Maybe.andThen .url location of
+        Just url ->
+            E.newTabLink
+                [ E.paddingEach { top = 0, right = 0, bottom = 8, left = 0 }
+                , Font.size 14
+                , Font.color (E.rgb255 100 100 100)
+                ]
+                { url = url, label = E.text "view source" }
+
+        Nothing ->
+            E.none
+
+
+viewPageMetadata : Schema.Page -> E.Element msg
+viewPageMetadata docPage =
+    E.column [ E.spacing 8 ]
+        [ if docPage.description /= "" then
+            E.paragraph [ Font.italic ] [ E.text docPage.description ]
+
+          else
+            E.none
+        , if List.isEmpty docPage.tags then
+            E.none
+
+          else
+            E.el
+                [ Font.size 14
+                , Font.color (E.rgb255 100 100 100)
+                ]
+                (E.text (String.concat [ "tags: ", String.join ", " docPage.tags ]))
+        ]
+
+
+viewPrivate : String -> E.Element msg
+viewPrivate visibility =
+    if visibility == "private" then
+        E.el
+            [ E.paddingEach { top = 0, right = 0, bottom = 8, left = 0 }
+            , Font.size 14
+            , Font.color (E.rgb255 100 100 100)
+            ]
+            (E.text "private")
+
+    else
+        E.none
+
+
+viewDeprecated : Bool -> E.Element msg
+viewDeprecated deprecated =
+    if deprecated then
+        E.el
+            [ E.paddingEach { top = 0, right = 0, bottom = 8, left = 0 }
+            , Font.size 14
+            , Font.bold
+            , Font.color (E.rgb255 180 80 0)
+            ]
+            (E.text "deprecated")
+
+    else
+        E.none
+
+
+viewPlatforms : List String -> E.Element msg
+viewPlatforms platforms =
+    if List.isEmpty platforms then
+        E.none
+
+    else
+        E.el
+            [ E.paddingEach { top = 0, right = 0, bottom = 8, left = 0 }
+            , Font.size 14
+            , Font.color (E.rgb255 100 100 100)
+            ]
+            (E.text (String.concat [ "only on ", String.join ", " platforms ]))
+
+
 logo =
     E.row [ E.centerX ]
         [ E.image
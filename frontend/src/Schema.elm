@@ -103,7 +103,11 @@ pageDecoder =
         |> Pipeline.required "path" Decode.string
         |> Pipeline.required "title" Decode.string
         |> Pipeline.required "detail" Decode.string
+        |> Pipeline.optional "description" Decode.string ""
+        |> Pipeline.optional "tags" (Decode.list Decode.string) []
+        |> Pipeline.optional "weight" Decode.int 0
         |> Pipeline.required "searchKey" (Decode.list Decode.string)
+        |> Pipeline.optional "location" (Decode.nullable locationDecoder) Nothing
         |> Pipeline.required "sections" (Decode.lazy (\_ -> sectionsDecoder))
         |> Pipeline.optional "subpages" (Decode.lazy (\_ -> pagesDecoder)) (Pages [])
 
@@ -116,6 +120,13 @@ type alias Page =
       title : String
     , -- The detail
       detail : Markdown
+    , -- Description is a short summary of the page, if any, e.g. from Markdown frontmatter.
+      description : String
+    , -- Tags of the page, if any, e.g. from Markdown frontmatter.
+      tags : List String
+    , -- Weight orders the page relative to the other pages of its library: pages with a lower
+      -- weight come first, and those with the same weight are ordered by path.
+      weight : Int
 
     -- SearchKey describes a single string a user would type in to a search bar to find this
     -- page. For example, in Go this might be "net/http"
@@ -126,6 +137,9 @@ type alias Page =
     -- indexed (you can imagine the key is prefixed with the language name and directory/repository
     -- name for you.)
     , searchKey : List String
+    , -- Location of the page in the source code, if any. For pages that span many files (e.g. a Go
+      -- package) this describes the primary file, and the line/column range is left empty.
+      location : Maybe Location
     , -- Sections of the page.
       sections : Sections
     , -- Subpages of this one.
@@ -150,6 +164,11 @@ sectionDecoder =
         |> Pipeline.required "label" Decode.string
         |> Pipeline.required "detail" Decode.string
         |> Pipeline.required "searchKey" (Decode.list Decode.string)
+        |> Pipeline.optional "location" (Decode.nullable locationDecoder) Nothing
+        |> Pipeline.optional "labelLinks" (Decode.list linkDecoder) []
+        |> Pipeline.optional "visibility" Decode.string ""
+        |> Pipeline.optional "deprecated" Decode.bool False
+        |> Pipeline.optional "platforms" (Decode.list Decode.string) []
         |> Pipeline.optional "children" (Decode.lazy (\_ -> sectionsDecoder)) (Sections [])
 
 
@@ -181,6 +200,21 @@ type alias Section =
     -- indexed (you can imagine the key is prefixed with the language name and directory/repository
     -- name for you.)
     , searchKey : List String
+    , -- Location of this section in the source code, e.g. where the function or class it documents
+      -- was declared.
+      location : Maybe Location
+    , -- Links for identifiers appearing in the label which refer to other pages or sections in the
+      -- same project, in the order they appear in the label.
+      labelLinks : List Link
+    , -- Visibility of the symbol this section documents, "public" or "private". Private sections
+      -- are only present if the project was indexed with private symbols included. Empty if unknown.
+      visibility : String
+    , -- Deprecated indicates the symbol this section documents should no longer be used, e.g. because
+      -- its documentation has a "Deprecated:" paragraph. Deprecated sections rank lower in search.
+      deprecated : Bool
+    , -- Platforms this section exists on, e.g. ["linux/amd64", "darwin/amd64"], for code which is
+      -- only built for some of the platforms that were indexed. Empty if it exists on all of them.
+      platforms : List String
     , -- Any children sections. For example, if this section represents a class the children could be
       -- the methods of the class and they would be rendered immediately below this section and
       -- indicated as being children of the parent section.
@@ -196,5 +230,47 @@ sectionsDecoder =
     Decode.map Sections <| Decode.list (Decode.lazy (\_ -> sectionDecoder))
 
 
+locationDecoder : Decoder Location
+locationDecoder =
+    Decode.succeed Location
+        |> Pipeline.required "path" Decode.string
+        |> Pipeline.required "startLine" Decode.int
+        |> Pipeline.required "startColumn" Decode.int
+        |> Pipeline.required "endLine" Decode.int
+        |> Pipeline.required "endColumn" Decode.int
+        |> Pipeline.optional "url" (Decode.nullable Decode.string) Nothing
+
+
+type alias Location =
+    { -- Path of the file relative to the indexed directory, e.g. "net/http/client.go"
+      path : String
+    , -- StartLine and StartColumn of the range (1-based.) Zero if the location refers to an entire
+      -- file.
+      startLine : Int
+    , startColumn : Int
+    , -- EndLine and EndColumn of the range (1-based.) The end column is exclusive. Zero if the
+      -- location refers to an entire file.
+      endLine : Int
+    , endColumn : Int
+    , -- URL is a permalink to this location on the code host, at the commit that was indexed.
+      url : Maybe String
+    }
+
+
+linkDecoder : Decoder Link
+linkDecoder =
+    Decode.succeed Link
+        |> Pipeline.required "text" Decode.string
+        |> Pipeline.required "url" Decode.string
+
+
+type alias Link =
+    { -- Text that is linked, e.g. "Client"
+      text : String
+    , -- URL of the page or section being linked to.
+      url : String
+    }
+
+
 type alias Markdown =
     String
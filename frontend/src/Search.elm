@@ -149,7 +149,15 @@ searchResults request =
                                     [ E.column []
                                         [ E.link [ E.paddingEach { top = 0, right = 0, bottom = 4, left = 0 } ]
                                             { url = Url.Builder.absolute [ r.projectName, "-", r.language, "-", r.path ] [ Url.Builder.string "id" r.id ]
-                                            , label = E.el [ Font.underline ] (E.text r.searchKey)
+                                            , label =
+                                                E.el
+                                                    (if r.deprecated then
+                                                        [ Font.underline, Font.strike, Font.color (E.rgb 0.6 0.6 0.6) ]
+
+                                                     else
+                                                        [ Font.underline ]
+                                                    )
+                                                    (E.text r.searchKey)
                                             }
                                         , E.el
                                             [ Font.color (E.rgb 0.6 0.6 0.6)
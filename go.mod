@@ -3,7 +3,7 @@ module github.com/sourcegraph/doctree
 go 1.18
 
 require (
-	github.com/DaivikDave/tree-sitter-jsdoc/bindings/go v0.0.0-20220602053452-e02513edad7b
+	github.com/BurntSushi/toml v1.1.0
 	github.com/NYTimes/gziphandler v1.1.1
 	github.com/adrg/frontmatter v0.2.0
 	github.com/agnivade/levenshtein v1.1.1
@@ -16,10 +16,12 @@ require (
 	github.com/slimsag/tree-sitter-zig/bindings/go v0.0.0-20220513090138-e3dbdff9d013
 	github.com/smacker/go-tree-sitter v0.0.0-20220611151427-2c4b54ed41fe
 	github.com/spaolacci/murmur3 v1.1.0
+	github.com/yuin/goldmark v1.4.12
+	golang.org/x/mod v0.6.0-dev.0.20220106191415-9b9b3d81d5e3
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
 
 require (
-	github.com/BurntSushi/toml v1.1.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/google/go-cmp v0.5.7 // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
@@ -28,12 +30,10 @@ require (
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/nightlyone/lockfile v1.0.0 // indirect
 	github.com/shurcooL/go-goon v0.0.0-20210110234559-7585751d9a17 // indirect
-	golang.org/x/mod v0.6.0-dev.0.20220106191415-9b9b3d81d5e3 // indirect
 	golang.org/x/sys v0.0.0-20220615213510-4f61da869c0c // indirect
 	golang.org/x/tools v0.1.10 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 	mvdan.cc/gofumpt v0.3.0 // indirect
 )